@@ -27,7 +27,7 @@ func runCheck(cmd *cobra.Command, args []string) error {
 
 	printInfo(fmt.Sprintf("正在检查配置文件: %s", cfgPath))
 
-	cfg, err := config.LoadConfigFromFile(cfgPath)
+	cfg, err := config.LoadConfigReadOnly(cfgPath)
 	if err != nil {
 		printError(fmt.Sprintf("配置加载失败: %v", err))
 		return err
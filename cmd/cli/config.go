@@ -1,7 +1,10 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+	"strings"
 
 	"agent/config"
 
@@ -19,7 +22,7 @@ var configCmd = &cobra.Command{
 var configSetCmd = &cobra.Command{
 	Use:   "set [key] [value]",
 	Short: "设置配置项",
-	Long:  `设置配置项的值。支持的key: server, key, log_path, metrics_interval, detail_interval, system_interval, heartbeat_interval, log_retention_days`,
+	Long:  `设置配置项的值。支持的key: server, key, log_path, log_level, display_name, tags, tags.<name>, secrets_backend, encrypt_secrets, legacy_handshake, capabilities, status_page, transform_script, disabled_collectors, metrics_interval, detail_interval, system_interval, heartbeat_interval, package_interval, heartbeat_liveness, failed_logins, log_retention_days, shutdown_timeout, max_clock_skew, session_rotation, keypair_rotation, disable_public_ip, disable_cloud_metadata`,
 	Args:  cobra.ExactArgs(2),
 	RunE:  runConfigSet,
 }
@@ -28,7 +31,7 @@ var configSetCmd = &cobra.Command{
 var configGetCmd = &cobra.Command{
 	Use:   "get [key]",
 	Short: "获取配置项",
-	Long:  `获取配置项的值。支持的key: server, key, log_path, metrics_interval, detail_interval, system_interval, heartbeat_interval, log_retention_days`,
+	Long:  `获取配置项的值。支持的key: server, key, log_path, log_level, display_name, tags, tags.<name>, secrets_backend, encrypt_secrets, legacy_handshake, capabilities, status_page, transform_script, disabled_collectors, metrics_interval, detail_interval, system_interval, heartbeat_interval, package_interval, heartbeat_liveness, failed_logins, log_retention_days, shutdown_timeout, max_clock_skew, session_rotation, keypair_rotation, disable_public_ip, disable_cloud_metadata`,
 	Args:  cobra.ExactArgs(1),
 	RunE:  runConfigGet,
 }
@@ -37,17 +40,48 @@ var configGetCmd = &cobra.Command{
 var configListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "列出所有配置",
-	Long:  `列出所有配置项及其值。`,
+	Long:  `列出所有配置项及其值。使用 --json 输出结构化结果（key 已掩码）。`,
 	RunE:  runConfigList,
 }
 
+// configMigrateCmd 迁移密钥存储
+var configMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "迁移密钥存储",
+	Long:  `将旧版配置文件中的密钥迁移到独立的密钥存储，并按 encrypt_secrets 设置重新保存密钥。Agent 启动时也会自动执行。`,
+	Args:  cobra.NoArgs,
+	RunE:  runConfigMigrate,
+}
+
+var configListJSON bool
+
 func init() {
+	configListCmd.Flags().BoolVar(&configListJSON, "json", false, "以 JSON 格式输出")
 	configCmd.AddCommand(configSetCmd)
 	configCmd.AddCommand(configGetCmd)
 	configCmd.AddCommand(configListCmd)
+	configCmd.AddCommand(configMigrateCmd)
 	rootCmd.AddCommand(configCmd)
 }
 
+func runConfigMigrate(cmd *cobra.Command, args []string) error {
+	cfgPath := configPath
+	if cfgPath == "" {
+		cfgPath = config.GetConfigPath()
+	}
+
+	migrated, err := config.MigrateSecrets(cfgPath)
+	if err != nil {
+		return err
+	}
+	if migrated {
+		printSuccess("密钥已迁移到独立存储")
+	} else {
+		printInfo("密钥存储无需迁移")
+	}
+	return nil
+}
+
 func runConfigSet(cmd *cobra.Command, args []string) error {
 	key := args[0]
 	value := args[1]
@@ -58,11 +92,14 @@ func runConfigSet(cmd *cobra.Command, args []string) error {
 		cfgPath = config.GetConfigPath()
 	}
 
-	// 加载现有配置
-	cfg, err := config.LoadConfigFromFile(cfgPath)
-	if err != nil {
-		// 如果文件不存在，创建新配置
-		cfg = config.Config{}
+	// 加载现有配置，文件不存在时创建新配置
+	// 其他错误（如密钥无法解密）直接返回，避免保存时覆盖原有配置和密钥
+	var cfg config.Config
+	if _, err := os.Stat(cfgPath); err == nil {
+		cfg, err = config.LoadConfigFromFile(cfgPath)
+		if err != nil {
+			return fmt.Errorf("加载配置失败: %w", err)
+		}
 	}
 
 	// 设置配置值
@@ -89,7 +126,7 @@ func runConfigGet(cmd *cobra.Command, args []string) error {
 	}
 
 	// 加载配置
-	cfg, err := config.LoadConfigFromFile(cfgPath)
+	cfg, err := config.LoadConfigReadOnly(cfgPath)
 	if err != nil {
 		return fmt.Errorf("加载配置失败: %w", err)
 	}
@@ -107,14 +144,34 @@ func runConfigGet(cmd *cobra.Command, args []string) error {
 // getConfigDescription 获取配置项的说明
 func getConfigDescription(key string) string {
 	descriptions := map[string]string{
-		"server":             "WebSocket服务器地址",
-		"key":                "Agent通信密钥",
-		"log_path":           "日志文件存储路径",
-		"metrics_interval":   "性能指标上报间隔（秒）",
-		"detail_interval":    "详细信息上报间隔（秒）",
-		"system_interval":    "系统信息上报间隔（秒）",
-		"heartbeat_interval": "心跳间隔（秒）",
-		"log_retention_days": "日志保留天数",
+		"server":              "WebSocket服务器地址",
+		"key":                 "Agent通信密钥",
+		"log_path":            "日志文件存储路径",
+		"display_name":        "面板显示名称",
+		"log_level":           "日志级别（debug/info/warn/error）",
+		"tags":                "主机标签（k=v,k2=v2；单个标签用 tags.<name> 设置，值为空时删除）",
+		"secrets_backend":     "密钥存储后端（file/keyring）",
+		"encrypt_secrets":     "加密存储私钥和会话密钥（true/false）",
+		"legacy_handshake":    "仅使用 RSA+AES 握手（true/false）",
+		"capabilities":        "显式开启的敏感能力（逗号分隔，如 pcap_capture、speedtest_custom_url、support_bundle）",
+		"status_page":         "本地状态页监听地址（如 127.0.0.1:8765，留空不启用）",
+		"transform_script":    "上报前转换数据的 Lua 脚本绝对路径（留空不启用）",
+		"disabled_collectors": "关闭的采集项（逗号分隔，如 gpu_info,process_info）",
+		"metrics_interval":    "性能指标上报间隔（秒）",
+		"detail_interval":     "详细信息上报间隔（秒）",
+		"system_interval":     "系统信息上报间隔（秒）",
+		"heartbeat_interval":  "心跳间隔（秒）",
+		"package_interval":    "软件包与待更新信息上报间隔（秒，0 表示不采集）",
+		"heartbeat_liveness":  "心跳携带精简存活数据（true/false）",
+		"failed_logins":       "上报最近 SSH 登录失败统计（true/false）",
+		"log_retention_days":  "日志保留天数",
+		"shutdown_timeout":    "退出前排空待发送数据的最长等待时间（秒）",
+		"max_clock_skew":      "与面板允许的最大时钟偏差（秒），超过时告警",
+		"session_rotation":    "会话密钥轮换间隔（秒，0 表示不轮换）",
+		"keypair_rotation":    "RSA 密钥对轮换间隔（秒，0 表示不轮换）",
+		"disable_public_ip":   "不向第三方服务查询公网IP（true/false）",
+
+		"disable_cloud_metadata": "不探测云厂商实例元数据服务（true/false）",
 	}
 	if desc, ok := descriptions[key]; ok {
 		return desc
@@ -130,11 +187,15 @@ func runConfigList(cmd *cobra.Command, args []string) error {
 	}
 
 	// 加载配置
-	cfg, err := config.LoadConfigFromFile(cfgPath)
+	cfg, err := config.LoadConfigReadOnly(cfgPath)
 	if err != nil {
 		return fmt.Errorf("加载配置失败: %w", err)
 	}
 
+	if configListJSON {
+		return printConfigListJSON(cfg)
+	}
+
 	// 以友好格式输出配置项
 	fmt.Println("当前配置:")
 	fmt.Println()
@@ -143,6 +204,21 @@ func runConfigList(cmd *cobra.Command, args []string) error {
 	fmt.Printf("  %-20s = %-50s  # %s\n", "server", cfg.Server, getConfigDescription("server"))
 	fmt.Printf("  %-20s = %-50s  # %s\n", "key", maskKey(cfg.Key), getConfigDescription("key"))
 	fmt.Printf("  %-20s = %-50s  # %s\n", "log_path", cfg.LogPath, getConfigDescription("log_path"))
+	fmt.Printf("  %-20s = %-50s  # %s\n", "log_level", cfg.LogLevel, getConfigDescription("log_level"))
+	fmt.Printf("  %-20s = %-50s  # %s\n", "display_name", cfg.DisplayName, getConfigDescription("display_name"))
+	fmt.Printf("  %-20s = %-50s  # %s\n", "tags", cfg.TagsString(), getConfigDescription("tags"))
+	secretsBackend, _ := cfg.GetConfigValue("secrets_backend")
+	fmt.Printf("  %-20s = %-50s  # %s\n", "secrets_backend", secretsBackend, getConfigDescription("secrets_backend"))
+	fmt.Printf("  %-20s = %-50t  # %s\n", "heartbeat_liveness", cfg.HeartbeatLiveness, getConfigDescription("heartbeat_liveness"))
+	fmt.Printf("  %-20s = %-50t  # %s\n", "failed_logins", cfg.FailedLogins, getConfigDescription("failed_logins"))
+	fmt.Printf("  %-20s = %-50t  # %s\n", "encrypt_secrets", cfg.EncryptSecrets, getConfigDescription("encrypt_secrets"))
+	fmt.Printf("  %-20s = %-50t  # %s\n", "legacy_handshake", cfg.LegacyHandshake, getConfigDescription("legacy_handshake"))
+	fmt.Printf("  %-20s = %-50s  # %s\n", "capabilities", strings.Join(cfg.Capabilities, ","), getConfigDescription("capabilities"))
+	fmt.Printf("  %-20s = %-50s  # %s\n", "status_page", cfg.StatusPage, getConfigDescription("status_page"))
+	fmt.Printf("  %-20s = %-50s  # %s\n", "transform_script", cfg.TransformScript, getConfigDescription("transform_script"))
+	fmt.Printf("  %-20s = %-50s  # %s\n", "disabled_collectors", strings.Join(cfg.DisabledCollectors, ","), getConfigDescription("disabled_collectors"))
+	fmt.Printf("  %-20s = %-50t  # %s\n", "disable_public_ip", cfg.DisablePublicIP, getConfigDescription("disable_public_ip"))
+	fmt.Printf("  %-20s = %-50t  # %s\n", "disable_cloud_metadata", cfg.DisableCloudMetadata, getConfigDescription("disable_cloud_metadata"))
 
 	fmt.Println()
 
@@ -151,7 +227,12 @@ func runConfigList(cmd *cobra.Command, args []string) error {
 	fmt.Printf("  %-20s = %-50d  # %s\n", "detail_interval", cfg.DetailInterval, getConfigDescription("detail_interval"))
 	fmt.Printf("  %-20s = %-50d  # %s\n", "system_interval", cfg.SystemInterval, getConfigDescription("system_interval"))
 	fmt.Printf("  %-20s = %-50d  # %s\n", "heartbeat_interval", cfg.HeartbeatInterval, getConfigDescription("heartbeat_interval"))
+	fmt.Printf("  %-20s = %-50d  # %s\n", "package_interval", cfg.PackageInterval, getConfigDescription("package_interval"))
 	fmt.Printf("  %-20s = %-50d  # %s\n", "log_retention_days", cfg.LogRetentionDays, getConfigDescription("log_retention_days"))
+	fmt.Printf("  %-20s = %-50d  # %s\n", "shutdown_timeout", cfg.ShutdownTimeout, getConfigDescription("shutdown_timeout"))
+	fmt.Printf("  %-20s = %-50d  # %s\n", "max_clock_skew", cfg.MaxClockSkew, getConfigDescription("max_clock_skew"))
+	fmt.Printf("  %-20s = %-50d  # %s\n", "session_rotation", cfg.SessionKeyRotation, getConfigDescription("session_rotation"))
+	fmt.Printf("  %-20s = %-50d  # %s\n", "keypair_rotation", cfg.KeypairRotation, getConfigDescription("keypair_rotation"))
 
 	fmt.Println()
 	printInfo("使用 './agent config set <key> <value>' 修改配置项")
@@ -159,6 +240,57 @@ func runConfigList(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// printConfigListJSON 以 JSON 格式输出配置项，保留数值和布尔类型
+func printConfigListJSON(cfg config.Config) error {
+	secretsBackend, _ := cfg.GetConfigValue("secrets_backend")
+	capabilities := cfg.Capabilities
+	if capabilities == nil {
+		capabilities = []string{}
+	}
+	disabledCollectors := cfg.DisabledCollectors
+	if disabledCollectors == nil {
+		disabledCollectors = []string{}
+	}
+	tags := cfg.Tags
+	if tags == nil {
+		tags = config.Tags{}
+	}
+	output := map[string]interface{}{
+		"server":              cfg.Server,
+		"key":                 maskKey(cfg.Key),
+		"log_path":            cfg.LogPath,
+		"log_level":           cfg.LogLevel,
+		"display_name":        cfg.DisplayName,
+		"tags":                tags,
+		"secrets_backend":     secretsBackend,
+		"heartbeat_liveness":  cfg.HeartbeatLiveness,
+		"failed_logins":       cfg.FailedLogins,
+		"encrypt_secrets":     cfg.EncryptSecrets,
+		"legacy_handshake":    cfg.LegacyHandshake,
+		"capabilities":        capabilities,
+		"status_page":         cfg.StatusPage,
+		"transform_script":    cfg.TransformScript,
+		"disabled_collectors": disabledCollectors,
+		"metrics_interval":    cfg.MetricsInterval,
+		"detail_interval":     cfg.DetailInterval,
+		"system_interval":     cfg.SystemInterval,
+		"heartbeat_interval":  cfg.HeartbeatInterval,
+		"package_interval":    cfg.PackageInterval,
+		"log_retention_days":  cfg.LogRetentionDays,
+		"shutdown_timeout":    cfg.ShutdownTimeout,
+		"max_clock_skew":      cfg.MaxClockSkew,
+		"session_rotation":    cfg.SessionKeyRotation,
+		"keypair_rotation":    cfg.KeypairRotation,
+		"disable_public_ip":   cfg.DisablePublicIP,
+
+		"disable_cloud_metadata": cfg.DisableCloudMetadata,
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(output)
+}
+
 // maskKey 掩码显示密钥（只显示前4位和后4位）
 func maskKey(key string) string {
 	if len(key) <= 8 {
@@ -0,0 +1,20 @@
+package cli
+
+import (
+	"agent/config"
+	"agent/internal/control"
+	"encoding/json"
+	"time"
+)
+
+// controlTimeout CLI 调用本地控制通道的超时时间
+const controlTimeout = 30 * time.Second
+
+// callControl 通过本地控制通道向运行中的 Agent 发送命令
+func callControl(command string, args ...string) (json.RawMessage, error) {
+	cfgPath := configPath
+	if cfgPath == "" {
+		cfgPath = config.GetConfigPath()
+	}
+	return control.Call(control.AddressFor(cfgPath), controlTimeout, command, args...)
+}
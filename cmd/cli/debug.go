@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"agent/internal/control"
+
+	"github.com/spf13/cobra"
+)
+
+// debugCmd 调试日志开关命令
+var debugCmd = &cobra.Command{
+	Use:       "debug [on|off]",
+	Short:     "切换调试日志",
+	Long:      `通过本地控制通道在运行时开启或关闭调试日志，无需重启。关闭后恢复配置文件中的日志级别；不带参数时显示当前级别。`,
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: []string{"on", "off"},
+	RunE:      runDebug,
+}
+
+func init() {
+	rootCmd.AddCommand(debugCmd)
+}
+
+func runDebug(cmd *cobra.Command, args []string) error {
+	data, err := callControl("debug", args...)
+	if err != nil {
+		if errors.Is(err, control.ErrUnavailable) {
+			printError("无法连接本地控制通道，agent可能未运行")
+		}
+		return fmt.Errorf("切换调试日志失败: %w", err)
+	}
+
+	var result struct {
+		LogLevel string `json:"log_level"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+	printSuccess(fmt.Sprintf("当前日志级别: %s", result.LogLevel))
+	return nil
+}
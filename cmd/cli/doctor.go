@@ -0,0 +1,377 @@
+package cli
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"agent/config"
+
+	"github.com/gorilla/websocket"
+	"github.com/spf13/cobra"
+)
+
+// 诊断检查的超时与阈值
+const (
+	doctorTimeout       = 10 * time.Second
+	doctorSkewWarn      = 30 * time.Second
+	doctorSkewFail      = 5 * time.Minute
+	doctorCertWarnAhead = 14 * 24 * time.Hour
+)
+
+// 诊断结果状态
+const (
+	doctorPass = "pass"
+	doctorWarn = "warn"
+	doctorFail = "fail"
+	doctorSkip = "skip"
+)
+
+// doctorCmd 诊断命令
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "诊断运行环境",
+	Long:  `检查配置、面板连通性（DNS/TCP/WebSocket/TLS）、时钟偏差、文件权限以及可选工具，并输出诊断报告。`,
+	RunE:  runDoctor,
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
+
+// doctorReport 收集并输出诊断结果
+type doctorReport struct {
+	passed, warned, failed int
+}
+
+func (r *doctorReport) add(status, name, detail string) {
+	line := name
+	if detail != "" {
+		line = fmt.Sprintf("%-16s %s", name, detail)
+	}
+	switch status {
+	case doctorPass:
+		r.passed++
+		printSuccess(line)
+	case doctorWarn:
+		r.warned++
+		printWarning(line)
+	case doctorFail:
+		r.failed++
+		printError(line)
+	default:
+		printColor(ColorWhite, "- "+line)
+	}
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	cfgPath := configPath
+	if cfgPath == "" {
+		cfgPath = config.GetConfigPath()
+	}
+
+	report := &doctorReport{}
+
+	fmt.Println("配置")
+	cfg, serverURL := doctorCheckConfig(report, cfgPath)
+
+	fmt.Println()
+	fmt.Println("连通性")
+	doctorCheckConnectivity(report, serverURL)
+
+	fmt.Println()
+	fmt.Println("文件权限")
+	doctorCheckPermissions(report, cfgPath, cfg)
+
+	fmt.Println()
+	fmt.Println("可选工具")
+	doctorCheckTools(report)
+
+	fmt.Println()
+	summary := fmt.Sprintf("通过 %d 项，警告 %d 项，失败 %d 项", report.passed, report.warned, report.failed)
+	if report.failed > 0 {
+		printError(summary)
+		return fmt.Errorf("诊断未通过")
+	}
+	if report.warned > 0 {
+		printWarning(summary)
+		return nil
+	}
+	printSuccess(summary)
+	return nil
+}
+
+// doctorCheckConfig 检查配置文件是否可加载以及关键字段
+func doctorCheckConfig(report *doctorReport, cfgPath string) (*config.Config, *url.URL) {
+	cfg, err := config.LoadConfigReadOnly(cfgPath)
+	if err != nil {
+		report.add(doctorFail, "配置文件", err.Error())
+		return nil, nil
+	}
+	report.add(doctorPass, "配置文件", cfgPath)
+
+	// 加密的密钥无法解密时 Agent 无法启动，此处仅给出警告以便继续诊断其他项
+	if _, err := config.LoadSecrets(cfgPath, cfg.SecretsBackend); errors.Is(err, config.ErrSecretsUnavailable) {
+		report.add(doctorWarn, "密钥", err.Error())
+	}
+
+	if cfg.Key == "" {
+		report.add(doctorFail, "Key", "未配置")
+	} else if len(cfg.Key) != 36 {
+		report.add(doctorWarn, "Key", fmt.Sprintf("长度异常（%d），正常应为 36 个字符", len(cfg.Key)))
+	} else {
+		report.add(doctorPass, "Key", maskKey(cfg.Key))
+	}
+
+	if cfg.Server == "" {
+		report.add(doctorFail, "Server", "未配置")
+		return &cfg, nil
+	}
+	serverURL, err := url.Parse(cfg.Server)
+	if err != nil || serverURL.Host == "" {
+		report.add(doctorFail, "Server", fmt.Sprintf("地址无效: %s", cfg.Server))
+		return &cfg, nil
+	}
+	if serverURL.Scheme != "ws" && serverURL.Scheme != "wss" {
+		report.add(doctorFail, "Server", fmt.Sprintf("协议应为 ws 或 wss: %s", cfg.Server))
+		return &cfg, nil
+	}
+	if serverURL.Scheme == "ws" {
+		report.add(doctorWarn, "Server", fmt.Sprintf("%s（未使用 TLS）", cfg.Server))
+	} else {
+		report.add(doctorPass, "Server", cfg.Server)
+	}
+	return &cfg, serverURL
+}
+
+// doctorCheckConnectivity 依次检查 DNS、TCP、TLS、WebSocket 握手和时钟偏差
+func doctorCheckConnectivity(report *doctorReport, serverURL *url.URL) {
+	if serverURL == nil {
+		report.add(doctorSkip, "连通性检查", "服务器地址不可用，已跳过")
+		return
+	}
+
+	host := serverURL.Hostname()
+	port := serverURL.Port()
+	if port == "" {
+		port = "80"
+		if serverURL.Scheme == "wss" {
+			port = "443"
+		}
+	}
+	address := net.JoinHostPort(host, port)
+
+	// DNS 解析
+	ctx, cancel := context.WithTimeout(context.Background(), doctorTimeout)
+	addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+	cancel()
+	if err != nil {
+		report.add(doctorFail, "DNS", fmt.Sprintf("解析 %s 失败: %v", host, err))
+		return
+	}
+	report.add(doctorPass, "DNS", fmt.Sprintf("%s -> %s", host, strings.Join(addrs, ", ")))
+
+	// TCP 连接
+	start := time.Now()
+	conn, err := net.DialTimeout("tcp", address, doctorTimeout)
+	if err != nil {
+		report.add(doctorFail, "TCP", fmt.Sprintf("连接 %s 失败: %v", address, err))
+		return
+	}
+	conn.Close()
+	report.add(doctorPass, "TCP", fmt.Sprintf("%s（%dms）", address, time.Since(start).Milliseconds()))
+
+	// TLS 证书链
+	if serverURL.Scheme == "wss" {
+		doctorCheckTLS(report, host, address)
+	}
+
+	// WebSocket 握手
+	dialer := websocket.Dialer{HandshakeTimeout: doctorTimeout, Proxy: http.ProxyFromEnvironment}
+	start = time.Now()
+	wsConn, resp, err := dialer.Dial(serverURL.String(), nil)
+	if err != nil {
+		detail := err.Error()
+		if resp != nil {
+			detail = fmt.Sprintf("%v（HTTP %d）", err, resp.StatusCode)
+		}
+		report.add(doctorFail, "WebSocket", detail)
+	} else {
+		wsConn.Close()
+		report.add(doctorPass, "WebSocket", fmt.Sprintf("握手成功（%dms）", time.Since(start).Milliseconds()))
+	}
+
+	// 时钟偏差
+	if resp != nil {
+		doctorCheckClock(report, resp.Header.Get("Date"))
+	} else {
+		doctorCheckClockHTTP(report, serverURL)
+	}
+}
+
+// doctorCheckTLS 校验证书链并检查有效期
+func doctorCheckTLS(report *doctorReport, host, address string) {
+	dialer := &net.Dialer{Timeout: doctorTimeout}
+	tlsConn, err := tls.DialWithDialer(dialer, "tcp", address, &tls.Config{ServerName: host})
+	if err != nil {
+		report.add(doctorFail, "TLS", fmt.Sprintf("证书校验失败: %v", err))
+		return
+	}
+	defer tlsConn.Close()
+
+	certs := tlsConn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		report.add(doctorFail, "TLS", "服务器未提供证书")
+		return
+	}
+	leaf := certs[0]
+	remaining := time.Until(leaf.NotAfter)
+	detail := fmt.Sprintf("%s，签发者 %s，%s 到期", leaf.Subject.CommonName, leaf.Issuer.CommonName, leaf.NotAfter.Format("2006-01-02"))
+	if remaining < doctorCertWarnAhead {
+		report.add(doctorWarn, "TLS", fmt.Sprintf("证书即将过期（剩余 %d 天）: %s", int(remaining.Hours()/24), detail))
+		return
+	}
+	report.add(doctorPass, "TLS", detail)
+}
+
+// doctorCheckClockHTTP WebSocket 握手失败时通过普通 HTTP 请求获取面板时间
+func doctorCheckClockHTTP(report *doctorReport, serverURL *url.URL) {
+	httpURL := *serverURL
+	httpURL.Scheme = "http"
+	if serverURL.Scheme == "wss" {
+		httpURL.Scheme = "https"
+	}
+	client := &http.Client{Timeout: doctorTimeout}
+	resp, err := client.Head(httpURL.String())
+	if err != nil {
+		report.add(doctorSkip, "时钟偏差", fmt.Sprintf("无法获取面板时间: %v", err))
+		return
+	}
+	resp.Body.Close()
+	doctorCheckClock(report, resp.Header.Get("Date"))
+}
+
+// doctorCheckClock 根据面板响应的 Date 头计算时钟偏差
+func doctorCheckClock(report *doctorReport, dateHeader string) {
+	if dateHeader == "" {
+		report.add(doctorSkip, "时钟偏差", "面板响应未包含 Date 头")
+		return
+	}
+	panelTime, err := http.ParseTime(dateHeader)
+	if err != nil {
+		report.add(doctorSkip, "时钟偏差", fmt.Sprintf("无法解析面板时间: %v", err))
+		return
+	}
+
+	skew := time.Since(panelTime)
+	if skew < 0 {
+		skew = -skew
+	}
+	// Date 头精度为秒
+	skew = skew.Truncate(time.Second)
+	detail := fmt.Sprintf("与面板相差 %s", skew)
+	switch {
+	case skew >= doctorSkewFail:
+		report.add(doctorFail, "时钟偏差", detail+"，请校准系统时间")
+	case skew >= doctorSkewWarn:
+		report.add(doctorWarn, "时钟偏差", detail)
+	default:
+		report.add(doctorPass, "时钟偏差", detail)
+	}
+}
+
+// doctorCheckPermissions 检查配置、密钥、日志和 PID 文件的权限
+func doctorCheckPermissions(report *doctorReport, cfgPath string, cfg *config.Config) {
+	doctorCheckSecretFile(report, "配置文件权限", cfgPath)
+	doctorCheckSecretFile(report, "密钥文件权限", config.SecretsPathFor(cfgPath))
+
+	if cfg != nil {
+		logPath := cfg.LogPath
+		if !filepath.IsAbs(logPath) {
+			if execPath, err := os.Executable(); err == nil {
+				logPath = filepath.Join(filepath.Dir(execPath), logPath)
+			}
+		}
+		doctorCheckWritableDir(report, "日志目录", logPath)
+	}
+
+	doctorCheckWritableDir(report, "PID目录", filepath.Dir(pidFile))
+}
+
+// doctorCheckSecretFile 包含密钥的文件不应被其他用户读取
+func doctorCheckSecretFile(report *doctorReport, name, path string) {
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			report.add(doctorSkip, name, fmt.Sprintf("%s 不存在", path))
+		} else {
+			report.add(doctorFail, name, err.Error())
+		}
+		return
+	}
+	if runtime.GOOS == "windows" {
+		report.add(doctorPass, name, path)
+		return
+	}
+	mode := info.Mode().Perm()
+	if mode&0o077 != 0 {
+		report.add(doctorWarn, name, fmt.Sprintf("%s 权限为 %04o，建议设置为 0600", path, mode))
+		return
+	}
+	report.add(doctorPass, name, fmt.Sprintf("%s（%04o）", path, mode))
+}
+
+// doctorCheckWritableDir 检查目录是否存在且可写
+func doctorCheckWritableDir(report *doctorReport, name, dir string) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			report.add(doctorWarn, name, fmt.Sprintf("%s 不存在（首次运行时创建）", dir))
+		} else {
+			report.add(doctorFail, name, err.Error())
+		}
+		return
+	}
+	if !info.IsDir() {
+		report.add(doctorFail, name, fmt.Sprintf("%s 不是目录", dir))
+		return
+	}
+
+	probe, err := os.CreateTemp(dir, ".doctor-*")
+	if err != nil {
+		report.add(doctorFail, name, fmt.Sprintf("%s 不可写: %v", dir, err))
+		return
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+	report.add(doctorPass, name, dir)
+}
+
+// doctorCheckTools 检查可选的外部工具
+func doctorCheckTools(report *doctorReport) {
+	tools := []struct {
+		name, usage string
+	}{
+		{"nvidia-smi", "GPU 监控"},
+		{"smartctl", "磁盘 SMART 状态"},
+		{"tcpdump", "远程抓包"},
+	}
+	for _, tool := range tools {
+		path, err := exec.LookPath(tool.name)
+		if err != nil {
+			report.add(doctorSkip, tool.name, fmt.Sprintf("未安装（%s不可用）", tool.usage))
+			continue
+		}
+		report.add(doctorPass, tool.name, path)
+	}
+}
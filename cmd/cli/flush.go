@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"agent/internal/control"
+
+	"github.com/spf13/cobra"
+)
+
+// flushCmd 立即上报命令
+var flushCmd = &cobra.Command{
+	Use:   "flush",
+	Short: "立即上报",
+	Long:  `通过本地控制通道通知运行中的Agent立即发送缓冲日志并执行一次完整上报。`,
+	RunE:  runFlush,
+}
+
+func init() {
+	rootCmd.AddCommand(flushCmd)
+}
+
+func runFlush(cmd *cobra.Command, args []string) error {
+	if _, err := callControl("flush"); err != nil {
+		if errors.Is(err, control.ErrUnavailable) {
+			printError("无法连接本地控制通道，agent可能未运行")
+		}
+		return fmt.Errorf("立即上报失败: %w", err)
+	}
+	printSuccess("已完成一次立即上报")
+	return nil
+}
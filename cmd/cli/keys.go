@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"fmt"
+
+	"agent/config"
+	"agent/internal/crypto"
+	"agent/internal/svc"
+
+	"github.com/spf13/cobra"
+)
+
+var sessionOnlyFlag bool
+
+// keysCmd 密钥管理命令组
+var keysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "密钥管理",
+	Long:  `管理 Agent 的通信密钥。`,
+}
+
+// keysRotateCmd 密钥轮换命令
+var keysRotateCmd = &cobra.Command{
+	Use:   "rotate",
+	Short: "轮换密钥",
+	Long: `重新生成 Agent RSA 密钥对并丢弃当前会话密钥。
+若服务正在运行会自动重启，重新认证时面板将收到新公钥并下发新的会话密钥。
+使用 --session-only 仅丢弃会话密钥，保留现有密钥对。`,
+	RunE: runKeysRotate,
+}
+
+func init() {
+	keysRotateCmd.Flags().BoolVar(&sessionOnlyFlag, "session-only", false, "仅轮换会话密钥")
+	keysCmd.AddCommand(keysRotateCmd)
+	rootCmd.AddCommand(keysCmd)
+}
+
+func runKeysRotate(cmd *cobra.Command, args []string) error {
+	// 获取配置文件路径
+	cfgPath := configPath
+	if cfgPath == "" {
+		cfgPath = config.GetConfigPath()
+	}
+
+	// 加载配置
+	cfg, err := config.LoadConfigFromFile(cfgPath)
+	if err != nil {
+		return fmt.Errorf("加载配置失败: %w", err)
+	}
+
+	if !sessionOnlyFlag {
+		privateKeyBytes, publicKeyBytes, err := crypto.GenerateKeyPair()
+		if err != nil {
+			return fmt.Errorf("生成Agent密钥对失败: %w", err)
+		}
+		cfg.AgentPrivateKey = string(privateKeyBytes)
+		cfg.AgentPublicKey = string(publicKeyBytes)
+	}
+	cfg.SessionKey = ""
+	cfg.EncryptionEnabled = false
+
+	if err := config.SaveConfig(cfg, cfgPath); err != nil {
+		return fmt.Errorf("保存配置失败: %w", err)
+	}
+
+	if sessionOnlyFlag {
+		printSuccess("会话密钥已丢弃")
+	} else {
+		fingerprint, _ := crypto.GetPublicKeyFingerprint([]byte(cfg.AgentPublicKey))
+		printSuccess(fmt.Sprintf("Agent密钥对已轮换，新公钥指纹: %s", fingerprint))
+	}
+
+	// 服务运行中时重启以使用新密钥重新认证
+	s, err := svc.New(configPath)
+	if err != nil {
+		return nil
+	}
+	status, err := s.Status()
+	if err != nil || status != "running" {
+		printInfo("agent未运行，新密钥将在下次启动时生效")
+		return nil
+	}
+
+	printInfo("正在重启服务以使用新密钥...")
+	if err := s.Restart(); err != nil {
+		printError(fmt.Sprintf("服务重启失败: %v", err))
+		return err
+	}
+	printSuccess("服务已重启，将使用新密钥重新认证")
+	return nil
+}
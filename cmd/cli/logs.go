@@ -40,7 +40,7 @@ func runLogs(cmd *cobra.Command, args []string) error {
 
 	// 尝试加载配置以获取日志路径
 	var logDir string
-	cfg, err := config.LoadConfigFromFile(cfgPath)
+	cfg, err := config.LoadConfigReadOnly(cfgPath)
 	if err != nil {
 		logDir = "logs"
 	} else {
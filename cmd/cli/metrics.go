@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"agent/config"
+	"agent/internal/collector"
+	"agent/internal/logger"
+	"agent/internal/websocket"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	metricsTypes  []string
+	metricsFormat string
+)
+
+// metricsCmd 本地一次性采集命令
+var metricsCmd = &cobra.Command{
+	Use:   "metrics",
+	Short: "本地采集一次并输出",
+	Long: fmt.Sprintf(`执行一次采集并将将要上报的数据输出到标准输出，不连接面板。
+支持的类型: %s`, strings.Join(collector.OneShotTypes, ", ")),
+	RunE: runMetrics,
+}
+
+func init() {
+	metricsCmd.Flags().StringSliceVarP(&metricsTypes, "type", "t", nil, "只采集指定类型（可多次指定或逗号分隔）")
+	metricsCmd.Flags().StringVarP(&metricsFormat, "format", "f", "json", "输出格式：json/table")
+	rootCmd.AddCommand(metricsCmd)
+}
+
+func runMetrics(cmd *cobra.Command, args []string) error {
+	if metricsFormat != "json" && metricsFormat != "table" {
+		return fmt.Errorf("不支持的输出格式: %s", metricsFormat)
+	}
+
+	cfgPath := configPath
+	if cfgPath == "" {
+		cfgPath = config.GetConfigPath()
+	}
+
+	// 配置不存在时使用默认值，仍可采集
+	cfg, err := config.LoadConfigReadOnly(cfgPath)
+	if err != nil {
+		cfg = config.DefaultConfig()
+	}
+
+	// 日志输出到 stderr，保证 stdout 只包含采集结果
+	log := logger.NewConsoleLogger(os.Stderr)
+	col := collector.NewCollector(config.InitSystem(), log, nil, cfg)
+
+	messages, collectErr := col.CollectOnce(cmd.Context(), metricsTypes)
+	if collectErr != nil {
+		log.Warn("部分采集失败: %v", collectErr)
+	}
+
+	if metricsFormat == "table" {
+		printMetricsTable(messages)
+		return nil
+	}
+
+	// 同一类型有多条消息时（如多个插件的 custom_metric）输出为数组
+	grouped := make(map[string][]interface{}, len(messages))
+	for _, message := range messages {
+		grouped[message.Type] = append(grouped[message.Type], message.Data)
+	}
+	result := make(map[string]interface{}, len(grouped))
+	for msgType, items := range grouped {
+		if len(items) == 1 {
+			result[msgType] = items[0]
+		} else {
+			result[msgType] = items
+		}
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
+
+// printMetricsTable 以表格形式输出采集结果，嵌套结构以紧凑 JSON 显示
+func printMetricsTable(messages []websocket.Message) {
+	for i, message := range messages {
+		if i > 0 {
+			fmt.Println()
+		}
+		printColor(ColorCyan, fmt.Sprintf("[%s]", message.Type))
+
+		data, ok := message.Data.(map[string]interface{})
+		if !ok {
+			raw, _ := json.Marshal(message.Data)
+			fmt.Printf("  %s\n", raw)
+			continue
+		}
+
+		keys := make([]string, 0, len(data))
+		for key := range data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			var value string
+			switch v := data[key].(type) {
+			case string:
+				value = v
+			default:
+				raw, _ := json.Marshal(v)
+				value = string(raw)
+			}
+			fmt.Printf("  %-24s %s\n", key, value)
+		}
+	}
+}
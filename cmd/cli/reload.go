@@ -1,7 +1,9 @@
 package cli
 
 import (
+	"agent/internal/control"
 	"agent/internal/svc"
+	"errors"
 	"fmt"
 
 	"github.com/spf13/cobra"
@@ -11,7 +13,7 @@ import (
 var reloadCmd = &cobra.Command{
 	Use:   "reload",
 	Short: "重载配置",
-	Long:  `重启服务以应用新的配置。`,
+	Long:  `通过本地控制通道通知运行中的Agent重载配置；控制通道不可用时重启服务以应用新的配置。`,
 	RunE:  runReload,
 }
 
@@ -20,6 +22,15 @@ func init() {
 }
 
 func runReload(cmd *cobra.Command, args []string) error {
+	// 优先通过控制通道热重载，无需重启进程
+	if _, err := callControl("reload"); err == nil {
+		printSuccess("配置已重载")
+		return nil
+	} else if !errors.Is(err, control.ErrUnavailable) {
+		printError(fmt.Sprintf("配置重载失败: %v", err))
+		return err
+	}
+
 	s, err := svc.New(configPath)
 	if err != nil {
 		return err
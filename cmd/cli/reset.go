@@ -51,6 +51,11 @@ func runReset(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	// 删除独立存储的密钥
+	if err := config.DeleteSecrets(cfgPath); err != nil {
+		printWarning(fmt.Sprintf("删除密钥文件失败: %v", err))
+	}
+
 	printSuccess(fmt.Sprintf("配置文件 %s 已删除", cfgPath))
 	printInfo("请运行 './agent start' 进入交互式配置模式")
 
@@ -1,33 +1,82 @@
 package cli
 
 import (
+	"agent/config"
+	"agent/internal/agent"
 	"agent/internal/svc"
+	"agent/internal/version"
+	"encoding/json"
 	"fmt"
+	"os"
+	"sort"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
+var statusJSON bool
+
 // statusCmd 状态命令
 var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "查看服务状态",
-	Long:  `查看CloudSentinel Agent服务状态。`,
+	Long:  `查看CloudSentinel Agent服务状态。使用 --json 输出结构化结果，便于自动化工具解析。`,
 	RunE:  runStatus,
 }
 
 func init() {
+	statusCmd.Flags().BoolVar(&statusJSON, "json", false, "以 JSON 格式输出")
 	rootCmd.AddCommand(statusCmd)
 }
 
+// statusOutput status --json 的输出结构
+type statusOutput struct {
+	State         string            `json:"state"`
+	PID           int               `json:"pid,omitempty"`
+	UptimeSeconds int64             `json:"uptime_seconds,omitempty"`
+	StartedAt     string            `json:"started_at,omitempty"`
+	Connected     bool              `json:"connected"`
+	LastReports   map[string]string `json:"last_reports,omitempty"`
+	Version       string            `json:"version"`
+	StateUpdated  string            `json:"state_updated_at,omitempty"`
+	QueueLength   int               `json:"queue_length"`
+	LastErrors    map[string]string `json:"last_errors,omitempty"`
+	LogLevel      string            `json:"log_level,omitempty"`
+}
+
 func runStatus(cmd *cobra.Command, args []string) error {
 	s, err := svc.New(configPath)
 	if err != nil {
 		return fmt.Errorf("初始化服务配置失败: %w", err)
 	}
 
-	status, err := s.Status()
-	if err != nil {
-		return fmt.Errorf("获取状态失败: %w", err)
+	status, statusErr := s.Status()
+
+	// 优先通过本地控制通道获取实时状态（前台运行时同样可用）
+	var state *agent.State
+	if data, controlErr := callControl("status"); controlErr == nil {
+		var st agent.State
+		if json.Unmarshal(data, &st) == nil {
+			state = &st
+			status = "running"
+			statusErr = nil
+		}
+	} else if status == "running" {
+		// 控制通道不可用时回退到运行状态文件，过期的状态文件视为无效
+		cfgPath := configPath
+		if cfgPath == "" {
+			cfgPath = config.GetConfigPath()
+		}
+		if st, readErr := agent.ReadState(cfgPath); readErr == nil && !st.IsStale() {
+			state = st
+		}
+	}
+
+	if statusJSON {
+		return printStatusJSON(status, state)
+	}
+	if statusErr != nil {
+		return fmt.Errorf("获取状态失败: %w", statusErr)
 	}
 
 	switch status {
@@ -38,5 +87,56 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	default:
 		printStatus("unknown", fmt.Sprintf("服务状态: 未知 (%s)", status))
 	}
+
+	if state != nil {
+		fmt.Printf("  PID: %d\n", state.PID)
+		fmt.Printf("  运行时长: %s\n", state.Uptime().Truncate(time.Second))
+		if state.Connected {
+			fmt.Println("  连接: 已连接")
+		} else {
+			fmt.Println("  连接: 未连接")
+		}
+		fmt.Printf("  版本: %s\n", state.Version)
+		fmt.Printf("  日志级别: %s\n", state.LogLevel)
+		fmt.Printf("  待发送日志: %d\n", state.QueueLength)
+		if len(state.LastErrors) > 0 {
+			printWarning("最近的采集错误:")
+			names := make([]string, 0, len(state.LastErrors))
+			for name := range state.LastErrors {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				fmt.Printf("  %-14s %s\n", name, state.LastErrors[name])
+			}
+		}
+	}
 	return nil
 }
+
+// printStatusJSON 以 JSON 格式输出服务状态
+func printStatusJSON(status string, state *agent.State) error {
+	output := statusOutput{
+		State:   status,
+		Version: version.AgentVersion,
+	}
+	if state != nil {
+		output.PID = state.PID
+		output.UptimeSeconds = int64(state.Uptime().Seconds())
+		output.StartedAt = state.StartedAt.Format(time.RFC3339)
+		output.Connected = state.Connected
+		output.Version = state.Version
+		output.StateUpdated = state.UpdatedAt.Format(time.RFC3339)
+		output.QueueLength = state.QueueLength
+		output.LastErrors = state.LastErrors
+		output.LogLevel = state.LogLevel
+		output.LastReports = make(map[string]string, len(state.LastReports))
+		for name, t := range state.LastReports {
+			output.LastReports[name] = t.Format(time.RFC3339)
+		}
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(output)
+}
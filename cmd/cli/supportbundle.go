@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"agent/config"
+	"agent/internal/agent"
+	"agent/internal/control"
+	"agent/internal/logger"
+	"agent/internal/supportbundle"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	supportBundleOutput   string
+	supportBundleLogFiles int
+)
+
+// supportBundleCmd 生成诊断包命令
+var supportBundleCmd = &cobra.Command{
+	Use:   "support-bundle",
+	Short: "生成诊断包",
+	Long: `将最近的日志、脱敏后的配置、系统快照、goroutine 堆栈和最近的协议错误打包为 tar.gz，用于提交问题报告。
+Agent 运行中时通过本地控制通道由 Agent 进程生成；未运行时在本地生成（不含 goroutine 堆栈和协议错误）。
+通信密钥和加密密钥会被自动脱敏。`,
+	RunE: runSupportBundle,
+}
+
+func init() {
+	supportBundleCmd.Flags().StringVarP(&supportBundleOutput, "output", "o", "", "输出文件路径（默认当前目录下 support-bundle-<时间>.tar.gz）")
+	supportBundleCmd.Flags().IntVar(&supportBundleLogFiles, "logs", supportbundle.DefaultLogFiles, "包含最近几天的日志文件")
+	rootCmd.AddCommand(supportBundleCmd)
+}
+
+func runSupportBundle(cmd *cobra.Command, args []string) error {
+	output := supportBundleOutput
+	if output == "" {
+		output = supportbundle.FileName(time.Now())
+	}
+	output, err := filepath.Abs(output)
+	if err != nil {
+		return err
+	}
+
+	// 优先由运行中的 Agent 生成，可包含其 goroutine 堆栈和协议错误
+	raw, err := callControl("support_bundle", output, strconv.Itoa(supportBundleLogFiles))
+	if err == nil {
+		var result struct {
+			Path string `json:"path"`
+			Size int    `json:"size"`
+		}
+		_ = json.Unmarshal(raw, &result)
+		printSuccess(fmt.Sprintf("诊断包已生成: %s（%d 字节）", output, result.Size))
+		return nil
+	}
+	if !errors.Is(err, control.ErrUnavailable) {
+		return fmt.Errorf("生成诊断包失败: %w", err)
+	}
+
+	printWarning("无法连接本地控制通道，将在本地生成诊断包（不含运行中 Agent 的 goroutine 堆栈和协议错误）")
+	cfgPath := configPath
+	if cfgPath == "" {
+		cfgPath = config.GetConfigPath()
+	}
+	cfg, err := config.LoadConfigReadOnly(cfgPath)
+	if err != nil {
+		return fmt.Errorf("加载配置失败: %w", err)
+	}
+
+	opts := supportbundle.Options{
+		Config:     &cfg,
+		ConfigPath: cfgPath,
+		Logger:     logger.NewConsoleLogger(os.Stderr),
+		LogFiles:   supportBundleLogFiles,
+	}
+	if state, err := agent.ReadState(cfgPath); err == nil {
+		opts.State = state
+	}
+	data, err := supportbundle.Build(opts)
+	if err != nil {
+		return fmt.Errorf("生成诊断包失败: %w", err)
+	}
+	if err := os.WriteFile(output, data, 0600); err != nil {
+		return fmt.Errorf("写入诊断包失败: %w", err)
+	}
+	printSuccess(fmt.Sprintf("诊断包已生成: %s（%d 字节）", output, len(data)))
+	return nil
+}
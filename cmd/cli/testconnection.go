@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"agent/config"
+
+	"github.com/gorilla/websocket"
+	"github.com/spf13/cobra"
+)
+
+// test-connection 的退出码，便于部署脚本判断失败阶段
+const (
+	exitConnOK           = 0
+	exitConnConfig       = 2 // 配置无效
+	exitConnDial         = 3 // 无法建立 TCP/TLS 连接
+	exitConnUpgrade      = 4 // WebSocket 升级失败
+	exitConnAuthRejected = 5 // 认证被拒绝
+	exitConnTimeout      = 6 // 等待响应超时
+)
+
+var testConnTimeout time.Duration
+
+// testConnectionCmd 连接测试命令
+var testConnectionCmd = &cobra.Command{
+	Use:   "test-connection",
+	Short: "测试与面板的连接",
+	Long: `连接配置的服务器并发送认证消息，等待认证结果，输出失败所在阶段。
+退出码: 0 成功，2 配置无效，3 连接失败，4 WebSocket 升级失败，5 认证被拒绝，6 超时。`,
+	Run: runTestConnection,
+}
+
+func init() {
+	testConnectionCmd.Flags().DurationVar(&testConnTimeout, "timeout", 15*time.Second, "等待认证响应的超时时间")
+	rootCmd.AddCommand(testConnectionCmd)
+}
+
+func runTestConnection(cmd *cobra.Command, args []string) {
+	os.Exit(testConnection())
+}
+
+func testConnection() int {
+	cfgPath := configPath
+	if cfgPath == "" {
+		cfgPath = config.GetConfigPath()
+	}
+
+	cfg, err := config.LoadConfigReadOnly(cfgPath)
+	if err != nil {
+		printError(fmt.Sprintf("[配置] 加载失败: %v", err))
+		return exitConnConfig
+	}
+	if cfg.Server == "" || cfg.Key == "" {
+		printError("[配置] Server 或 Key 未配置")
+		return exitConnConfig
+	}
+	printSuccess(fmt.Sprintf("[配置] %s", cfg.Server))
+
+	// 建立连接并升级为 WebSocket
+	dialer := websocket.Dialer{HandshakeTimeout: testConnTimeout, Proxy: http.ProxyFromEnvironment}
+	start := time.Now()
+	conn, resp, err := dialer.Dial(cfg.Server, nil)
+	if err != nil {
+		if resp != nil {
+			printError(fmt.Sprintf("[升级] WebSocket 升级失败: HTTP %d", resp.StatusCode))
+			return exitConnUpgrade
+		}
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			printError(fmt.Sprintf("[连接] 连接超时: %v", err))
+			return exitConnTimeout
+		}
+		printError(fmt.Sprintf("[连接] 连接失败: %v", err))
+		return exitConnDial
+	}
+	defer conn.Close()
+	printSuccess(fmt.Sprintf("[连接] WebSocket 已建立（%dms）", time.Since(start).Milliseconds()))
+
+	// 发送认证消息（不携带 Agent 公钥，避免触发加密握手或修改本地密钥）
+	auth := map[string]interface{}{
+		"type": "auth",
+		"data": map[string]interface{}{
+			"type": "server",
+			"key":  cfg.Key,
+		},
+	}
+	if err := conn.WriteJSON(auth); err != nil {
+		printError(fmt.Sprintf("[认证] 发送认证消息失败: %v", err))
+		return exitConnDial
+	}
+	printInfo("[认证] 已发送认证消息，等待响应...")
+
+	// 等待认证结果
+	deadline := time.Now().Add(testConnTimeout)
+	conn.SetReadDeadline(deadline)
+	for {
+		messageType, message, err := conn.ReadMessage()
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				printError(fmt.Sprintf("[认证] %s 内未收到认证结果", testConnTimeout))
+				return exitConnTimeout
+			}
+			printError(fmt.Sprintf("[认证] 连接被关闭: %v", err))
+			return exitConnAuthRejected
+		}
+		if messageType != websocket.TextMessage {
+			continue
+		}
+
+		var reply struct {
+			Type    string `json:"type"`
+			Status  string `json:"status"`
+			Message string `json:"message"`
+		}
+		if err := json.Unmarshal(message, &reply); err != nil {
+			continue
+		}
+
+		switch reply.Type {
+		case "key_exchange":
+			printInfo("[握手] 已收到面板公钥")
+		case "auth":
+			if reply.Status == "success" {
+				printSuccess(fmt.Sprintf("[认证] 认证成功（总耗时 %dms）", time.Since(start).Milliseconds()))
+				return exitConnOK
+			}
+			if reply.Status != "" {
+				printError(fmt.Sprintf("[认证] 认证被拒绝: %s", reply.Message))
+				return exitConnAuthRejected
+			}
+		}
+	}
+}
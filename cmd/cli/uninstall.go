@@ -33,7 +33,7 @@ func runUninstall(cmd *cobra.Command, args []string) error {
 	}
 
 	// 在卸载前先获取配置，以便后续清理日志
-	cfg, cfgErr := config.LoadConfigFromFile(configPath)
+	cfg, cfgErr := config.LoadConfigReadOnly(configPath)
 
 	s, err := svc.New(configPath)
 	if err != nil {
@@ -1,44 +1,131 @@
 package config
 
 import (
+	"agent/internal/fault"
 	"agent/internal/logger"
 	"agent/internal/system"
 	"bufio"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 )
 
 type Config struct {
-	Server              string   `json:"server"`
-	Key                 string   `json:"key"`
-	LogPath             string   `json:"log_path"`
-	MetricsInterval     int      `json:"metrics_interval"`                // 性能指标上报间隔（秒）
-	DetailInterval      int      `json:"detail_interval"`                 // 详细信息上报间隔（秒）
-	SystemInterval      int      `json:"system_interval"`                 // 系统信息上报间隔（秒）
-	HeartbeatInterval   int      `json:"heartbeat_interval"`              // 心跳间隔（秒）
-	Timezone            string   `json:"timezone,omitempty"`              // 时区设置，默认 Asia/Shanghai
-	AgentPrivateKey     string   `json:"agent_private_key,omitempty"`     // Agent 私钥（PEM格式）
-	AgentPublicKey      string   `json:"agent_public_key,omitempty"`      // Agent 公钥（PEM格式）
-	PanelPublicKey      string   `json:"panel_public_key,omitempty"`      // 面板公钥（PEM格式）
-	PanelFingerprint    string   `json:"panel_fingerprint,omitempty"`     // 面板公钥指纹
-	SessionKey          string   `json:"session_key,omitempty"`           // AES 会话密钥（Base64编码字符串）
-	EncryptionEnabled   bool     `json:"encryption_enabled,omitempty"`    // 是否启用加密
-	LogRetentionDays    int      `json:"log_retention_days"`              // 日志保留天数
-	MonitoredServices   []string `json:"monitored_services"`              // 监控的服务列表
-	ExcludedMountPoints []string `json:"excluded_mount_points,omitempty"` // 排除的挂载点列表
-	ExcludedFilesystems []string `json:"excluded_filesystems,omitempty"`  // 排除的文件系统类型列表
+	Server              string          `json:"server"`
+	Key                 string          `json:"key"`
+	DisplayName         string          `json:"display_name,omitempty"` // 显示名称（面板中展示，独立于主机名）
+	Tags                Tags            `json:"tags,omitempty"`         // 主机标签（如 env=prod、region=ap-east），用于面板分组和筛选
+	LogPath             string          `json:"log_path"`
+	LogLevel            string          `json:"log_level,omitempty"`             // 日志级别：debug/info/warn/error
+	MetricsInterval     int             `json:"metrics_interval"`                // 性能指标上报间隔（秒）
+	DetailInterval      int             `json:"detail_interval"`                 // 详细信息上报间隔（秒）
+	SystemInterval      int             `json:"system_interval"`                 // 系统信息上报间隔（秒）
+	HeartbeatInterval   int             `json:"heartbeat_interval"`              // 心跳间隔（秒）
+	PackageInterval     int             `json:"package_interval,omitempty"`      // 软件包与待更新信息上报间隔（秒），0 表示不采集
+	HeartbeatLiveness   bool            `json:"heartbeat_liveness,omitempty"`    // 心跳携带精简的存活数据（负载、CPU、内存、健康分）
+	FailedLogins        bool            `json:"failed_logins,omitempty"`         // users_info 中附带最近 SSH 登录失败统计（需读取认证日志）
+	Timezone            string          `json:"timezone,omitempty"`              // 时区设置，默认 Asia/Shanghai
+	AgentPrivateKey     string          `json:"agent_private_key,omitempty"`     // Agent 私钥（PEM格式）
+	AgentPublicKey      string          `json:"agent_public_key,omitempty"`      // Agent 公钥（PEM格式）
+	PanelPublicKey      string          `json:"panel_public_key,omitempty"`      // 面板公钥（PEM格式）
+	PanelFingerprint    string          `json:"panel_fingerprint,omitempty"`     // 面板公钥指纹
+	SessionKey          string          `json:"session_key,omitempty"`           // AES 会话密钥（Base64编码字符串）
+	SecretsBackend      string          `json:"secrets_backend,omitempty"`       // 密钥存储后端：file（默认）/keyring
+	EncryptSecrets      bool            `json:"encrypt_secrets,omitempty"`       // 使用机器标识或口令加密存储私钥和会话密钥
+	SessionKeyRotation  int             `json:"session_rotation,omitempty"`      // 会话密钥轮换间隔（秒），0 表示不轮换
+	LegacyHandshake     bool            `json:"legacy_handshake,omitempty"`      // 仅使用 RSA+AES 握手（不声明 X25519 能力）
+	KeypairRotation     int             `json:"keypair_rotation,omitempty"`      // RSA 密钥对轮换间隔（秒），0 表示不轮换
+	EncryptionEnabled   bool            `json:"encryption_enabled,omitempty"`    // 是否启用加密
+	LogRetentionDays    int             `json:"log_retention_days"`              // 日志保留天数
+	ShutdownTimeout     int             `json:"shutdown_timeout,omitempty"`      // 退出前排空待发送数据的最长等待时间（秒）
+	MaxClockSkew        int             `json:"max_clock_skew,omitempty"`        // 与面板允许的最大时钟偏差（秒），超过时告警
+	MonitoredServices   []string        `json:"monitored_services"`              // 监控的服务列表
+	DisabledCollectors  []string        `json:"disabled_collectors,omitempty"`   // 关闭的采集项（如 gpu_info、process_info）
+	ExcludedMountPoints []string        `json:"excluded_mount_points,omitempty"` // 排除的挂载点列表
+	ExcludedFilesystems []string        `json:"excluded_filesystems,omitempty"`  // 排除的文件系统类型列表
+	PublicIPProviders   []string        `json:"public_ip_providers,omitempty"`   // 公网IP查询服务列表
+	PublicIPInterval    int             `json:"public_ip_interval,omitempty"`    // 公网IP重新查询间隔（秒）
+	DisablePublicIP     bool            `json:"disable_public_ip,omitempty"`     // 不向第三方服务查询公网IP
+	DisableConfigWatch  bool            `json:"disable_config_watch,omitempty"`  // 禁用配置文件变更自动重载
+	DisableControl      bool            `json:"disable_control,omitempty"`       // 禁用本地控制通道（Unix 套接字/命名管道）
+	StatusPage          string          `json:"status_page,omitempty"`           // 本地状态页监听地址（如 127.0.0.1:8765），为空不启用
+	Debug               *DebugSettings  `json:"debug,omitempty"`                 // 运行时诊断（pprof/expvar）
+	Plugins             []PluginConfig  `json:"plugins,omitempty"`               // 自定义脚本采集插件
+	TransformScript     string          `json:"transform_script,omitempty"`      // 上报前转换数据的 Lua 脚本路径（可添加标签、删除字段）
+	FaultInjection      *fault.Settings `json:"fault_injection,omitempty"`       // 故障注入（仅用于测试与预发布环境）
+	Capabilities        []string        `json:"capabilities,omitempty"`          // 显式开启的敏感能力（如 pcap_capture）
+
+	DisableCloudMetadata bool `json:"disable_cloud_metadata,omitempty"` // 不探测云厂商实例元数据服务
+}
+
+// MinPackageInterval 软件包信息的最小上报间隔（秒），查询包管理器开销较大
+const MinPackageInterval = 600
+
+// 需要在配置中显式开启的敏感能力
+const (
+	// CapabilityPcapCapture 允许面板远程触发抓包
+	CapabilityPcapCapture = "pcap_capture"
+	// CapabilitySpeedtestCustomURL 允许测速任务访问面板测速接口以外的 URL
+	CapabilitySpeedtestCustomURL = "speedtest_custom_url"
+	// CapabilitySupportBundle 允许面板远程获取诊断包（包含日志与脱敏后的配置）
+	CapabilitySupportBundle = "support_bundle"
+)
+
+// HasCapability 是否显式开启了指定能力
+func (c *Config) HasCapability(name string) bool {
+	for _, capability := range c.Capabilities {
+		if strings.EqualFold(strings.TrimSpace(capability), name) {
+			return true
+		}
+	}
+	return false
 }
 
 // RestartStartDelay Agent 自重启时，新进程启动前的固定延迟。
 const RestartStartDelay = 2 * time.Second
 
 // LoadConfigFromFile 从指定文件加载配置
+// 只读取配置文件和密钥存储，不会改写磁盘；旧版配置中的密钥迁移见 MigrateSecrets
 func LoadConfigFromFile(configPath string) (Config, error) {
+	cfg, _, err := loadConfigFile(configPath)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.applyDefaults()
+	return cfg, nil
+}
+
+// LoadConfigReadOnly 加载配置，供只读的命令（config get/list、doctor 等）使用
+// 已加密的密钥无法解密时（如未设置口令环境变量）不视为错误，返回不含这些密钥的配置并在标准错误输出提示
+// 返回的配置不能用于 SaveConfig，否则会丢失无法解密的密钥
+func LoadConfigReadOnly(configPath string) (Config, error) {
+	cfg, _, err := loadConfigFile(configPath)
+	if err != nil {
+		if !errors.Is(err, ErrSecretsUnavailable) {
+			return cfg, err
+		}
+		fmt.Fprintf(os.Stderr, "警告: %v，已忽略加密的密钥\n", err)
+	}
+	cfg.applyDefaults()
+	return cfg, nil
+}
+
+// LoadConfigForUpdate 读取配置文件原样的内容（不填充默认值），用于修改部分配置项后 SaveConfig 写回
+// 使用 LoadConfigFromFile 的结果写回会把所有默认值固化到配置文件，之后调整内置默认值对该主机不再生效
+func LoadConfigForUpdate(configPath string) (Config, error) {
+	cfg, _, err := loadConfigFile(configPath)
+	return cfg, err
+}
+
+// loadConfigFile 读取配置文件并合并密钥材料，同时返回是否需要迁移密钥存储
+// 密钥无法解密时返回的配置仍包含其余内容，错误包装 ErrSecretsUnavailable
+func loadConfigFile(configPath string) (Config, bool, error) {
 	var cfg Config
 
 	// 如果文件存在，读取配置
@@ -46,21 +133,127 @@ func LoadConfigFromFile(configPath string) (Config, error) {
 	if err == nil {
 		file, err := os.ReadFile(configPath)
 		if err != nil {
-			return cfg, fmt.Errorf("读取配置文件时出错: %w", err)
+			return cfg, false, fmt.Errorf("读取配置文件时出错: %w", err)
 		}
 
 		err = json.Unmarshal(file, &cfg)
 		if err != nil {
-			return cfg, fmt.Errorf("解析JSON数据时出错: %w", err)
+			return cfg, false, fmt.Errorf("解析JSON数据时出错: %w", err)
 		}
 	} else {
-		return cfg, fmt.Errorf("配置文件不存在: %s", configPath)
+		return cfg, false, fmt.Errorf("配置文件不存在: %s", configPath)
 	}
 
-	// 设置默认值
+	// 密钥材料单独存储；旧版配置文件中若仍包含密钥，则需要迁移到密钥文件
+	needMigrate := !cfg.secrets().IsEmpty()
+	secrets, encrypted, err := loadSecrets(configPath, cfg.SecretsBackend)
+	if err != nil {
+		cfg.mergeSecrets(secrets)
+		return cfg, false, err
+	}
+	cfg.mergeSecrets(secrets)
+	// 加密设置与存储状态不一致时需要重新保存（启用或关闭加密）
+	if !secrets.IsEmpty() && encrypted != cfg.EncryptSecrets {
+		needMigrate = true
+	}
+	return cfg, needMigrate, nil
+}
+
+// MigrateSecrets 将旧版配置文件中残留的密钥迁移到独立存储，并按 encrypt_secrets 重新保存密钥
+// 由 Agent 启动和 config migrate 命令显式调用，返回是否执行了迁移
+func MigrateSecrets(configPath string) (bool, error) {
+	cfg, needMigrate, err := loadConfigFile(configPath)
+	if err != nil || !needMigrate {
+		return false, err
+	}
+	if err := SaveConfig(cfg, configPath); err != nil {
+		return false, fmt.Errorf("迁移密钥到独立存储失败: %w", err)
+	}
+	return true, nil
+}
+
+// IsCollectorDisabled 判断采集项是否被关闭
+func (c *Config) IsCollectorDisabled(name string) bool {
+	for _, disabled := range c.DisabledCollectors {
+		if disabled == name {
+			return true
+		}
+	}
+	return false
+}
+
+// DebugSettings 运行时诊断配置
+type DebugSettings struct {
+	PprofEnabled bool   `json:"pprof_enabled,omitempty"` // 启用 pprof 和 expvar 诊断端点
+	PprofAddress string `json:"pprof_address,omitempty"` // 诊断端点监听地址，仅允许回环地址，默认 127.0.0.1:6060
+}
+
+// DefaultPprofAddress 诊断端点默认监听地址
+const DefaultPprofAddress = "127.0.0.1:6060"
+
+// PprofEnabled 是否启用 pprof 诊断端点
+func (c *Config) PprofEnabled() bool {
+	return c.Debug != nil && c.Debug.PprofEnabled
+}
+
+// PprofAddress 返回诊断端点监听地址
+func (c *Config) PprofAddress() string {
+	if c.Debug == nil || c.Debug.PprofAddress == "" {
+		return DefaultPprofAddress
+	}
+	return c.Debug.PprofAddress
+}
+
+// PluginConfig 自定义脚本采集插件：定期执行外部脚本，标准输出的 JSON 作为 custom_metric 上报
+type PluginConfig struct {
+	Name     string   `json:"name"`               // 插件名称，上报时用于区分
+	Path     string   `json:"path"`               // 脚本或可执行文件的绝对路径
+	Args     []string `json:"args,omitempty"`     // 命令行参数
+	Interval int      `json:"interval,omitempty"` // 执行间隔（秒），默认 60
+	Timeout  int      `json:"timeout,omitempty"`  // 单次执行超时（秒），默认 10
+}
+
+// 插件默认值
+const (
+	DefaultPluginInterval = 60
+	DefaultPluginTimeout  = 10
+)
+
+// IntervalDuration 返回插件执行间隔
+func (p PluginConfig) IntervalDuration() time.Duration {
+	if p.Interval <= 0 {
+		return DefaultPluginInterval * time.Second
+	}
+	return time.Duration(p.Interval) * time.Second
+}
+
+// TimeoutDuration 返回插件单次执行超时，不超过执行间隔
+func (p PluginConfig) TimeoutDuration() time.Duration {
+	timeout := time.Duration(p.Timeout) * time.Second
+	if p.Timeout <= 0 {
+		timeout = DefaultPluginTimeout * time.Second
+	}
+	if interval := p.IntervalDuration(); timeout > interval {
+		timeout = interval
+	}
+	return timeout
+}
+
+// DefaultConfig 返回仅包含默认值的配置（不读取配置文件）
+func DefaultConfig() Config {
+	var cfg Config
+	cfg.applyDefaults()
+	return cfg
+}
+
+// applyDefaults 为未设置的配置项填充默认值
+func (cfg *Config) applyDefaults() {
 	if cfg.LogPath == "" {
 		cfg.LogPath = "logs"
 	}
+	if cfg.LogLevel == "" {
+		cfg.LogLevel = "info"
+	}
 
 	// 设置默认上报间隔
 	if cfg.MetricsInterval <= 0 {
@@ -96,7 +289,19 @@ func LoadConfigFromFile(configPath string) (Config, error) {
 		cfg.ExcludedFilesystems = []string{"tmpfs", "devtmpfs", "squashfs", "overlay"}
 	}
 
-	return cfg, nil
+	// 设置默认公网IP查询服务
+	if len(cfg.PublicIPProviders) == 0 {
+		cfg.PublicIPProviders = []string{"https://api.ipify.org", "https://ifconfig.me/ip", "https://ipinfo.io/ip"}
+	}
+	if cfg.PublicIPInterval <= 0 {
+		cfg.PublicIPInterval = 3600
+	}
+	if cfg.ShutdownTimeout <= 0 {
+		cfg.ShutdownTimeout = 5
+	}
+	if cfg.MaxClockSkew <= 0 {
+		cfg.MaxClockSkew = 30
+	}
 }
 
 // GetConfigPath 获取配置文件路径
@@ -120,25 +325,58 @@ func GetConfigPath() string {
 	return execDir + "/agent.lock.json"
 }
 
-// SaveConfig 保存配置到文件
+// SaveConfig 保存配置到文件，密钥材料写入独立的密钥存储
 func SaveConfig(cfg Config, configPath string) error {
+	if err := SaveSecrets(cfg.secrets(), configPath, cfg.SecretsBackend, cfg.EncryptSecrets); err != nil {
+		return err
+	}
+	cfg.clearSecrets()
+
 	configJSON, err := json.MarshalIndent(cfg, "", "  ")
 	if err != nil {
 		return fmt.Errorf("序列化配置时出错: %w", err)
 	}
 
-	if err := os.WriteFile(configPath, configJSON, 0600); err != nil {
+	if err := writeFileAtomic(configPath, configJSON, 0600); err != nil {
 		return fmt.Errorf("写入文件时出错: %w", err)
 	}
-	// Best-effort 强制权限为 0600（即使文件已存在）。
-	// 在 Windows 上该权限模型不一定适用，因此不强制失败。
-	_ = os.Chmod(configPath, 0600)
-
 	return nil
 }
 
+// writeFileAtomic 先写入同目录下的临时文件再重命名覆盖目标文件
+// 临时文件创建时即为 perm 权限，不存在先以宽松权限写入、再 Chmod 的窗口期，中途失败也不会留下半截文件
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	// 重命名成功后临时文件已不存在，删除失败可忽略
+	defer os.Remove(tmpPath)
+
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
 // SetConfigValue 设置配置项的值
 func (c *Config) SetConfigValue(key, value string) error {
+	if strings.HasPrefix(key, TagKeyPrefix) {
+		return c.SetTag(strings.TrimPrefix(key, TagKeyPrefix), value)
+	}
 	switch key {
 	case "server":
 		c.Server = value
@@ -146,6 +384,69 @@ func (c *Config) SetConfigValue(key, value string) error {
 		c.Key = value
 	case "log_path":
 		c.LogPath = value
+	case "display_name":
+		c.DisplayName = strings.TrimSpace(value)
+	case "log_level":
+		if _, err := logger.ParseLevel(value); err != nil {
+			return fmt.Errorf("log_level必须是 debug/info/warn/error 之一")
+		}
+		c.LogLevel = strings.ToLower(strings.TrimSpace(value))
+	case "secrets_backend":
+		backend := strings.ToLower(strings.TrimSpace(value))
+		if backend != SecretsBackendFile && backend != SecretsBackendKeyring {
+			return fmt.Errorf("secrets_backend必须是 file/keyring 之一")
+		}
+		c.SecretsBackend = backend
+	case "heartbeat_liveness":
+		val, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("heartbeat_liveness必须是 true/false: %w", err)
+		}
+		c.HeartbeatLiveness = val
+	case "failed_logins":
+		val, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("failed_logins必须是 true/false: %w", err)
+		}
+		c.FailedLogins = val
+	case "legacy_handshake":
+		val, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("legacy_handshake必须是 true/false: %w", err)
+		}
+		c.LegacyHandshake = val
+	case "capabilities":
+		var capabilities []string
+		for _, capability := range strings.Split(value, ",") {
+			if capability = strings.TrimSpace(capability); capability != "" {
+				capabilities = append(capabilities, capability)
+			}
+		}
+		c.Capabilities = capabilities
+	case "status_page":
+		c.StatusPage = strings.TrimSpace(value)
+	case "tags":
+		return c.setTags(value)
+	case "transform_script":
+		path := strings.TrimSpace(value)
+		if path != "" && !filepath.IsAbs(path) {
+			return fmt.Errorf("transform_script必须是绝对路径")
+		}
+		c.TransformScript = path
+	case "disabled_collectors":
+		var collectors []string
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				collectors = append(collectors, name)
+			}
+		}
+		c.DisabledCollectors = collectors
+	case "encrypt_secrets":
+		val, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("encrypt_secrets必须是 true/false: %w", err)
+		}
+		c.EncryptSecrets = val
 	case "metrics_interval":
 		val, err := strconv.Atoi(value)
 		if err != nil {
@@ -173,6 +474,15 @@ func (c *Config) SetConfigValue(key, value string) error {
 			return fmt.Errorf("system_interval必须大于0")
 		}
 		c.SystemInterval = val
+	case "package_interval":
+		val, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("package_interval必须是整数: %w", err)
+		}
+		if val != 0 && val < MinPackageInterval {
+			return fmt.Errorf("package_interval必须为0（不采集）或不小于%d", MinPackageInterval)
+		}
+		c.PackageInterval = val
 	case "heartbeat_interval":
 		val, err := strconv.Atoi(value)
 		if err != nil {
@@ -191,6 +501,54 @@ func (c *Config) SetConfigValue(key, value string) error {
 			return fmt.Errorf("log_retention_days必须大于0")
 		}
 		c.LogRetentionDays = val
+	case "shutdown_timeout":
+		val, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("shutdown_timeout必须是整数: %w", err)
+		}
+		if val <= 0 {
+			return fmt.Errorf("shutdown_timeout必须大于0")
+		}
+		c.ShutdownTimeout = val
+	case "max_clock_skew":
+		val, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("max_clock_skew必须是整数: %w", err)
+		}
+		if val <= 0 {
+			return fmt.Errorf("max_clock_skew必须大于0")
+		}
+		c.MaxClockSkew = val
+	case "session_rotation":
+		val, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("session_rotation必须是整数: %w", err)
+		}
+		if val < 0 {
+			return fmt.Errorf("session_rotation不能小于0")
+		}
+		c.SessionKeyRotation = val
+	case "keypair_rotation":
+		val, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("keypair_rotation必须是整数: %w", err)
+		}
+		if val < 0 {
+			return fmt.Errorf("keypair_rotation不能小于0")
+		}
+		c.KeypairRotation = val
+	case "disable_public_ip":
+		val, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("disable_public_ip必须是 true/false: %w", err)
+		}
+		c.DisablePublicIP = val
+	case "disable_cloud_metadata":
+		val, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("disable_cloud_metadata必须是 true/false: %w", err)
+		}
+		c.DisableCloudMetadata = val
 	default:
 		return fmt.Errorf("未知的配置项: %s", key)
 	}
@@ -199,6 +557,14 @@ func (c *Config) SetConfigValue(key, value string) error {
 
 // GetConfigValue 获取配置项的值
 func (c *Config) GetConfigValue(key string) (string, error) {
+	if strings.HasPrefix(key, TagKeyPrefix) {
+		tag := strings.TrimPrefix(key, TagKeyPrefix)
+		value, ok := c.Tags[tag]
+		if !ok {
+			return "", fmt.Errorf("标签不存在: %s", tag)
+		}
+		return value, nil
+	}
 	switch key {
 	case "server":
 		return c.Server, nil
@@ -206,16 +572,57 @@ func (c *Config) GetConfigValue(key string) (string, error) {
 		return c.Key, nil
 	case "log_path":
 		return c.LogPath, nil
+	case "display_name":
+		return c.DisplayName, nil
+	case "log_level":
+		return c.LogLevel, nil
+	case "secrets_backend":
+		if c.SecretsBackend == "" {
+			return SecretsBackendFile, nil
+		}
+		return c.SecretsBackend, nil
+	case "encrypt_secrets":
+		return strconv.FormatBool(c.EncryptSecrets), nil
+	case "heartbeat_liveness":
+		return strconv.FormatBool(c.HeartbeatLiveness), nil
+	case "failed_logins":
+		return strconv.FormatBool(c.FailedLogins), nil
+	case "legacy_handshake":
+		return strconv.FormatBool(c.LegacyHandshake), nil
+	case "capabilities":
+		return strings.Join(c.Capabilities, ","), nil
+	case "status_page":
+		return c.StatusPage, nil
+	case "tags":
+		return c.TagsString(), nil
+	case "transform_script":
+		return c.TransformScript, nil
+	case "disabled_collectors":
+		return strings.Join(c.DisabledCollectors, ","), nil
+	case "shutdown_timeout":
+		return fmt.Sprintf("%d", c.ShutdownTimeout), nil
+	case "max_clock_skew":
+		return fmt.Sprintf("%d", c.MaxClockSkew), nil
 	case "metrics_interval":
 		return fmt.Sprintf("%d", c.MetricsInterval), nil
 	case "detail_interval":
 		return fmt.Sprintf("%d", c.DetailInterval), nil
 	case "system_interval":
 		return fmt.Sprintf("%d", c.SystemInterval), nil
+	case "package_interval":
+		return fmt.Sprintf("%d", c.PackageInterval), nil
 	case "heartbeat_interval":
 		return fmt.Sprintf("%d", c.HeartbeatInterval), nil
 	case "log_retention_days":
 		return fmt.Sprintf("%d", c.LogRetentionDays), nil
+	case "session_rotation":
+		return fmt.Sprintf("%d", c.SessionKeyRotation), nil
+	case "keypair_rotation":
+		return fmt.Sprintf("%d", c.KeypairRotation), nil
+	case "disable_public_ip":
+		return strconv.FormatBool(c.DisablePublicIP), nil
+	case "disable_cloud_metadata":
+		return strconv.FormatBool(c.DisableCloudMetadata), nil
 	default:
 		return "", fmt.Errorf("未知的配置项: %s", key)
 	}
@@ -0,0 +1,240 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/zalando/go-keyring"
+)
+
+// 密钥存储后端
+const (
+	SecretsBackendFile    = "file"
+	SecretsBackendKeyring = "keyring"
+)
+
+// keyringService 系统密钥环中使用的服务名
+const keyringService = "cloudsentinel-agent"
+
+// Secrets 与通用配置分开存储的密钥材料
+type Secrets struct {
+	AgentPrivateKey  string `json:"agent_private_key,omitempty"`
+	AgentPublicKey   string `json:"agent_public_key,omitempty"`
+	PanelPublicKey   string `json:"panel_public_key,omitempty"`
+	PanelFingerprint string `json:"panel_fingerprint,omitempty"`
+	SessionKey       string `json:"session_key,omitempty"`
+}
+
+// secretsFile 密钥文件（或密钥环条目）的存储格式
+type secretsFile struct {
+	Secrets
+	Encryption *secretsEncryption `json:"encryption,omitempty"` // 加密参数，为空表示明文存储
+}
+
+// IsEmpty 是否不包含任何密钥
+func (s Secrets) IsEmpty() bool {
+	return s == Secrets{}
+}
+
+// SecretsPathFor 根据配置文件路径推导密钥文件路径
+// 例如 agent.lock.json -> agent.secrets.json
+func SecretsPathFor(configPath string) string {
+	dir := filepath.Dir(configPath)
+	base := strings.TrimSuffix(filepath.Base(configPath), ".json")
+	base = strings.TrimSuffix(base, ".lock")
+	return filepath.Join(dir, base+".secrets.json")
+}
+
+// secrets 提取配置中的密钥材料
+func (c *Config) secrets() Secrets {
+	return Secrets{
+		AgentPrivateKey:  c.AgentPrivateKey,
+		AgentPublicKey:   c.AgentPublicKey,
+		PanelPublicKey:   c.PanelPublicKey,
+		PanelFingerprint: c.PanelFingerprint,
+		SessionKey:       c.SessionKey,
+	}
+}
+
+// clearSecrets 清除配置中的密钥材料
+func (c *Config) clearSecrets() {
+	c.AgentPrivateKey = ""
+	c.AgentPublicKey = ""
+	c.PanelPublicKey = ""
+	c.PanelFingerprint = ""
+	c.SessionKey = ""
+}
+
+// Redacted 返回去除密钥材料、通信密钥仅保留首尾的配置副本，可用于诊断输出
+func (c *Config) Redacted() Config {
+	redacted := *c
+	redacted.clearSecrets()
+	if len(redacted.Key) > 8 {
+		redacted.Key = redacted.Key[:4] + "..." + redacted.Key[len(redacted.Key)-4:]
+	} else if redacted.Key != "" {
+		redacted.Key = "****"
+	}
+	return redacted
+}
+
+// SecretValues 返回需要在诊断输出中脱敏的密钥原文
+// 包括通信密钥、Secrets 的全部字段（内存中的值以及密钥文件中存储的原始值，含加密后的密文）和密钥加密口令
+func (c *Config) SecretValues(configPath string) []string {
+	values := []string{c.Key, os.Getenv(SecretsPassphraseEnv)}
+	values = appendSecretFields(values, c.secrets())
+	if configPath != "" {
+		var file secretsFile
+		if data, err := os.ReadFile(SecretsPathFor(configPath)); err == nil && json.Unmarshal(data, &file) == nil {
+			values = appendSecretFields(values, file.Secrets)
+		}
+	}
+
+	result := values[:0]
+	for _, v := range values {
+		if v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// appendSecretFields 按 Secrets 的字段逐一取值，新增密钥字段时无需同步修改脱敏逻辑
+func appendSecretFields(values []string, s Secrets) []string {
+	v := reflect.ValueOf(s)
+	for i := 0; i < v.NumField(); i++ {
+		if field := v.Field(i); field.Kind() == reflect.String {
+			values = append(values, field.String())
+		}
+	}
+	return values
+}
+
+// mergeSecrets 将密钥材料合并到配置中，已存在的值不会被覆盖
+func (c *Config) mergeSecrets(s Secrets) {
+	if c.AgentPrivateKey == "" {
+		c.AgentPrivateKey = s.AgentPrivateKey
+	}
+	if c.AgentPublicKey == "" {
+		c.AgentPublicKey = s.AgentPublicKey
+	}
+	if c.PanelPublicKey == "" {
+		c.PanelPublicKey = s.PanelPublicKey
+	}
+	if c.PanelFingerprint == "" {
+		c.PanelFingerprint = s.PanelFingerprint
+	}
+	if c.SessionKey == "" {
+		c.SessionKey = s.SessionKey
+	}
+}
+
+// keyringUser 密钥环条目名，使用配置文件绝对路径以区分多个实例
+func keyringUser(configPath string) string {
+	if abs, err := filepath.Abs(configPath); err == nil {
+		return abs
+	}
+	return configPath
+}
+
+// LoadSecrets 读取密钥材料，已加密的私钥和会话密钥会被解密
+// 使用 keyring 后端时优先从系统密钥环读取，失败时回退到密钥文件
+func LoadSecrets(configPath, backend string) (Secrets, error) {
+	s, _, err := loadSecrets(configPath, backend)
+	return s, err
+}
+
+// loadSecrets 读取并解密密钥材料，同时返回存储时是否已加密
+func loadSecrets(configPath, backend string) (Secrets, bool, error) {
+	var file secretsFile
+	var data []byte
+
+	if backend == SecretsBackendKeyring {
+		raw, err := keyring.Get(keyringService, keyringUser(configPath))
+		if err == nil {
+			data = []byte(raw)
+		} else if !errors.Is(err, keyring.ErrNotFound) {
+			fmt.Fprintf(os.Stderr, "读取系统密钥环失败，回退到密钥文件: %v\n", err)
+		}
+	}
+
+	if data == nil {
+		raw, err := os.ReadFile(SecretsPathFor(configPath))
+		if err != nil {
+			if os.IsNotExist(err) {
+				return file.Secrets, false, nil
+			}
+			return file.Secrets, false, fmt.Errorf("读取密钥文件时出错: %w", err)
+		}
+		data = raw
+	}
+
+	if err := json.Unmarshal(data, &file); err != nil {
+		return file.Secrets, false, fmt.Errorf("解析密钥数据时出错: %w", err)
+	}
+	if file.Encryption == nil {
+		return file.Secrets, false, nil
+	}
+
+	s, err := decryptSecrets(file.Secrets, file.Encryption)
+	if err != nil {
+		// 无法解密时仍返回明文存储的部分，由调用方决定是否继续
+		return withoutEncrypted(file.Secrets), true, err
+	}
+	return s, true, nil
+}
+
+// SaveSecrets 保存密钥材料，encrypt 为 true 时加密私钥和会话密钥
+// 使用 keyring 后端时写入系统密钥环，失败时回退到密钥文件（0600 权限）
+func SaveSecrets(s Secrets, configPath, backend string, encrypt bool) error {
+	file := secretsFile{Secrets: s}
+	if encrypt && !s.IsEmpty() {
+		encrypted, enc, err := encryptSecrets(s)
+		if err != nil {
+			return err
+		}
+		file = secretsFile{Secrets: encrypted, Encryption: enc}
+	}
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化密钥时出错: %w", err)
+	}
+
+	secretsPath := SecretsPathFor(configPath)
+
+	if backend == SecretsBackendKeyring {
+		if err := keyring.Set(keyringService, keyringUser(configPath), string(data)); err == nil {
+			// 密钥已写入密钥环，移除可能残留的密钥文件
+			_ = os.Remove(secretsPath)
+			return nil
+		} else {
+			fmt.Fprintf(os.Stderr, "写入系统密钥环失败，回退到密钥文件: %v\n", err)
+		}
+	}
+
+	if s.IsEmpty() {
+		if err := os.Remove(secretsPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("删除密钥文件时出错: %w", err)
+		}
+		return nil
+	}
+
+	if err := writeFileAtomic(secretsPath, data, 0600); err != nil {
+		return fmt.Errorf("写入密钥文件时出错: %w", err)
+	}
+	return nil
+}
+
+// DeleteSecrets 删除密钥文件及密钥环中的条目
+func DeleteSecrets(configPath string) error {
+	_ = keyring.Delete(keyringService, keyringUser(configPath))
+	if err := os.Remove(SecretsPathFor(configPath)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"agent/internal/crypto"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// SecretsPassphraseEnv 密钥加密口令的环境变量，设置后优先于机器标识
+const SecretsPassphraseEnv = "CLOUDSENTINEL_SECRETS_PASSPHRASE"
+
+// 加密密钥来源
+const (
+	KeySourceMachine    = "machine"
+	KeySourcePassphrase = "passphrase"
+)
+
+// ErrSecretsUnavailable 已加密的密钥无法解密（未设置口令、机器标识或口令不匹配）
+var ErrSecretsUnavailable = errors.New("无法解密已加密的密钥")
+
+const (
+	encryptedPrefix   = "enc:"
+	secretsSaltSize   = 16
+	secretsIterations = 100000
+)
+
+// secretsEncryption 密钥文件中记录的加密参数
+type secretsEncryption struct {
+	KeySource  string `json:"key_source"`
+	Salt       string `json:"salt"`
+	Iterations int    `json:"iterations"`
+}
+
+// secretsKeySource 当前环境下使用的密钥来源
+func secretsKeySource() string {
+	if os.Getenv(SecretsPassphraseEnv) != "" {
+		return KeySourcePassphrase
+	}
+	return KeySourceMachine
+}
+
+// secretsKeyMaterial 获取派生加密密钥所需的原始材料
+func secretsKeyMaterial(source string) ([]byte, error) {
+	if source == KeySourcePassphrase {
+		passphrase := os.Getenv(SecretsPassphraseEnv)
+		if passphrase == "" {
+			return nil, fmt.Errorf("%w: 密钥使用口令加密，但未设置环境变量 %s", ErrSecretsUnavailable, SecretsPassphraseEnv)
+		}
+		return []byte(passphrase), nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	id, err := InitSystem().GetHostIDWithContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: 获取机器标识失败: %v", ErrSecretsUnavailable, err)
+	}
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return nil, fmt.Errorf("%w: 获取机器标识失败: 标识为空", ErrSecretsUnavailable)
+	}
+	return []byte(id), nil
+}
+
+// deriveSecretsKey 根据加密参数派生 AES 密钥
+func deriveSecretsKey(enc *secretsEncryption) ([]byte, error) {
+	material, err := secretsKeyMaterial(enc.KeySource)
+	if err != nil {
+		return nil, err
+	}
+	salt, err := base64.StdEncoding.DecodeString(enc.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("解析密钥盐值失败: %w", err)
+	}
+	return crypto.DeriveKey(material, salt, enc.Iterations), nil
+}
+
+// encryptSecrets 加密私钥和会话密钥，返回加密后的密钥及加密参数
+func encryptSecrets(s Secrets) (Secrets, *secretsEncryption, error) {
+	salt := make([]byte, secretsSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return s, nil, fmt.Errorf("生成密钥盐值失败: %w", err)
+	}
+	enc := &secretsEncryption{
+		KeySource:  secretsKeySource(),
+		Salt:       base64.StdEncoding.EncodeToString(salt),
+		Iterations: secretsIterations,
+	}
+	key, err := deriveSecretsKey(enc)
+	if err != nil {
+		return s, nil, err
+	}
+
+	for _, field := range []*string{&s.AgentPrivateKey, &s.SessionKey} {
+		if *field == "" || strings.HasPrefix(*field, encryptedPrefix) {
+			continue
+		}
+		ciphertext, err := crypto.EncryptMessage([]byte(*field), key)
+		if err != nil {
+			return s, nil, fmt.Errorf("加密密钥失败: %w", err)
+		}
+		*field = encryptedPrefix + base64.StdEncoding.EncodeToString(ciphertext)
+	}
+	return s, enc, nil
+}
+
+// withoutEncrypted 去除仍处于加密状态的密钥，仅保留明文存储的公钥和指纹
+func withoutEncrypted(s Secrets) Secrets {
+	for _, field := range []*string{&s.AgentPrivateKey, &s.SessionKey} {
+		if strings.HasPrefix(*field, encryptedPrefix) {
+			*field = ""
+		}
+	}
+	return s
+}
+
+// decryptSecrets 使用加密参数解密私钥和会话密钥
+func decryptSecrets(s Secrets, enc *secretsEncryption) (Secrets, error) {
+	key, err := deriveSecretsKey(enc)
+	if err != nil {
+		return s, err
+	}
+
+	for _, field := range []*string{&s.AgentPrivateKey, &s.SessionKey} {
+		if !strings.HasPrefix(*field, encryptedPrefix) {
+			continue
+		}
+		ciphertext, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(*field, encryptedPrefix))
+		if err != nil {
+			return s, fmt.Errorf("解析加密密钥失败: %w", err)
+		}
+		plaintext, err := crypto.DecryptMessage(ciphertext, key)
+		if err != nil {
+			return s, fmt.Errorf("%w: 机器标识或口令不匹配: %v", ErrSecretsUnavailable, err)
+		}
+		*field = string(plaintext)
+	}
+	return s, nil
+}
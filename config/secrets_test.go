@@ -0,0 +1,37 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestSecretValues(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "agent.lock.json")
+	// 密钥文件中存储的旧值（含加密后的密文）同样需要脱敏
+	stored := `{"session_key":"enc:c3RvcmVkLWNpcGhlcnRleHQ=","panel_fingerprint":"stored-fingerprint"}`
+	if err := os.WriteFile(SecretsPathFor(configPath), []byte(stored), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(SecretsPassphraseEnv, "passphrase-value")
+
+	cfg := Config{
+		Key:              "communication-key",
+		AgentPrivateKey:  "private-key-pem",
+		PanelFingerprint: "current-fingerprint",
+	}
+	values := cfg.SecretValues(configPath)
+	for _, want := range []string{
+		"communication-key", "private-key-pem", "current-fingerprint",
+		"enc:c3RvcmVkLWNpcGhlcnRleHQ=", "stored-fingerprint", "passphrase-value",
+	} {
+		if !slices.Contains(values, want) {
+			t.Errorf("缺少 %q: %v", want, values)
+		}
+	}
+	if slices.Contains(values, "") {
+		t.Errorf("不应包含空值: %v", values)
+	}
+}
@@ -0,0 +1,64 @@
+package config
+
+import "sync"
+
+// Store 运行期间多个协程共享的配置，读写均加锁
+// 同时记录实际使用的配置文件路径（可能由 --config 指定），保存时写回该文件
+type Store struct {
+	mu   sync.RWMutex
+	cfg  Config
+	path string
+}
+
+// NewStore 创建共享配置，path 为配置文件路径，为空时使用默认路径
+func NewStore(cfg Config, path string) *Store {
+	if path == "" {
+		path = GetConfigPath()
+	}
+	return &Store{cfg: cfg, path: path}
+}
+
+// Get 返回当前配置的副本
+func (s *Store) Get() Config {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg
+}
+
+// Path 返回配置文件路径
+func (s *Store) Path() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.path
+}
+
+// Update 在锁内修改配置并返回修改后的副本
+func (s *Store) Update(fn func(cfg *Config)) Config {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(&s.cfg)
+	return s.cfg
+}
+
+// Replace 以重新加载的配置替换当前配置
+// 运行期间协商或轮换得到的密钥材料以内存中的为准，不会被文件中的旧值覆盖
+func (s *Store) Replace(cfg Config) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fileSecrets := cfg.secrets()
+	cfg.clearSecrets()
+	cfg.mergeSecrets(s.cfg.secrets())
+	cfg.mergeSecrets(fileSecrets)
+	s.cfg = cfg
+}
+
+// SaveSecrets 仅将当前的密钥材料写入密钥存储，不改写配置文件
+func (s *Store) SaveSecrets() error {
+	s.mu.RLock()
+	secrets := s.cfg.secrets()
+	path := s.path
+	backend := s.cfg.SecretsBackend
+	encrypt := s.cfg.EncryptSecrets
+	s.mu.RUnlock()
+	return SaveSecrets(secrets, path, backend, encrypt)
+}
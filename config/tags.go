@@ -0,0 +1,79 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// TagKeyPrefix 单个标签的配置项前缀，如 tags.env
+const TagKeyPrefix = "tags."
+
+// maxTagValueLength 标签值的最大长度
+const maxTagValueLength = 255
+
+// Tags 主机标签
+type Tags map[string]string
+
+// tagKeyPattern 标签名：字母或数字开头，可包含 _ . -，最长 63 个字符
+var tagKeyPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.\-]{0,62}$`)
+
+// SetTag 设置单个标签，值为空时删除该标签
+func (c *Config) SetTag(key, value string) error {
+	key = strings.TrimSpace(key)
+	value = strings.TrimSpace(value)
+	if !tagKeyPattern.MatchString(key) {
+		return fmt.Errorf("无效的标签名: %q（字母或数字开头，仅包含字母、数字、_ . -，最长63个字符）", key)
+	}
+	if len(value) > maxTagValueLength {
+		return fmt.Errorf("标签 %s 的值过长（最多%d个字符）", key, maxTagValueLength)
+	}
+	if value == "" {
+		delete(c.Tags, key)
+		if len(c.Tags) == 0 {
+			c.Tags = nil
+		}
+		return nil
+	}
+	if c.Tags == nil {
+		c.Tags = make(Tags)
+	}
+	c.Tags[key] = value
+	return nil
+}
+
+// setTags 以 "k1=v1,k2=v2" 格式整体替换标签，空字符串清空所有标签
+func (c *Config) setTags(value string) error {
+	tags := c.Tags
+	c.Tags = nil
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(val) == "" {
+			c.Tags = tags
+			return fmt.Errorf("tags格式应为 key=value,key2=value2: %q", pair)
+		}
+		if err := c.SetTag(key, val); err != nil {
+			c.Tags = tags
+			return err
+		}
+	}
+	return nil
+}
+
+// TagsString 以 "k1=v1,k2=v2" 格式返回标签（按名称排序）
+func (c *Config) TagsString() string {
+	keys := make([]string, 0, len(c.Tags))
+	for key := range c.Tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+c.Tags[key])
+	}
+	return strings.Join(pairs, ",")
+}
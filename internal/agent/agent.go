@@ -3,11 +3,15 @@ package agent
 import (
 	"agent/config"
 	"agent/internal/collector"
+	"agent/internal/control"
+	"agent/internal/fault"
 	"agent/internal/logger"
 	"agent/internal/process"
 	"agent/internal/reporter"
+	"agent/internal/statuspage"
 	"agent/internal/system"
 	"agent/internal/websocket"
+	"net/http"
 	"os"
 	"os/signal"
 	"sync"
@@ -16,17 +20,25 @@ import (
 )
 
 type Agent struct {
-	cfg       config.Config
-	logger    *logger.Logger
-	sys       *system.System
-	client    *websocket.Client
-	collector *collector.Collector
-	pm        *process.ProcessManager
-	wg        sync.WaitGroup
-	sigChan   chan os.Signal
-	stopChan  chan struct{}
-	mu        sync.Mutex
-	running   bool
+	cfg        config.Config
+	configPath string
+	store      *config.Store // 与 Reporter 共享的配置，重载时同步更新
+	logger     *logger.Logger
+	sys        *system.System
+	client     *websocket.Client
+	collector  *collector.Collector
+	pm         *process.ProcessManager
+	wg         sync.WaitGroup
+	sigChan    chan os.Signal
+	stopChan   chan struct{}
+	stopDone   chan struct{}
+	stopping   bool
+	mu         sync.Mutex
+	running    bool
+	startedAt  time.Time
+	control    *control.Server
+	statusPage *statuspage.Server
+	diagServer *http.Server
 }
 
 // NewAgent 创建新的Agent实例
@@ -50,6 +62,17 @@ func NewAgent(cfg config.Config) (*Agent, error) {
 
 	// 初始化日志
 	logger := config.InitLogger(cfg.LogPath, cfg.LogRetentionDays)
+	if err := logger.SetLevel(cfg.LogLevel); err != nil {
+		logger.Warn("日志级别配置无效，使用默认级别 info: %v", err)
+	}
+
+	// 故障注入（仅用于测试，需使用 -tags faultinject 构建）
+	fault.Configure(cfg.FaultInjection)
+	if fault.Enabled() {
+		logger.Warn("故障注入已启用，请勿在生产环境使用: %s", fault.Describe())
+	} else if cfg.FaultInjection != nil && !fault.Available {
+		logger.Warn("当前版本未包含故障注入功能，已忽略 fault_injection 配置")
+	}
 
 	// 初始化系统信息
 	sys := config.InitSystem()
@@ -60,6 +83,9 @@ func NewAgent(cfg config.Config) (*Agent, error) {
 	// 创建数据收集器
 	col := collector.NewCollector(sys, logger, client, cfg)
 
+	// 心跳可选携带精简存活数据（由 heartbeat_liveness 控制）
+	client.HeartbeatPayload = col.HeartbeatPayload
+
 	// 设置日志处理器
 	logger.SetHandler(func(level, message string) {
 		// 发送日志到服务器
@@ -75,15 +101,17 @@ func NewAgent(cfg config.Config) (*Agent, error) {
 	pm.SetHeartbeatInterval(time.Duration(cfg.HeartbeatInterval) * time.Second)
 
 	return &Agent{
-		cfg:       cfg,
-		logger:    logger,
-		sys:       sys,
-		client:    client,
-		collector: col,
-		pm:        pm,
-		sigChan:   make(chan os.Signal, 1),
-		stopChan:  make(chan struct{}),
-		running:   false,
+		cfg:        cfg,
+		configPath: config.GetConfigPath(),
+		logger:     logger,
+		sys:        sys,
+		client:     client,
+		collector:  col,
+		pm:         pm,
+		sigChan:    make(chan os.Signal, 1),
+		stopChan:   make(chan struct{}),
+		stopDone:   make(chan struct{}),
+		running:    false,
 	}, nil
 }
 
@@ -95,6 +123,8 @@ func (a *Agent) Start() error {
 		return nil
 	}
 	a.running = true
+	a.startedAt = time.Now()
+	a.store = config.NewStore(a.cfg, a.configPath)
 	a.mu.Unlock()
 
 	// 连接到服务器
@@ -120,20 +150,21 @@ func (a *Agent) Start() error {
 			a.pm.StopHeartbeat()
 			a.pm.StopReporter()
 		},
-		OnReload: func() {
+		OnReload: func() error {
 			a.logger.Info("收到配置重载请求，正在重载配置...")
 			if err := a.Reload(); err != nil {
 				a.logger.Error("配置重载失败: %v", err)
-			} else {
-				a.logger.Info("配置重载成功")
+				return err
 			}
+			a.logger.Info("配置重载成功")
+			return nil
 		},
 	}
 
 	a.wg.Add(1)
 	go func() {
 		defer a.wg.Done()
-		reporter.StartReporter(a.client, a.logger, a.cfg, callbacks)
+		reporter.StartReporter(a.client, a.logger, a.store, callbacks)
 	}()
 
 	// 设置信号处理，优雅退出
@@ -142,9 +173,38 @@ func (a *Agent) Start() error {
 	// 启动信号处理循环
 	go a.handleSignals()
 
+	// 监听配置文件变化
+	if !a.cfg.DisableConfigWatch {
+		go a.watchConfig()
+	}
+
+	// 定期写入运行状态，供 'agent status' 查询
+	go a.runStateWriter()
+
+	// 本地控制通道
+	if !a.cfg.DisableControl {
+		a.startControl()
+	}
+
+	// 本地状态页（可选）
+	a.startStatusPage()
+
+	// 运行时诊断端点（可选）
+	a.startPprof()
+
 	return nil
 }
 
+// SetConfigPath 设置配置文件路径（用于重载和监听）
+func (a *Agent) SetConfigPath(path string) {
+	if path == "" {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.configPath = path
+}
+
 // handleSignals 处理系统信号
 func (a *Agent) handleSignals() {
 	for {
@@ -162,7 +222,7 @@ func (a *Agent) handleSignals() {
 			case os.Interrupt, syscall.SIGTERM:
 				// 优雅退出
 				a.logger.Info("收到退出信号，正在关闭...")
-				a.Stop()
+				a.StopWithReason("signal: " + sig.String())
 				return
 			}
 		case <-a.stopChan:
@@ -173,18 +233,43 @@ func (a *Agent) handleSignals() {
 
 // Stop 停止agent
 func (a *Agent) Stop() {
+	a.StopWithReason("stop")
+}
+
+// StopWithReason 优雅停止agent：停止定时任务、排空待发送数据并通知面板后再断开连接
+func (a *Agent) StopWithReason(reason string) {
 	a.mu.Lock()
 	if !a.running {
+		stopping := a.stopping
 		a.mu.Unlock()
+		// 其他调用方（信号处理或服务管理器）正在停止时，等待其完成
+		if stopping {
+			<-a.stopDone
+		}
 		return
 	}
 	a.running = false
+	a.stopping = true
 	close(a.stopChan)
 	a.mu.Unlock()
+	defer close(a.stopDone)
 
-	// 优雅关闭所有子进程
+	if a.control != nil {
+		a.control.Close()
+	}
+	if a.statusPage != nil {
+		a.statusPage.Close()
+	}
+	if a.diagServer != nil {
+		a.diagServer.Close()
+	}
+
+	// 优雅关闭所有子进程（停止心跳和定时上报）
 	a.pm.Shutdown()
-	a.client.Close()
+
+	// 排空待发送数据后以关闭帧断开连接
+	a.drain(reason)
+	a.client.CloseWithReason(reason)
 
 	// 等待所有 goroutine 完成
 	done := make(chan struct{})
@@ -201,10 +286,53 @@ func (a *Agent) Stop() {
 	}
 }
 
+// drain 发送缓冲中的日志和 agent_shutdown 消息，最多等待 shutdown_timeout
+func (a *Agent) drain(reason string) {
+	if !a.client.IsConnected() {
+		return
+	}
+
+	a.mu.Lock()
+	timeout := time.Duration(a.cfg.ShutdownTimeout) * time.Second
+	a.mu.Unlock()
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	deadline := time.Now().Add(timeout)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if !a.collector.FlushLogs(time.Until(deadline)) {
+			a.logger.Warn("排空日志超时，部分日志未发送")
+		}
+		message := websocket.Message{
+			Type: "agent_shutdown",
+			Data: map[string]interface{}{
+				"reason":         reason,
+				"uptime_seconds": int64(time.Since(a.startedAt).Seconds()),
+				"timestamp":      time.Now().Unix(),
+			},
+		}
+		if err := a.client.SendMessage(message); err != nil {
+			a.logger.Warn("发送关闭通知失败: %v", err)
+		}
+	}()
+
+	select {
+	case <-done:
+		a.logger.Info("待发送数据已排空")
+	case <-time.After(time.Until(deadline)):
+		a.logger.Warn("排空待发送数据超时（%s），直接关闭连接", timeout)
+	}
+}
+
 // Reload 重载配置
 func (a *Agent) Reload() error {
 	// 重新加载配置
-	configPath := config.GetConfigPath()
+	a.mu.Lock()
+	configPath := a.configPath
+	a.mu.Unlock()
 	newCfg, err := config.LoadConfigFromFile(configPath)
 	if err != nil {
 		return err
@@ -214,10 +342,24 @@ func (a *Agent) Reload() error {
 	a.mu.Lock()
 	oldCfg := a.cfg
 	a.cfg = newCfg
+	store := a.store
 	a.mu.Unlock()
 
-	// 如果配置有变化，更新收集器
+	// 同步到收集器和 Reporter
 	a.collector.UpdateConfig(newCfg)
+	if store != nil {
+		store.Replace(newCfg)
+	}
+
+	fault.Configure(newCfg.FaultInjection)
+
+	if oldCfg.LogLevel != newCfg.LogLevel {
+		if err := a.logger.SetLevel(newCfg.LogLevel); err != nil {
+			a.logger.Warn("日志级别配置无效: %v", err)
+		} else {
+			a.logger.Info("日志级别已更新为: %s", newCfg.LogLevel)
+		}
+	}
 
 	if oldCfg.HeartbeatInterval != newCfg.HeartbeatInterval {
 		a.pm.SetHeartbeatInterval(time.Duration(newCfg.HeartbeatInterval) * time.Second)
@@ -0,0 +1,130 @@
+package agent
+
+import (
+	"agent/internal/control"
+	"agent/internal/reporter"
+	"agent/internal/supportbundle"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// flushTimeout 控制通道 flush 命令的采集超时
+const flushTimeout = 30 * time.Second
+
+// startControl 启动本地控制通道，供 CLI 查询状态、重载配置、立即上报和切换调试日志
+func (a *Agent) startControl() {
+	a.mu.Lock()
+	address := control.AddressFor(a.configPath)
+	a.mu.Unlock()
+
+	server := control.NewServer(address, a.logger)
+	server.Handle("status", func(args []string) (interface{}, error) {
+		return a.snapshotState(), nil
+	})
+	server.Handle("reload", func(args []string) (interface{}, error) {
+		if err := a.Reload(); err != nil {
+			return nil, err
+		}
+		a.logger.Info("已通过控制通道重载配置")
+		return nil, nil
+	})
+	server.Handle("flush", func(args []string) (interface{}, error) {
+		if !a.client.IsConnected() {
+			return nil, fmt.Errorf("未连接到服务器")
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+		defer cancel()
+		a.collector.Flush(ctx)
+		return nil, nil
+	})
+	server.Handle("debug", a.handleDebugCommand)
+	server.Handle("support_bundle", a.handleSupportBundleCommand)
+
+	if err := server.Start(); err != nil {
+		a.logger.Warn("启动本地控制通道失败: %v", err)
+		return
+	}
+	a.control = server
+	a.logger.Info("本地控制通道已启动: %s", address)
+}
+
+// handleDebugCommand 开启或关闭调试日志，无参数时仅返回当前级别
+// 关闭时恢复为配置文件中的日志级别
+func (a *Agent) handleDebugCommand(args []string) (interface{}, error) {
+	if len(args) > 0 {
+		switch args[0] {
+		case "on":
+			if err := a.logger.SetLevel("debug"); err != nil {
+				return nil, err
+			}
+			a.logger.Info("已通过控制通道开启调试日志")
+		case "off":
+			a.mu.Lock()
+			level := a.cfg.LogLevel
+			a.mu.Unlock()
+			if err := a.logger.SetLevel(level); err != nil {
+				a.logger.SetLevel("info")
+			}
+			a.logger.Info("已通过控制通道关闭调试日志")
+		default:
+			return nil, fmt.Errorf("无效参数: %s（可选 on/off）", args[0])
+		}
+	}
+	return map[string]string{"log_level": a.logger.Level()}, nil
+}
+
+// handleSupportBundleCommand 生成诊断包并写入指定路径
+// 参数: <输出文件绝对路径> [日志文件数]
+// 输出文件必须不存在，避免以 Agent 的权限覆盖任意已有文件（包括经由符号链接）
+func (a *Agent) handleSupportBundleCommand(args []string) (interface{}, error) {
+	if len(args) == 0 || !filepath.IsAbs(args[0]) {
+		return nil, fmt.Errorf("需要指定输出文件的绝对路径")
+	}
+	logFiles := 0
+	if len(args) > 1 {
+		n, err := strconv.Atoi(args[1])
+		if err != nil {
+			return nil, fmt.Errorf("无效的日志文件数: %s", args[1])
+		}
+		logFiles = n
+	}
+
+	a.mu.Lock()
+	cfg := a.cfg
+	configPath := a.configPath
+	a.mu.Unlock()
+
+	data, err := supportbundle.Build(supportbundle.Options{
+		Config:         &cfg,
+		ConfigPath:     configPath,
+		Logger:         a.logger,
+		LogFiles:       logFiles,
+		Goroutines:     true,
+		State:          a.snapshotState(),
+		ProtocolErrors: reporter.RecentProtocolErrors(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(args[0], os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		if os.IsExist(err) {
+			return nil, fmt.Errorf("输出文件已存在: %s", args[0])
+		}
+		return nil, err
+	}
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(args[0])
+		return nil, err
+	}
+	a.logger.Info("已通过控制通道生成诊断包: %s", args[0])
+	return map[string]interface{}{"path": args[0], "size": len(data)}, nil
+}
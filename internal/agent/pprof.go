@@ -0,0 +1,77 @@
+package agent
+
+import (
+	"errors"
+	"expvar"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"sync"
+	"time"
+)
+
+var publishExpvarOnce sync.Once
+
+// startPprof 在回环地址上启动 pprof 和 expvar 诊断端点（debug.pprof_enabled 开启时）
+func (a *Agent) startPprof() {
+	a.mu.Lock()
+	enabled := a.cfg.PprofEnabled()
+	address := a.cfg.PprofAddress()
+	a.mu.Unlock()
+	if !enabled {
+		return
+	}
+
+	if !isLoopbackAddress(address) {
+		a.logger.Warn("诊断端点只能监听回环地址，已忽略: %s", address)
+		return
+	}
+
+	publishExpvarOnce.Do(func() {
+		expvar.Publish("goroutines", expvar.Func(func() interface{} {
+			return runtime.NumGoroutine()
+		}))
+		expvar.Publish("agent", expvar.Func(func() interface{} {
+			return a.snapshotState()
+		}))
+		expvar.Publish("collectors", expvar.Func(func() interface{} {
+			return a.collector.CollectorStats()
+		}))
+	})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		a.logger.Warn("启动诊断端点失败: %v", err)
+		return
+	}
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	a.diagServer = server
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			a.logger.Warn("诊断端点异常退出: %v", err)
+		}
+	}()
+	a.logger.Warn("pprof 诊断端点已启用: http://%s/debug/pprof/", address)
+}
+
+// isLoopbackAddress 判断监听地址是否为回环地址
+func isLoopbackAddress(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,133 @@
+package agent
+
+import (
+	"agent/internal/version"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// stateWriteInterval 运行状态文件的刷新间隔
+const stateWriteInterval = 10 * time.Second
+
+// State 运行中的 Agent 定期写入的状态快照，供 CLI 查询
+type State struct {
+	PID         int                  `json:"pid"`
+	Version     string               `json:"version"`
+	StartedAt   time.Time            `json:"started_at"`
+	UpdatedAt   time.Time            `json:"updated_at"`
+	Connected   bool                 `json:"connected"`
+	LastReports map[string]time.Time `json:"last_reports"`
+	LastErrors  map[string]string    `json:"last_errors,omitempty"`
+	QueueLength int                  `json:"queue_length"`
+	LogLevel    string               `json:"log_level"`
+}
+
+// Uptime 返回 Agent 已运行时长
+func (s *State) Uptime() time.Duration {
+	if s.StartedAt.IsZero() {
+		return 0
+	}
+	return time.Since(s.StartedAt)
+}
+
+// IsStale 状态文件长时间未刷新时认为已过期（进程可能已异常退出）
+func (s *State) IsStale() bool {
+	return time.Since(s.UpdatedAt) > 3*stateWriteInterval
+}
+
+// StatePathFor 返回配置文件对应的运行状态文件路径（与配置文件同目录）
+func StatePathFor(configPath string) string {
+	dir := filepath.Dir(configPath)
+	base := strings.TrimSuffix(filepath.Base(configPath), ".lock.json")
+	base = strings.TrimSuffix(base, ".json")
+	return filepath.Join(dir, base+".state.json")
+}
+
+// ReadState 读取运行状态文件
+func ReadState(configPath string) (*State, error) {
+	data, err := os.ReadFile(StatePathFor(configPath))
+	if err != nil {
+		return nil, err
+	}
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// snapshotState 生成当前运行状态快照
+func (a *Agent) snapshotState() State {
+	lastReports := make(map[string]time.Time)
+	lastErrors := make(map[string]string)
+	for _, stat := range a.collector.CollectorStats() {
+		if !stat.LastRun.IsZero() {
+			lastReports[stat.Name] = stat.LastRun
+		}
+		if stat.LastError != "" {
+			lastErrors[stat.Name] = stat.LastError
+		} else if stat.LastSendError != "" {
+			lastErrors[stat.Name] = stat.LastSendError
+		}
+	}
+	return State{
+		PID:         os.Getpid(),
+		Version:     version.AgentVersion,
+		StartedAt:   a.startedAt,
+		UpdatedAt:   time.Now(),
+		Connected:   a.client.IsConnected(),
+		LastReports: lastReports,
+		LastErrors:  lastErrors,
+		QueueLength: a.collector.QueueLength(),
+		LogLevel:    a.logger.Level(),
+	}
+}
+
+// writeState 写入运行状态文件（先写临时文件再重命名，避免读到半截内容）
+func (a *Agent) writeState() error {
+	a.mu.Lock()
+	path := StatePathFor(a.configPath)
+	a.mu.Unlock()
+
+	data, err := json.MarshalIndent(a.snapshotState(), "", "  ")
+	if err != nil {
+		return err
+	}
+	// 状态文件包含连接与运行信息，仅允许运行 Agent 的用户读取
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return err
+	}
+	// 临时文件可能由旧版本以更宽的权限创建，WriteFile 不会修改已存在文件的权限
+	if err := os.Chmod(tmpPath, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// runStateWriter 定期刷新运行状态文件，退出时删除
+func (a *Agent) runStateWriter() {
+	ticker := time.NewTicker(stateWriteInterval)
+	defer ticker.Stop()
+
+	if err := a.writeState(); err != nil {
+		a.logger.Warn("写入运行状态文件失败: %v", err)
+	}
+	for {
+		select {
+		case <-ticker.C:
+			if err := a.writeState(); err != nil {
+				a.logger.Debug("写入运行状态文件失败: %v", err)
+			}
+		case <-a.stopChan:
+			a.mu.Lock()
+			path := StatePathFor(a.configPath)
+			a.mu.Unlock()
+			os.Remove(path)
+			return
+		}
+	}
+}
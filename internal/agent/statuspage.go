@@ -0,0 +1,69 @@
+package agent
+
+import (
+	"agent/internal/statuspage"
+	"sort"
+	"strings"
+)
+
+// statusPageLogLines 状态页展示的最近日志条数
+const statusPageLogLines = 100
+
+// startStatusPage 启动本地状态页（仅在配置了 status_page 时启用）
+func (a *Agent) startStatusPage() {
+	a.mu.Lock()
+	address := a.cfg.StatusPage
+	a.mu.Unlock()
+	if address == "" {
+		return
+	}
+
+	server := statuspage.NewServer(address, a.logger, a.statusPageSnapshot)
+	if err := server.Start(); err != nil {
+		a.logger.Warn("启动本地状态页失败: %v", err)
+		return
+	}
+	a.statusPage = server
+	a.logger.Info("本地状态页已启动: http://%s/", address)
+}
+
+// statusPageSnapshot 汇总状态页所需的数据
+func (a *Agent) statusPageSnapshot() statuspage.Snapshot {
+	state := a.snapshotState()
+
+	payloads := make([]statuspage.Payload, 0)
+	for name, payload := range a.collector.LastPayloads() {
+		payloads = append(payloads, statuspage.Payload{Type: name, Time: payload.Time, Data: payload.Data})
+	}
+	sort.Slice(payloads, func(i, j int) bool { return payloads[i].Type < payloads[j].Type })
+
+	return statuspage.Snapshot{
+		Connected: state.Connected,
+		Status:    state,
+		Config:    a.configSummary(),
+		Payloads:  payloads,
+		Logs:      a.logger.Recent(statusPageLogLines),
+	}
+}
+
+// configSummary 返回不含敏感信息的配置摘要
+func (a *Agent) configSummary() map[string]interface{} {
+	a.mu.Lock()
+	cfg := a.cfg
+	configPath := a.configPath
+	a.mu.Unlock()
+
+	return map[string]interface{}{
+		"config_path":        configPath,
+		"server":             cfg.Server,
+		"display_name":       cfg.DisplayName,
+		"log_level":          cfg.LogLevel,
+		"log_path":           cfg.LogPath,
+		"metrics_interval":   cfg.MetricsInterval,
+		"detail_interval":    cfg.DetailInterval,
+		"system_interval":    cfg.SystemInterval,
+		"heartbeat_interval": cfg.HeartbeatInterval,
+		"encryption_enabled": a.client.IsEncryptionEnabled(),
+		"capabilities":       strings.Join(cfg.Capabilities, ","),
+	}
+}
@@ -0,0 +1,73 @@
+package agent
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// configWatchDebounce 配置文件变更的防抖时间，避免编辑器多次写入触发多次重载
+const configWatchDebounce = 1 * time.Second
+
+// watchConfig 监听配置文件变化并自动重载
+// 监听的是配置文件所在目录，以兼容编辑器“写临时文件再重命名”的保存方式
+func (a *Agent) watchConfig() {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		a.logger.Warn("创建配置文件监听器失败: %v", err)
+		return
+	}
+	defer watcher.Close()
+
+	configFile := filepath.Clean(a.configPath)
+	if err := watcher.Add(filepath.Dir(configFile)); err != nil {
+		a.logger.Warn("监听配置文件目录失败: %v", err)
+		return
+	}
+	a.logger.Info("已启用配置文件自动重载: %s", configFile)
+
+	var debounce *time.Timer
+	reloadChan := make(chan struct{}, 1)
+
+	for {
+		select {
+		case <-a.stopChan:
+			if debounce != nil {
+				debounce.Stop()
+			}
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) != configFile {
+				continue
+			}
+			if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) && !event.Has(fsnotify.Rename) {
+				continue
+			}
+			if debounce != nil {
+				debounce.Stop()
+			}
+			debounce = time.AfterFunc(configWatchDebounce, func() {
+				select {
+				case reloadChan <- struct{}{}:
+				default:
+				}
+			})
+		case <-reloadChan:
+			a.logger.Info("检测到配置文件变化，正在重载配置...")
+			if err := a.Reload(); err != nil {
+				a.logger.Error("配置重载失败: %v", err)
+			} else {
+				a.logger.Info("配置重载成功")
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			a.logger.Warn("配置文件监听出错: %v", err)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package collector
+
+import (
+	"agent/internal/system"
+	"context"
+	"sync"
+	"time"
+)
+
+// cloudMetadataRetryInterval 未检测到云厂商元数据时重新探测的间隔
+const cloudMetadataRetryInterval = time.Hour
+
+// cloudMetadataTimeout 一次探测的总超时
+const cloudMetadataTimeout = 5 * time.Second
+
+// cloudMetadataResolver 云厂商元数据解析器
+// 探测在后台进行，调用方始终立即拿到缓存值；实例元数据在进程生命周期内不会变化，检测成功后不再重复查询
+type cloudMetadataResolver struct {
+	mu        sync.Mutex
+	metadata  *system.CloudMetadata
+	lastProbe time.Time
+	probing   bool
+}
+
+func newCloudMetadataResolver() *cloudMetadataResolver {
+	return &cloudMetadataResolver{}
+}
+
+// Get 返回缓存的元数据，尚未检测或非云主机时返回 nil
+func (r *cloudMetadataResolver) Get(sys *system.System) *system.CloudMetadata {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.metadata == nil && !r.probing &&
+		(r.lastProbe.IsZero() || time.Since(r.lastProbe) >= cloudMetadataRetryInterval) {
+		r.lastProbe = time.Now()
+		r.probing = true
+		go r.probe(sys)
+	}
+	return r.metadata
+}
+
+func (r *cloudMetadataResolver) probe(sys *system.System) {
+	ctx, cancel := context.WithTimeout(context.Background(), cloudMetadataTimeout)
+	defer cancel()
+	metadata, err := sys.GetCloudMetadataWithContext(ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.probing = false
+	if err == nil {
+		r.metadata = metadata
+	}
+}
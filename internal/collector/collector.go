@@ -4,6 +4,7 @@ import (
 	"agent/config"
 	"agent/internal/logger"
 	"agent/internal/system"
+	"agent/internal/transform"
 	"agent/internal/version"
 	"agent/internal/websocket"
 	"bytes"
@@ -19,8 +20,8 @@ import (
 	"sync"
 	"time"
 
-	"github.com/shirou/gopsutil/disk"
-	"github.com/shirou/gopsutil/net"
+	"github.com/shirou/gopsutil/v4/disk"
+	"github.com/shirou/gopsutil/v4/net"
 )
 
 const reportCompressionThreshold = 1024
@@ -29,12 +30,10 @@ type Collector struct {
 	System *system.System
 	Logger *logger.Logger
 	Client *websocket.Client
-	Config config.Config
 
-	// 上报间隔配置
-	MetricsInterval int // 性能指标上报间隔（秒）
-	DetailInterval  int // 详细信息上报间隔（秒）
-	SystemInterval  int // 系统信息上报间隔（秒）
+	// 当前配置，热重载时整体替换，读写均需加锁
+	cfg   config.Config
+	cfgMu sync.RWMutex
 
 	// 网络IO统计相关
 	lastNetIOCounters map[string]net.IOCountersStat
@@ -47,7 +46,44 @@ type Collector struct {
 	diskIOMutex        sync.RWMutex
 
 	// 日志发送相关
-	logChan chan map[string]interface{}
+	logChan      chan map[string]interface{}
+	logFlushChan chan chan struct{}
+	logStop      chan struct{}
+	logStopOnce  sync.Once
+
+	// 公网IP解析
+	publicIP *publicIPResolver
+
+	// 云厂商实例元数据
+	cloud *cloudMetadataResolver
+
+	// 运行环境（物理机/虚拟机/容器），首次使用时检测
+	env     *system.Environment
+	envOnce sync.Once
+
+	// 上报间隔变更通知
+	intervalChanged chan struct{}
+
+	// 采集项耗时统计
+	stats *collectorStats
+
+	// 软件包信息上次采集时间（按 package_interval 限频）
+	packageMu   sync.Mutex
+	packageLast time.Time
+
+	// 上报数据转换脚本
+	transformer *transform.Transformer
+	transformMu sync.Mutex
+
+	// 各类型最近一次上报的数据，供本地状态页展示
+	lastPayloads   map[string]LastPayload
+	lastPayloadsMu sync.Mutex
+}
+
+// LastPayload 某类型最近一次上报的数据
+type LastPayload struct {
+	Time time.Time   `json:"time"`
+	Data interface{} `json:"data"`
 }
 
 func NewCollector(sys *system.System, log *logger.Logger, client *websocket.Client, cfg config.Config) *Collector {
@@ -55,19 +91,46 @@ func NewCollector(sys *system.System, log *logger.Logger, client *websocket.Clie
 		System:          sys,
 		Logger:          log,
 		Client:          client,
-		MetricsInterval: cfg.MetricsInterval,
-		DetailInterval:  cfg.DetailInterval,
-		SystemInterval:  cfg.SystemInterval,
-		Config:          cfg,
+		cfg:             cfg,
 		logChan:         make(chan map[string]interface{}, 100),
+		logFlushChan:    make(chan chan struct{}),
+		logStop:         make(chan struct{}),
+		publicIP:        newPublicIPResolver(),
+		cloud:           newCloudMetadataResolver(),
+		intervalChanged: make(chan struct{}, 1),
+		stats:           newCollectorStats(),
+		lastPayloads:    make(map[string]LastPayload),
 	}
 
+	c.setTransformScript(cfg.TransformScript)
+
 	// 启动日志发送协程
 	go c.processLogs()
 
 	return c
 }
 
+// Config 返回当前配置的副本
+func (c *Collector) Config() config.Config {
+	c.cfgMu.RLock()
+	defer c.cfgMu.RUnlock()
+	return c.cfg
+}
+
+// isCollectorDisabled 判断采集项是否被关闭
+func (c *Collector) isCollectorDisabled(name string) bool {
+	cfg := c.Config()
+	return cfg.IsCollectorDisabled(name)
+}
+
+// intervals 返回当前的上报间隔：性能指标、详细信息、系统信息
+func (c *Collector) intervals() (metrics, detail, system time.Duration) {
+	cfg := c.Config()
+	return time.Duration(cfg.MetricsInterval) * time.Second,
+		time.Duration(cfg.DetailInterval) * time.Second,
+		time.Duration(cfg.SystemInterval) * time.Second
+}
+
 // SendLog 发送日志
 func (c *Collector) SendLog(level, message string) {
 	select {
@@ -100,10 +163,53 @@ func (c *Collector) processLogs() {
 				c.flushLogs(buffer)
 				buffer = make([]interface{}, 0, 10)
 			}
+		case done := <-c.logFlushChan:
+			// 立即发送缓冲区和通道中所有待发送的日志
+		drain:
+			for {
+				select {
+				case log := <-c.logChan:
+					buffer = append(buffer, log)
+				default:
+					break drain
+				}
+			}
+			if len(buffer) > 0 {
+				c.flushLogs(buffer)
+				buffer = make([]interface{}, 0, 10)
+			}
+			close(done)
+		case <-c.logStop:
+			return
 		}
 	}
 }
 
+// Close 停止日志发送协程，用于一次性采集等临时创建的采集器
+func (c *Collector) Close() {
+	c.logStopOnce.Do(func() { close(c.logStop) })
+	c.setTransformScript("")
+}
+
+// FlushLogs 立即发送所有待发送的日志，最多等待 timeout
+func (c *Collector) FlushLogs(timeout time.Duration) bool {
+	done := make(chan struct{})
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case c.logFlushChan <- done:
+	case <-timer.C:
+		return false
+	}
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
 // flushLogs 发送日志缓冲区
 func (c *Collector) flushLogs(logs []interface{}) {
 	message := websocket.Message{
@@ -115,6 +221,15 @@ func (c *Collector) flushLogs(logs []interface{}) {
 }
 
 func (c *Collector) sendMessage(message websocket.Message) error {
+	message, keep := c.applyTransform(message)
+	if !keep {
+		return nil
+	}
+	if message.Type != "agent_log" {
+		c.lastPayloadsMu.Lock()
+		c.lastPayloads[message.Type] = LastPayload{Time: time.Now(), Data: message.Data}
+		c.lastPayloadsMu.Unlock()
+	}
 	message = compressReportMessage(message)
 	if err := c.Client.SendMessage(message); err == nil {
 		return nil
@@ -160,7 +275,7 @@ func compressReportMessage(message websocket.Message) websocket.Message {
 
 func isCompressibleReportType(reportType string) bool {
 	switch reportType {
-	case "system_info", "metrics", "memory_info", "disk_info", "disk_io", "network_info", "swap_info", "process_info", "gpu_info", "agent_log":
+	case "inventory", "system_info", "agent_stats", "metrics", "memory_info", "disk_info", "disk_io", "network_info", "swap_info", "process_info", "gpu_info", "agent_log":
 		return true
 	default:
 		return false
@@ -168,15 +283,15 @@ func isCompressibleReportType(reportType string) bool {
 }
 
 func (c *Collector) sendHTTPFallback(message websocket.Message) error {
-	if c.Config.Server == "" || c.Config.Key == "" {
+	if c.Config().Server == "" || c.Config().Key == "" {
 		return fmt.Errorf("missing server or agent key")
 	}
-	endpoint, err := agentReportEndpoint(c.Config.Server)
+	endpoint, err := agentReportEndpoint(c.Config().Server)
 	if err != nil {
 		return err
 	}
 	payload := map[string]interface{}{
-		"agent_key": c.Config.Key,
+		"agent_key": c.Config().Key,
 		"type":      message.Type,
 		"data":      message.Data,
 	}
@@ -223,10 +338,16 @@ func agentReportEndpoint(server string) (string, error) {
 	return parsed.String(), nil
 }
 
-// SendSystemInfo 发送系统基础信息
-func (c *Collector) SendSystemInfo() error {
-	hostInfo := c.System.GetHostInfo()
-	bootTimeUnix, err := c.System.GetBootTime()
+// collectSystemInfo 采集系统基础信息
+func (c *Collector) collectSystemInfo(ctx context.Context) (*websocket.Message, error) {
+	ctx, cancel := c.System.CallContext(ctx)
+	defer cancel()
+
+	hostInfo, err := c.System.GetHostInfoWithContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取主机信息失败: %w", err)
+	}
+	bootTimeUnix, err := c.System.GetBootTimeWithContext(ctx)
 	if err != nil {
 		c.Logger.Warn("获取系统启动时间失败: %v", err)
 		bootTimeUnix = 0
@@ -239,8 +360,14 @@ func (c *Collector) SendSystemInfo() error {
 		bootTime = time.Now()
 	}
 
-	// 使用统一的函数获取系统运行时间
-	systemUptime := c.System.GetUptime()
+	systemUptime, err := c.System.GetUptimeWithContext(ctx)
+	if err != nil {
+		c.Logger.Warn("获取系统运行时间失败: %v", err)
+	}
+	cores, err := c.System.GetCpuCountsWithContext(ctx, true)
+	if err != nil {
+		c.Logger.Warn("获取CPU逻辑核心数失败: %v", err)
+	}
 
 	systemData := map[string]interface{}{
 		"agent_version": version.AgentVersion,
@@ -249,29 +376,170 @@ func (c *Collector) SendSystemInfo() error {
 		"architecture":  runtime.GOARCH,
 		"kernel":        hostInfo.KernelVersion,
 		"hostname":      hostInfo.Hostname,
-		"cores":         c.System.GetCpuLogicCount(),
+		"cores":         cores,
 		"boot_time":     bootTime.Format(time.RFC3339),
 		"uptime":        systemUptime,
 	}
 
+	if c.Config().DisplayName != "" {
+		systemData["display_name"] = c.Config().DisplayName
+	}
+	if len(c.Config().Tags) > 0 {
+		systemData["tags"] = c.Config().Tags
+	}
+
+	if publicIP := c.getPublicIP(); publicIP != "" {
+		systemData["public_ip"] = publicIP
+	}
+
+	// 云厂商、地域、实例规格和实例 ID（配置 disable_cloud_metadata 时不探测）
+	if !c.Config().DisableCloudMetadata {
+		if cloud := c.cloud.Get(c.System); cloud != nil {
+			systemData["cloud"] = cloud
+		}
+	}
+
+	// 运行环境：物理机、虚拟机（KVM/VMware/Hyper-V 等）或容器（Docker/LXC/Kubernetes 等）
+	systemData["environment"] = c.environment(ctx)
+
+	// 时间同步状态（NTP/chrony/timesyncd/w32time）
+	if timeSync, err := c.System.GetTimeSyncStatusWithContext(ctx); err != nil {
+		c.Logger.Debug("获取时间同步状态失败: %v", err)
+	} else {
+		systemData["time_sync"] = timeSync
+	}
+
+	// SELinux/AppArmor、防火墙和待重启状态
+	systemData["security"] = c.System.GetSecurityPostureWithContext(ctx)
+
+	// Agent 运行时长与连接可用性（本地一次性采集时没有连接）
+	if c.Client != nil {
+		systemData["availability"] = c.Client.Availability()
+	}
+
 	message := websocket.Message{
 		Type: "system_info",
 		Data: systemData,
 	}
 
-	return c.sendMessage(message)
+	return &message, nil
+}
+
+// collectInventory 采集硬件资产清单（CPU、内存、磁盘分区、网络接口）
+func (c *Collector) collectInventory(ctx context.Context) (*websocket.Message, error) {
+	ctx, cancel := c.System.CallContext(ctx)
+	defer cancel()
+
+	cpuModel := ""
+	if cpuInfoList, err := c.System.GetCpuInfoWithContext(ctx); err != nil {
+		c.Logger.Warn("获取CPU信息失败: %v", err)
+	} else if len(cpuInfoList) > 0 {
+		cpuModel = cpuInfoList[0].ModelName
+	}
+	physicalCores, err := c.System.GetCpuCountsWithContext(ctx, false)
+	if err != nil {
+		c.Logger.Warn("获取CPU物理核心数失败: %v", err)
+	}
+	logicalCores, err := c.System.GetCpuCountsWithContext(ctx, true)
+	if err != nil {
+		c.Logger.Warn("获取CPU逻辑核心数失败: %v", err)
+	}
+	vm, err := c.System.GetVirtualMemoryWithContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取内存信息失败: %w", err)
+	}
+	var swapTotal uint64
+	if swap, err := c.System.GetSwapMemoryWithContext(ctx); err != nil {
+		c.Logger.Warn("获取Swap信息失败: %v", err)
+	} else {
+		swapTotal = swap.Total
+	}
+	partitions, err := c.System.GetDiskPartWithContext(ctx)
+	if err != nil {
+		c.Logger.Warn("获取磁盘分区失败: %v", err)
+	}
+	netInterfaces, err := c.System.GetNetInterfacesWithContext(ctx)
+	if err != nil {
+		c.Logger.Warn("获取网络接口失败: %v", err)
+	}
+
+	var disks []map[string]interface{}
+	for _, partition := range partitions {
+		if c.isVirtualFilesystem(partition.Mountpoint) || c.isExcludedFilesystem(partition.Fstype) {
+			continue
+		}
+		disks = append(disks, map[string]interface{}{
+			"device":      partition.Device,
+			"mount_point": partition.Mountpoint,
+			"fstype":      partition.Fstype,
+		})
+	}
+
+	var interfaces []map[string]interface{}
+	for _, iface := range netInterfaces {
+		addrs := make([]string, 0, len(iface.Addrs))
+		for _, addr := range iface.Addrs {
+			addrs = append(addrs, addr.Addr)
+		}
+		interfaces = append(interfaces, map[string]interface{}{
+			"name":  iface.Name,
+			"mac":   iface.HardwareAddr,
+			"mtu":   iface.MTU,
+			"addrs": addrs,
+		})
+	}
+
+	message := websocket.Message{
+		Type: "inventory",
+		Data: map[string]interface{}{
+			"cpu_model":          cpuModel,
+			"cpu_physical_cores": physicalCores,
+			"cpu_logical_cores":  logicalCores,
+			"memory_total":       vm.Total,
+			"swap_total":         swapTotal,
+			"disks":              disks,
+			"interfaces":         interfaces,
+		},
+	}
+
+	return &message, nil
+}
+
+// getPublicIP 获取缓存的公网IP，IP变化时发送 ip_changed 消息
+// 配置 disable_public_ip 时不向第三方服务查询
+func (c *Collector) getPublicIP() string {
+	if c.Config().DisablePublicIP {
+		return ""
+	}
+	interval := time.Duration(c.Config().PublicIPInterval) * time.Second
+	return c.publicIP.Get(c.Config().PublicIPProviders, interval, c.sendIPChanged)
+}
+
+// sendIPChanged 发送公网IP变化消息
+func (c *Collector) sendIPChanged(oldIP, newIP string) {
+	c.Logger.Info("检测到公网IP变化: %s -> %s", oldIP, newIP)
+	message := websocket.Message{
+		Type: "ip_changed",
+		Data: map[string]interface{}{
+			"old_ip": oldIP,
+			"new_ip": newIP,
+			"time":   time.Now().Format(time.RFC3339),
+		},
+	}
+	if err := c.sendMessage(message); err != nil {
+		c.Logger.Warn("发送公网IP变化消息失败: %v", err)
+	}
 }
 
 // getNetworkSpeed 计算网络速度（字节/秒）
-func (c *Collector) getNetworkSpeed() (uploadSpeed float64, downloadSpeed float64) {
+func (c *Collector) getNetworkSpeed(ctx context.Context) (uploadSpeed float64, downloadSpeed float64, err error) {
 	c.netIOMutex.Lock()
 	defer c.netIOMutex.Unlock()
 
 	// 获取当前网络IO统计
-	currentCounters, err := c.System.GetNetIOCounters()
+	currentCounters, err := c.System.GetNetIOCountersWithContext(ctx)
 	if err != nil {
-		c.Logger.Warn("获取网络IO统计失败: %v", err)
-		return 0.0, 0.0
+		return 0.0, 0.0, fmt.Errorf("获取网络IO统计失败: %w", err)
 	}
 
 	// 计算所有网络接口的总发送和接收字节数
@@ -286,7 +554,7 @@ func (c *Collector) getNetworkSpeed() (uploadSpeed float64, downloadSpeed float6
 	if c.lastNetIOCounters == nil || c.lastNetIOTime.IsZero() {
 		c.lastNetIOCounters = currentCounters
 		c.lastNetIOTime = time.Now()
-		return 0.0, 0.0
+		return 0.0, 0.0, nil
 	}
 
 	// 计算上一次的总字节数
@@ -311,19 +579,18 @@ func (c *Collector) getNetworkSpeed() (uploadSpeed float64, downloadSpeed float6
 	c.lastNetIOCounters = currentCounters
 	c.lastNetIOTime = time.Now()
 
-	return uploadSpeed, downloadSpeed
+	return uploadSpeed, downloadSpeed, nil
 }
 
 // getDiskIOSpeed 计算磁盘IO速度（字节/秒）
-func (c *Collector) getDiskIOSpeed() (readSpeed float64, writeSpeed float64) {
+func (c *Collector) getDiskIOSpeed(ctx context.Context) (readSpeed float64, writeSpeed float64, err error) {
 	c.diskIOMutex.Lock()
 	defer c.diskIOMutex.Unlock()
 
 	// 获取所有磁盘的IO统计
-	currentCounters, err := disk.IOCounters()
+	currentCounters, err := c.System.GetDiskIOCountersWithContext(ctx)
 	if err != nil {
-		c.Logger.Warn("获取磁盘IO统计失败: %v", err)
-		return 0.0, 0.0
+		return 0.0, 0.0, fmt.Errorf("获取磁盘IO统计失败: %w", err)
 	}
 
 	// 计算所有磁盘的总读取和写入字节数
@@ -338,7 +605,7 @@ func (c *Collector) getDiskIOSpeed() (readSpeed float64, writeSpeed float64) {
 	if c.lastDiskIOCounters == nil || c.lastDiskIOTime.IsZero() {
 		c.lastDiskIOCounters = currentCounters
 		c.lastDiskIOTime = time.Now()
-		return 0.0, 0.0
+		return 0.0, 0.0, nil
 	}
 
 	// 计算上一次的总字节数
@@ -363,14 +630,14 @@ func (c *Collector) getDiskIOSpeed() (readSpeed float64, writeSpeed float64) {
 	c.lastDiskIOCounters = currentCounters
 	c.lastDiskIOTime = time.Now()
 
-	return readSpeed, writeSpeed
+	return readSpeed, writeSpeed, nil
 }
 
 // getDiskUsage 计算磁盘使用率（返回所有磁盘的平均使用率）
-func (c *Collector) getDiskUsage() float64 {
-	partitions := c.System.GetDiskPart()
-	if len(partitions) == 0 {
-		return 0.0
+func (c *Collector) getDiskUsage(ctx context.Context) (float64, error) {
+	partitions, err := c.System.GetDiskPartWithContext(ctx)
+	if err != nil {
+		return 0.0, fmt.Errorf("获取磁盘分区失败: %w", err)
 	}
 
 	totalUsage := 0.0
@@ -393,8 +660,9 @@ func (c *Collector) getDiskUsage() float64 {
 			continue
 		}
 
-		usage := c.System.GetDiskUsage(partition.Mountpoint)
-		if usage == nil {
+		usage, err := c.System.GetDiskUsageWithContext(ctx, partition.Mountpoint)
+		if err != nil || usage == nil {
+			c.Logger.Debug("获取挂载点 %s 的使用情况失败: %v", partition.Mountpoint, err)
 			continue
 		}
 
@@ -409,56 +677,88 @@ func (c *Collector) getDiskUsage() float64 {
 	}
 
 	if validCount == 0 {
-		return 0.0
+		return 0.0, nil
 	}
 
-	return totalUsage / float64(validCount)
+	return totalUsage / float64(validCount), nil
 }
 
-// SendMetrics 发送性能指标
-func (c *Collector) SendMetrics() error {
-	memTotal := c.System.GetMemoryTotal()
-	memUsed := c.System.GetMemoryUsed()
-	memPercent := c.System.GetMemoryUsedPercent()
-	cpuPercent := c.System.GetCpuUsedPercent()
+// collectMetrics 采集性能指标
+func (c *Collector) collectMetrics(ctx context.Context) (*websocket.Message, error) {
+	ctx, cancel := c.System.CallContext(ctx)
+	defer cancel()
+
+	vm, err := c.System.GetVirtualMemoryWithContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取内存信息失败: %w", err)
+	}
+	cpuPercents, err := c.System.GetCpuPercentWithContext(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("获取CPU使用率失败: %w", err)
+	}
+	cpuPercent := 0
+	if len(cpuPercents) > 0 {
+		cpuPercent = int(cpuPercents[0])
+	}
 
 	// 获取网络速度
-	networkUpload, networkDownload := c.getNetworkSpeed()
+	networkUpload, networkDownload, err := c.getNetworkSpeed(ctx)
+	if err != nil {
+		c.Logger.Warn("%v", err)
+	}
 
 	// 获取磁盘使用率
-	diskUsage := c.getDiskUsage()
+	diskUsage, err := c.getDiskUsage(ctx)
+	if err != nil {
+		c.Logger.Warn("%v", err)
+	}
 
 	metricsData := map[string]interface{}{
 		"cpu_usage":            cpuPercent,
-		"memory_total":         memTotal,
-		"memory_used":          memUsed,
-		"memory_usage_percent": memPercent,
+		"memory_total":         int(vm.Total),
+		"memory_used":          int(vm.Used),
+		"memory_usage_percent": int(vm.UsedPercent),
 		"disk_usage":           diskUsage,
 		"network_upload":       networkUpload,
 		"network_download":     networkDownload,
 	}
 
+	// 与面板的时钟偏差，超过 max_clock_skew 时标记
+	if c.Client != nil {
+		if skew, ok := c.Client.ClockSkew(); ok {
+			metricsData["clock_skew_ms"] = skew.Offset.Milliseconds()
+			metricsData["clock_skew_exceeded"] = skew.Abs() > time.Duration(c.Config().MaxClockSkew)*time.Second
+		}
+	}
+
 	message := websocket.Message{
 		Type: "metrics",
 		Data: metricsData,
 	}
 
-	return c.sendMessage(message)
+	return &message, nil
 }
 
-// SendCPUInfo 发送详细CPU信息
-func (c *Collector) SendCPUInfo() error {
-	cpuPercents := c.System.GetCpuUsedPercentEach()
+// collectCPUInfo 采集详细CPU信息
+func (c *Collector) collectCPUInfo(ctx context.Context) (*websocket.Message, error) {
+	ctx, cancel := c.System.CallContext(ctx)
+	defer cancel()
+
+	cpuPercents, err := c.System.GetCpuPercentWithContext(ctx, true)
+	if err != nil {
+		return nil, fmt.Errorf("获取CPU核心使用率失败: %w", err)
+	}
 
-	cpuInfoList := c.System.GetCpuInfo()
 	cpuName := "Unknown CPU"
-	if len(cpuInfoList) > 0 {
+	if cpuInfoList, err := c.System.GetCpuInfoWithContext(ctx); err != nil {
+		c.Logger.Warn("获取CPU信息失败: %v", err)
+	} else if len(cpuInfoList) > 0 {
 		cpuName = cpuInfoList[0].ModelName
 	}
 
 	if len(cpuPercents) == 0 {
 		c.Logger.Warn("未获取到CPU核心使用率数据")
-		return nil
+		return nil, nil
 	}
 
 	var cpuData []map[string]interface{}
@@ -475,19 +775,23 @@ func (c *Collector) SendCPUInfo() error {
 		Data: cpuData,
 	}
 
-	return c.sendMessage(message)
+	return &message, nil
 }
 
-// SendMemoryInfo 发送内存历史信息
-func (c *Collector) SendMemoryInfo() error {
-	memTotal := c.System.GetMemoryTotal()
-	memUsed := c.System.GetMemoryUsed()
-	memPercent := c.System.GetMemoryUsedPercent()
+// collectMemoryInfo 采集内存历史信息
+func (c *Collector) collectMemoryInfo(ctx context.Context) (*websocket.Message, error) {
+	ctx, cancel := c.System.CallContext(ctx)
+	defer cancel()
+
+	vm, err := c.System.GetVirtualMemoryWithContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取内存信息失败: %w", err)
+	}
 
 	memoryData := map[string]interface{}{
-		"memory_total":         memTotal,
-		"memory_used":          memUsed,
-		"memory_usage_percent": memPercent,
+		"memory_total":         int(vm.Total),
+		"memory_used":          int(vm.Used),
+		"memory_usage_percent": int(vm.UsedPercent),
 	}
 
 	message := websocket.Message{
@@ -495,13 +799,13 @@ func (c *Collector) SendMemoryInfo() error {
 		Data: memoryData,
 	}
 
-	return c.sendMessage(message)
+	return &message, nil
 }
 
 // isVirtualFilesystem 判断是否为虚拟文件系统（基于挂载点）
 func (c *Collector) isVirtualFilesystem(mountPoint string) bool {
 	// 使用配置中的排除挂载点列表
-	for _, prefix := range c.Config.ExcludedMountPoints {
+	for _, prefix := range c.Config().ExcludedMountPoints {
 		if mountPoint == prefix || (len(mountPoint) > len(prefix) && mountPoint[:len(prefix)+1] == prefix+"/") {
 			return true
 		}
@@ -512,7 +816,7 @@ func (c *Collector) isVirtualFilesystem(mountPoint string) bool {
 
 // isExcludedFilesystem 判断文件系统类型是否应该被排除
 func (c *Collector) isExcludedFilesystem(fstype string) bool {
-	for _, excludedType := range c.Config.ExcludedFilesystems {
+	for _, excludedType := range c.Config().ExcludedFilesystems {
 		if fstype == excludedType {
 			return true
 		}
@@ -520,9 +824,15 @@ func (c *Collector) isExcludedFilesystem(fstype string) bool {
 	return false
 }
 
-// SendDiskInfo 发送磁盘信息
-func (c *Collector) SendDiskInfo() error {
-	partitions := c.System.GetDiskPart()
+// collectDiskInfo 采集磁盘信息
+func (c *Collector) collectDiskInfo(ctx context.Context) (*websocket.Message, error) {
+	ctx, cancel := c.System.CallContext(ctx)
+	defer cancel()
+
+	partitions, err := c.System.GetDiskPartWithContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取磁盘分区失败: %w", err)
+	}
 
 	var diskData []map[string]interface{}
 	seenDevices := make(map[string]bool) // 用于去重相同设备
@@ -543,8 +853,9 @@ func (c *Collector) SendDiskInfo() error {
 			continue
 		}
 
-		usage := c.System.GetDiskUsage(partition.Mountpoint)
-		if usage == nil {
+		usage, err := c.System.GetDiskUsageWithContext(ctx, partition.Mountpoint)
+		if err != nil || usage == nil {
+			c.Logger.Debug("获取挂载点 %s 的使用情况失败: %v", partition.Mountpoint, err)
 			continue
 		}
 
@@ -569,13 +880,19 @@ func (c *Collector) SendDiskInfo() error {
 		Data: diskData,
 	}
 
-	return c.sendMessage(message)
+	return &message, nil
 }
 
-// SendDiskIO 发送磁盘IO信息
-func (c *Collector) SendDiskIO() error {
+// collectDiskIO 采集磁盘IO信息
+func (c *Collector) collectDiskIO(ctx context.Context) (*websocket.Message, error) {
+	ctx, cancel := c.System.CallContext(ctx)
+	defer cancel()
+
 	// 获取磁盘IO速度
-	readSpeed, writeSpeed := c.getDiskIOSpeed()
+	readSpeed, writeSpeed, err := c.getDiskIOSpeed(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	diskIOData := map[string]interface{}{
 		"read_speed":  readSpeed,  // 字节/秒
@@ -587,12 +904,18 @@ func (c *Collector) SendDiskIO() error {
 		Data: diskIOData,
 	}
 
-	return c.sendMessage(message)
+	return &message, nil
 }
 
-// SendNetworkInfo 发送网络信息
-func (c *Collector) SendNetworkInfo() error {
-	connections := c.System.GetNetIO()
+// collectNetworkInfo 采集网络信息
+func (c *Collector) collectNetworkInfo(ctx context.Context) (*websocket.Message, error) {
+	ctx, cancel := c.System.CallContext(ctx)
+	defer cancel()
+
+	connections, err := c.System.GetNetIOWithContext(ctx)
+	if err != nil {
+		c.Logger.Warn("获取网络连接失败: %v", err)
+	}
 
 	tcpConns := 0
 	udpConns := 0
@@ -606,10 +929,9 @@ func (c *Collector) SendNetworkInfo() error {
 	}
 
 	// 获取网络IO统计
-	counters, err := c.System.GetNetIOCounters()
+	counters, err := c.System.GetNetIOCountersWithContext(ctx)
 	if err != nil {
-		c.Logger.Warn("获取网络IO统计失败: %v", err)
-		counters = make(map[string]net.IOCountersStat)
+		return nil, fmt.Errorf("获取网络IO统计失败: %w", err)
 	}
 
 	// 计算所有网络接口的总发送和接收字节数
@@ -621,7 +943,10 @@ func (c *Collector) SendNetworkInfo() error {
 	}
 
 	// 获取网络速度
-	uploadSpeed, downloadSpeed := c.getNetworkSpeed()
+	uploadSpeed, downloadSpeed, err := c.getNetworkSpeed(ctx)
+	if err != nil {
+		c.Logger.Warn("%v", err)
+	}
 
 	networkData := map[string]interface{}{
 		"tcp_connections": tcpConns,
@@ -637,18 +962,24 @@ func (c *Collector) SendNetworkInfo() error {
 		Data: networkData,
 	}
 
-	return c.sendMessage(message)
+	return &message, nil
 }
 
-// SendVirtualMemory 发送Swap内存信息
-func (c *Collector) SendVirtualMemory() error {
-	swapTotal, swapUsed, swapFree, swapUsedPercent := c.System.GetSwapMemory()
+// collectVirtualMemory 采集Swap内存信息
+func (c *Collector) collectVirtualMemory(ctx context.Context) (*websocket.Message, error) {
+	ctx, cancel := c.System.CallContext(ctx)
+	defer cancel()
+
+	swap, err := c.System.GetSwapMemoryWithContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取Swap信息失败: %w", err)
+	}
 
 	swapData := map[string]interface{}{
-		"swap_total":         swapTotal,
-		"swap_used":          swapUsed,
-		"swap_free":          swapFree,
-		"swap_usage_percent": swapUsedPercent,
+		"swap_total":         int(swap.Total),
+		"swap_used":          int(swap.Used),
+		"swap_free":          int(swap.Free),
+		"swap_usage_percent": swap.UsedPercent,
 	}
 
 	message := websocket.Message{
@@ -656,20 +987,22 @@ func (c *Collector) SendVirtualMemory() error {
 		Data: swapData,
 	}
 
-	return c.sendMessage(message)
+	return &message, nil
 }
 
-// SendProcessInfo 发送进程信息
-func (c *Collector) SendProcessInfo() error {
+// collectProcessInfo 采集进程信息
+func (c *Collector) collectProcessInfo(ctx context.Context) (*websocket.Message, error) {
 	// 检查配置中是否有 monitored_services
-	if len(c.Config.MonitoredServices) == 0 {
-		return nil
+	if len(c.Config().MonitoredServices) == 0 {
+		return nil, nil
 	}
 
-	processStatus, err := c.System.GetProcessStatus(c.Config.MonitoredServices)
+	ctx, cancel := c.System.CallContext(ctx)
+	defer cancel()
+
+	processStatus, err := c.System.GetProcessStatusWithContext(ctx, c.Config().MonitoredServices)
 	if err != nil {
-		c.Logger.Warn("获取进程状态失败: %v", err)
-		return err
+		return nil, fmt.Errorf("获取进程状态失败: %w", err)
 	}
 
 	// 构造数据包
@@ -688,20 +1021,19 @@ func (c *Collector) SendProcessInfo() error {
 		Data: data,
 	}
 
-	return c.sendMessage(message)
+	return &message, nil
 }
 
-// SendGPUInfo 发送GPU信息
-func (c *Collector) SendGPUInfo() error {
-	gpuStats, err := c.System.GetGPUInfo()
+// collectGPUInfo 采集GPU信息
+func (c *Collector) collectGPUInfo(ctx context.Context) (*websocket.Message, error) {
+	gpuStats, err := c.System.GetGPUInfoWithContext(ctx)
 	if err != nil {
-		c.Logger.Warn("获取GPU信息失败: %v", err)
-		return err
+		return nil, fmt.Errorf("获取GPU信息失败: %w", err)
 	}
 
 	// 如果GPU不可用，静默跳过（不报错）
 	if !gpuStats.Available {
-		return nil
+		return nil, nil
 	}
 
 	// 构造数据包
@@ -715,14 +1047,116 @@ func (c *Collector) SendGPUInfo() error {
 		Data: data,
 	}
 
-	return c.sendMessage(message)
+	return &message, nil
+}
+
+// initialSyncStep 首次全量同步各阶段之间的间隔，避免连接建立后瞬间突发大量消息
+const initialSyncStep = 500 * time.Millisecond
+
+// runInitialSync 认证成功后按固定顺序全量同步：资产清单 → 系统信息 → 详细信息 → 性能指标
+// 各阶段之间保持节奏，使新服务器在几秒内于面板中完整显示
+func (c *Collector) runInitialSync(ctx context.Context) error {
+	steps := []struct {
+		name string
+		fn   func() error
+	}{
+		{"资产清单", func() error { return c.timed(ctx, "inventory", c.collectInventory) }},
+		{"系统信息", func() error { return c.timed(ctx, "system_info", c.collectSystemInfo) }},
+		{"详细信息", func() error { c.sendDetailReports(ctx); return nil }},
+		{"性能指标", func() error { c.sendMetricsReports(ctx); return nil }},
+	}
+
+	var firstErr error
+	for i, step := range steps {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(initialSyncStep):
+			}
+		}
+		if err := step.fn(); err != nil {
+			c.Logger.Warn("首次同步%s失败: %v", step.name, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	c.Logger.Info("首次全量同步完成")
+	return firstErr
+}
+
+// LastPayloads 返回各类型最近一次上报的数据
+func (c *Collector) LastPayloads() map[string]LastPayload {
+	c.lastPayloadsMu.Lock()
+	defer c.lastPayloadsMu.Unlock()
+
+	result := make(map[string]LastPayload, len(c.lastPayloads))
+	for name, payload := range c.lastPayloads {
+		result[name] = payload
+	}
+	return result
+}
+
+// QueueLength 返回等待发送的日志条数
+func (c *Collector) QueueLength() int {
+	return len(c.logChan)
+}
+
+// Flush 立即发送缓冲中的日志，并执行一次性能指标和详细信息上报
+func (c *Collector) Flush(ctx context.Context) {
+	c.FlushLogs(5 * time.Second)
+	c.sendMetricsReports(ctx)
+	c.sendDetailReports(ctx)
+}
+
+// sendMetricsReports 发送性能指标类上报
+func (c *Collector) sendMetricsReports(ctx context.Context) {
+	if err := c.timed(ctx, "metrics", c.collectMetrics); err != nil {
+		c.Logger.Warn("发送性能指标失败: %v", err)
+	}
+	// 发送进程信息（与性能指标同频率）
+	if err := c.timed(ctx, "process_info", c.collectProcessInfo); err != nil {
+		c.Logger.Warn("发送进程信息失败: %v", err)
+	}
+}
+
+// sendDetailReports 发送详细信息类上报
+func (c *Collector) sendDetailReports(ctx context.Context) {
+	if err := c.timed(ctx, "cpu_info", c.collectCPUInfo); err != nil {
+		c.Logger.Warn("发送CPU详细信息失败: %v", err)
+	}
+	if err := c.timed(ctx, "memory_info", c.collectMemoryInfo); err != nil {
+		c.Logger.Warn("发送内存详细信息失败: %v", err)
+	}
+	if err := c.timed(ctx, "disk_info", c.collectDiskInfo); err != nil {
+		c.Logger.Warn("发送磁盘详细信息失败: %v", err)
+	}
+	if err := c.timed(ctx, "disk_io", c.collectDiskIO); err != nil {
+		c.Logger.Warn("发送磁盘IO信息失败: %v", err)
+	}
+	if err := c.timed(ctx, "network_info", c.collectNetworkInfo); err != nil {
+		c.Logger.Warn("发送网络详细信息失败: %v", err)
+	}
+	if err := c.timed(ctx, "swap_info", c.collectVirtualMemory); err != nil {
+		c.Logger.Warn("发送Swap信息失败: %v", err)
+	}
+	if err := c.timed(ctx, "gpu_info", c.collectGPUInfo); err != nil {
+		c.Logger.Warn("发送GPU信息失败: %v", err)
+	}
+	if err := c.timed(ctx, "users_info", c.collectUsersInfo); err != nil {
+		c.Logger.Warn("发送登录用户信息失败: %v", err)
+	}
 }
 
 // StartPeriodicReporting 启动周期性上报，使用 context 控制生命周期
 func (c *Collector) StartPeriodicReporting(ctx context.Context, healthChan chan<- bool) {
-	// 立即发送一次系统信息
-	if err := c.SendSystemInfo(); err != nil {
-		c.Logger.Warn("发送系统信息失败: %v", err)
+	// 首次全量同步
+	if err := c.runInitialSync(ctx); err != nil {
+		if ctx.Err() != nil {
+			c.Logger.Info("停止数据采集")
+			return
+		}
 		select {
 		case healthChan <- false:
 		default:
@@ -735,12 +1169,13 @@ func (c *Collector) StartPeriodicReporting(ctx context.Context, healthChan chan<
 	}
 
 	// 创建所有 ticker
-	metricsTicker := time.NewTicker(time.Duration(c.MetricsInterval) * time.Second)
-	detailTicker := time.NewTicker(time.Duration(c.DetailInterval) * time.Second)
-	systemTicker := time.NewTicker(time.Duration(c.SystemInterval) * time.Second)
+	metricsInterval, detailInterval, systemInterval := c.intervals()
+	metricsTicker := time.NewTicker(metricsInterval)
+	detailTicker := time.NewTicker(detailInterval)
+	systemTicker := time.NewTicker(systemInterval)
 
-	c.Logger.Info("数据上报间隔配置: 性能指标=%d秒, 详细信息=%d秒, 系统信息=%d秒",
-		c.MetricsInterval, c.DetailInterval, c.SystemInterval)
+	c.Logger.Info("数据上报间隔配置: 性能指标=%s, 详细信息=%s, 系统信息=%s",
+		metricsInterval, detailInterval, systemInterval)
 
 	defer func() {
 		metricsTicker.Stop()
@@ -748,64 +1183,62 @@ func (c *Collector) StartPeriodicReporting(ctx context.Context, healthChan chan<
 		systemTicker.Stop()
 	}()
 
+	// 自定义脚本插件按各自的间隔执行
+	go c.runPlugins(ctx)
+
 	for {
 		select {
 		case <-ctx.Done():
 			c.Logger.Info("停止数据采集")
 			return
+		case <-c.intervalChanged:
+			// 配置重载后按新的间隔重置 ticker
+			metricsInterval, detailInterval, systemInterval := c.intervals()
+			metricsTicker.Reset(metricsInterval)
+			detailTicker.Reset(detailInterval)
+			systemTicker.Reset(systemInterval)
+			c.Logger.Info("数据上报间隔已更新: 性能指标=%s, 详细信息=%s, 系统信息=%s",
+				metricsInterval, detailInterval, systemInterval)
 		case <-metricsTicker.C:
 			// 并发发送性能指标
-			go func() {
-				if err := c.SendMetrics(); err != nil {
-					c.Logger.Warn("发送性能指标失败: %v", err)
-				}
-				// 发送进程信息（与性能指标同频率）
-				if err := c.SendProcessInfo(); err != nil {
-					c.Logger.Warn("发送进程信息失败: %v", err)
-				}
-			}()
+			go c.sendMetricsReports(ctx)
 		case <-detailTicker.C:
 			// 并发发送详细信息
-			go func() {
-				if err := c.SendCPUInfo(); err != nil {
-					c.Logger.Warn("发送CPU详细信息失败: %v", err)
-				}
-				if err := c.SendMemoryInfo(); err != nil {
-					c.Logger.Warn("发送内存详细信息失败: %v", err)
-				}
-				if err := c.SendDiskInfo(); err != nil {
-					c.Logger.Warn("发送磁盘详细信息失败: %v", err)
-				}
-				if err := c.SendDiskIO(); err != nil {
-					c.Logger.Warn("发送磁盘IO信息失败: %v", err)
-				}
-				if err := c.SendNetworkInfo(); err != nil {
-					c.Logger.Warn("发送网络详细信息失败: %v", err)
-				}
-				if err := c.SendVirtualMemory(); err != nil {
-					c.Logger.Warn("发送Swap信息失败: %v", err)
-				}
-				if err := c.SendGPUInfo(); err != nil {
-					c.Logger.Warn("发送GPU信息失败: %v", err)
-				}
-			}()
+			go c.sendDetailReports(ctx)
 		case <-systemTicker.C:
 			// 发送系统信息
 			go func() {
-				if err := c.SendSystemInfo(); err != nil {
+				if err := c.timed(ctx, "system_info", c.collectSystemInfo); err != nil {
 					c.Logger.Warn("发送系统信息失败: %v", err)
 				}
+				if err := c.SendAgentStats(); err != nil {
+					c.Logger.Warn("发送Agent运行统计失败: %v", err)
+				}
+				c.maybeSendPackageInfo(ctx)
 			}()
 		}
 	}
 }
 
-// UpdateConfig 更新配置（用于配置重载）
+// UpdateConfig 更新配置（用于配置重载），可与采集协程并发调用
 func (c *Collector) UpdateConfig(cfg config.Config) {
-	c.Config = cfg
-	c.MetricsInterval = cfg.MetricsInterval
-	c.DetailInterval = cfg.DetailInterval
-	c.SystemInterval = cfg.SystemInterval
+	c.cfgMu.Lock()
+	old := c.cfg
+	c.cfg = cfg
+	c.cfgMu.Unlock()
+
+	intervalChanged := old.MetricsInterval != cfg.MetricsInterval ||
+		old.DetailInterval != cfg.DetailInterval ||
+		old.SystemInterval != cfg.SystemInterval
+
+	c.setTransformScript(cfg.TransformScript)
 	c.Logger.Info("配置已更新: 性能指标=%d秒, 详细信息=%d秒, 系统信息=%d秒, 监控服务数=%d",
-		c.MetricsInterval, c.DetailInterval, c.SystemInterval, len(cfg.MonitoredServices))
+		cfg.MetricsInterval, cfg.DetailInterval, cfg.SystemInterval, len(cfg.MonitoredServices))
+
+	if intervalChanged {
+		select {
+		case c.intervalChanged <- struct{}{}:
+		default:
+		}
+	}
 }
@@ -0,0 +1,19 @@
+package collector
+
+import (
+	"agent/internal/system"
+	"context"
+)
+
+// environment 返回运行环境，首次调用时检测并缓存（运行环境在进程生命周期内不会变化）
+func (c *Collector) environment(ctx context.Context) *system.Environment {
+	c.envOnce.Do(func() {
+		hostInfo, err := c.System.GetHostInfoWithContext(ctx)
+		if err != nil {
+			c.Logger.Debug("获取主机信息失败，仅根据容器特征检测运行环境: %v", err)
+		}
+		c.env = c.System.GetEnvironment(hostInfo)
+		c.Logger.Debug("运行环境: %+v", *c.env)
+	})
+	return c.env
+}
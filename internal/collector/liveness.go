@@ -0,0 +1,67 @@
+package collector
+
+import (
+	"context"
+	"math"
+	"runtime"
+	"time"
+)
+
+// livenessTimeout 心跳存活数据的采集超时，避免拖慢心跳
+const livenessTimeout = 2 * time.Second
+
+// HeartbeatPayload 返回心跳携带的精简存活数据，未开启 heartbeat_liveness 时返回 nil
+func (c *Collector) HeartbeatPayload() map[string]interface{} {
+	if !c.Config().HeartbeatLiveness {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), livenessTimeout)
+	defer cancel()
+
+	var load1 float64
+	if avg, err := c.System.GetLoadAvgWithContext(ctx); err != nil {
+		c.Logger.Debug("获取系统负载失败: %v", err)
+	} else if avg != nil {
+		load1 = avg.Load1
+	}
+
+	cpuPercent, err := c.System.GetCpuPercentSinceLastCallWithContext(ctx)
+	if err != nil {
+		c.Logger.Debug("获取CPU使用率失败: %v", err)
+	}
+
+	var memPercent float64
+	if vm, err := c.System.GetVirtualMemoryWithContext(ctx); err != nil {
+		c.Logger.Debug("获取内存使用率失败: %v", err)
+	} else if vm != nil {
+		memPercent = vm.UsedPercent
+	}
+
+	return map[string]interface{}{
+		"load1":        round2(load1),
+		"cpu_percent":  round2(cpuPercent),
+		"mem_percent":  round2(memPercent),
+		"health_score": healthScore(cpuPercent, memPercent, load1/float64(runtime.NumCPU())),
+	}
+}
+
+// healthScore 根据 CPU、内存和每核负载计算 0-100 的健康分，超过阈值的部分按比例扣分
+func healthScore(cpuPercent, memPercent, loadPerCore float64) int {
+	score := 100.0
+	if cpuPercent > 80 {
+		score -= (cpuPercent - 80) * 1.5
+	}
+	if memPercent > 85 {
+		score -= (memPercent - 85) * 2
+	}
+	if loadPerCore > 1 {
+		score -= (loadPerCore - 1) * 20
+	}
+	return int(math.Max(0, math.Min(100, math.Round(score))))
+}
+
+// round2 保留两位小数
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,116 @@
+package collector
+
+import (
+	"agent/internal/websocket"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// rateSampleWindow 一次性采集时网络/磁盘速率的采样窗口
+const rateSampleWindow = time.Second
+
+// OneShotTypes 支持一次性采集的消息类型（按输出顺序）
+var OneShotTypes = []string{
+	"system_info", "inventory", "metrics", "cpu_info", "memory_info", "swap_info",
+	"disk_info", "disk_io", "network_info", "process_info", "gpu_info", "users_info",
+	"package_info", "custom_metric",
+}
+
+// IsKnownCollector 判断是否为已知的采集项名称
+func IsKnownCollector(name string) bool {
+	for _, known := range OneShotTypes {
+		if known == name {
+			return true
+		}
+	}
+	return false
+}
+
+// oneShotCollector 返回指定消息类型对应的采集函数
+func (c *Collector) oneShotCollector(name string) collectFunc {
+	switch name {
+	case "system_info":
+		return c.collectSystemInfo
+	case "inventory":
+		return c.collectInventory
+	case "metrics":
+		return c.collectMetrics
+	case "cpu_info":
+		return c.collectCPUInfo
+	case "memory_info":
+		return c.collectMemoryInfo
+	case "swap_info":
+		return c.collectVirtualMemory
+	case "disk_info":
+		return c.collectDiskInfo
+	case "disk_io":
+		return c.collectDiskIO
+	case "network_info":
+		return c.collectNetworkInfo
+	case "process_info":
+		return c.collectProcessInfo
+	case "gpu_info":
+		return c.collectGPUInfo
+	case "users_info":
+		return c.collectUsersInfo
+	case "package_info":
+		return c.collectPackageInfo
+	}
+	return nil
+}
+
+// CollectOnce 执行一次采集并返回将要上报的消息，不连接面板
+// types 为空时采集 OneShotTypes 中的全部类型；单项失败不影响其他类型，错误会合并返回
+func (c *Collector) CollectOnce(ctx context.Context, types []string) ([]websocket.Message, error) {
+	if len(types) == 0 {
+		types = OneShotTypes
+	}
+
+	// 采集结果经过转换脚本后直接收集，不经过 sendMessage，运行中的采集器可以同时正常上报
+	var messages []websocket.Message
+	emit := func(message websocket.Message) error {
+		if message, keep := c.applyTransform(message); keep {
+			messages = append(messages, message)
+		}
+		return nil
+	}
+
+	// 速率类指标需要两次采样
+	if _, _, err := c.getNetworkSpeed(ctx); err != nil {
+		c.Logger.Debug("%v", err)
+	}
+	if _, _, err := c.getDiskIOSpeed(ctx); err != nil {
+		c.Logger.Debug("%v", err)
+	}
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(rateSampleWindow):
+	}
+
+	var errs []error
+	for _, name := range types {
+		if name == "custom_metric" {
+			// 每个插件各上报一条 custom_metric
+			if err := c.emitCustomMetrics(ctx, emit); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			}
+			continue
+		}
+		collect := c.oneShotCollector(name)
+		if collect == nil {
+			errs = append(errs, fmt.Errorf("不支持的类型: %s", name))
+			continue
+		}
+		message, err := runCollect(ctx, name, collect)
+		if err == nil && message != nil {
+			err = emit(*message)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return messages, errors.Join(errs...)
+}
@@ -0,0 +1,41 @@
+package collector
+
+import (
+	"agent/internal/websocket"
+	"context"
+	"time"
+)
+
+// collectPackageInfo 采集已安装软件包数量和待更新（含安全更新）情况
+func (c *Collector) collectPackageInfo(ctx context.Context) (*websocket.Message, error) {
+	stats, err := c.System.GetPackageStatsWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	message := websocket.Message{
+		Type: "package_info",
+		Data: stats,
+	}
+	return &message, nil
+}
+
+// maybeSendPackageInfo 随系统信息检查，距上次采集超过 package_interval 时采集一次
+// 查询包管理器开销较大，上报间隔通常以小时计
+func (c *Collector) maybeSendPackageInfo(ctx context.Context) {
+	interval := time.Duration(c.Config().PackageInterval) * time.Second
+	if interval <= 0 {
+		return
+	}
+
+	c.packageMu.Lock()
+	if !c.packageLast.IsZero() && time.Since(c.packageLast) < interval {
+		c.packageMu.Unlock()
+		return
+	}
+	c.packageLast = time.Now()
+	c.packageMu.Unlock()
+
+	if err := c.timed(ctx, "package_info", c.collectPackageInfo); err != nil {
+		c.Logger.Warn("发送软件包信息失败: %v", err)
+	}
+}
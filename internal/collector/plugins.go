@@ -0,0 +1,191 @@
+package collector
+
+import (
+	"agent/config"
+	"agent/internal/websocket"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 插件输出限制
+const (
+	pluginMaxStdout = 1024 * 1024
+	pluginMaxStderr = 4 * 1024
+)
+
+// pluginEnvAllowList 传递给插件的环境变量，其余变量（如 CLOUDSENTINEL_SECRETS_PASSPHRASE）不会传给插件
+// Windows 下 SystemRoot 等变量缺失时部分程序无法启动，一并保留
+var pluginEnvAllowList = []string{
+	"PATH", "HOME", "LANG", "LC_ALL", "TZ",
+	"SystemRoot", "TEMP", "TMP", "USERPROFILE",
+}
+
+// pluginEnv 构造插件的运行环境：仅保留白名单中的变量，并附加插件名
+func pluginEnv(name string) []string {
+	env := make([]string, 0, len(pluginEnvAllowList)+1)
+	for _, key := range pluginEnvAllowList {
+		if value, ok := os.LookupEnv(key); ok {
+			env = append(env, key+"="+value)
+		}
+	}
+	return append(env, "CLOUDSENTINEL_PLUGIN="+name)
+}
+
+// limitedBuffer 超出上限后丢弃写入内容并标记截断
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remaining := b.limit - b.buf.Len(); remaining < len(p) {
+		b.truncated = true
+		if remaining > 0 {
+			b.buf.Write(p[:remaining])
+		}
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+// runPlugins 按各插件的间隔执行自定义脚本，每秒检查一次配置，重载后自动生效
+// 同一插件上一次执行未结束时不会重复启动
+func (c *Collector) runPlugins(ctx context.Context) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	var mu sync.Mutex
+	running := make(map[string]bool)
+	nextRun := make(map[string]time.Time)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if c.isCollectorDisabled("custom_metric") {
+			continue
+		}
+		now := time.Now()
+		for _, plugin := range c.Config().Plugins {
+			if next, ok := nextRun[plugin.Name]; ok && now.Before(next) {
+				continue
+			}
+			mu.Lock()
+			if running[plugin.Name] {
+				mu.Unlock()
+				continue
+			}
+			running[plugin.Name] = true
+			mu.Unlock()
+			nextRun[plugin.Name] = now.Add(plugin.IntervalDuration())
+
+			go func(plugin config.PluginConfig) {
+				defer func() {
+					mu.Lock()
+					delete(running, plugin.Name)
+					mu.Unlock()
+				}()
+				if err := c.timed(ctx, "plugin:"+plugin.Name, func(ctx context.Context) (*websocket.Message, error) {
+					return c.collectCustomMetric(ctx, plugin)
+				}); err != nil {
+					c.Logger.Warn("插件 %s 执行失败: %v", plugin.Name, err)
+				}
+			}(plugin)
+		}
+	}
+}
+
+// emitCustomMetrics 依次执行所有已配置的插件，将结果交给 emit（用于一次性采集）
+func (c *Collector) emitCustomMetrics(ctx context.Context, emit func(websocket.Message) error) error {
+	var errs []error
+	for _, plugin := range c.Config().Plugins {
+		message, err := c.collectCustomMetric(ctx, plugin)
+		if sendErr := emit(*message); err == nil {
+			err = sendErr
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", plugin.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// collectCustomMetric 执行插件并生成 custom_metric，执行失败时消息中同样带有错误信息
+func (c *Collector) collectCustomMetric(ctx context.Context, plugin config.PluginConfig) (*websocket.Message, error) {
+	start := time.Now()
+	result, runErr := runPlugin(ctx, plugin)
+
+	data := map[string]interface{}{
+		"name":         plugin.Name,
+		"duration_ms":  time.Since(start).Milliseconds(),
+		"collected_at": start.Format(time.RFC3339),
+	}
+	if runErr != nil {
+		data["error"] = runErr.Error()
+	} else {
+		data["data"] = result
+	}
+
+	return &websocket.Message{Type: "custom_metric", Data: data}, runErr
+}
+
+// runPlugin 执行插件脚本，要求标准输出为合法 JSON
+func runPlugin(ctx context.Context, plugin config.PluginConfig) (json.RawMessage, error) {
+	if plugin.Name == "" {
+		return nil, errors.New("插件缺少名称")
+	}
+	if !filepath.IsAbs(plugin.Path) {
+		return nil, fmt.Errorf("插件路径必须是绝对路径: %s", plugin.Path)
+	}
+	info, err := os.Stat(plugin.Path)
+	if err != nil {
+		return nil, err
+	}
+	// 插件以 Agent 的权限运行，拒绝执行其他用户可修改的文件
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0022 != 0 {
+		return nil, fmt.Errorf("插件文件可被组或其他用户写入，拒绝执行: %s", plugin.Path)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, plugin.TimeoutDuration())
+	defer cancel()
+
+	stdout := &limitedBuffer{limit: pluginMaxStdout}
+	stderr := &limitedBuffer{limit: pluginMaxStderr}
+	cmd := exec.CommandContext(ctx, plugin.Path, plugin.Args...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.Env = pluginEnv(plugin.Name)
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("执行超时（%s）", plugin.TimeoutDuration())
+		}
+		if msg := strings.TrimSpace(stderr.buf.String()); msg != "" {
+			return nil, fmt.Errorf("%v: %s", err, msg)
+		}
+		return nil, err
+	}
+	if stdout.truncated {
+		return nil, fmt.Errorf("输出超过 %d 字节", pluginMaxStdout)
+	}
+
+	output := bytes.TrimSpace(stdout.buf.Bytes())
+	if !json.Valid(output) {
+		return nil, errors.New("输出不是合法的 JSON")
+	}
+	return json.RawMessage(output), nil
+}
@@ -0,0 +1,23 @@
+package collector
+
+import (
+	"agent/config"
+	"slices"
+	"testing"
+)
+
+func TestPluginEnvDropsSecrets(t *testing.T) {
+	t.Setenv(config.SecretsPassphraseEnv, "passphrase")
+	t.Setenv("PATH", "/usr/bin")
+	t.Setenv("UNRELATED_VAR", "x")
+
+	env := pluginEnv("demo")
+	if !slices.Contains(env, "PATH=/usr/bin") || !slices.Contains(env, "CLOUDSENTINEL_PLUGIN=demo") {
+		t.Errorf("env = %v", env)
+	}
+	for _, kv := range env {
+		if kv == config.SecretsPassphraseEnv+"=passphrase" || kv == "UNRELATED_VAR=x" {
+			t.Errorf("不应传递给插件: %s", kv)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package collector
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	stdnet "net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const publicIPLookupTimeout = 5 * time.Second
+
+// publicIPRetryInterval 查询失败后的重试间隔，失败不按 public_ip_interval 缓存
+const publicIPRetryInterval = time.Minute
+
+// publicIPResolver 公网IP解析器，带缓存和网络变化检测
+// 查询在后台进行，调用方始终立即拿到缓存值，不会因第三方服务缓慢而阻塞上报
+type publicIPResolver struct {
+	mu             sync.Mutex
+	client         *http.Client
+	ip             string
+	lastResolve    time.Time // 最近一次查询成功的时间
+	lastAttempt    time.Time // 最近一次发起查询的时间
+	netFingerprint string
+	resolving      bool
+}
+
+func newPublicIPResolver() *publicIPResolver {
+	return &publicIPResolver{
+		client: &http.Client{Timeout: publicIPLookupTimeout},
+	}
+}
+
+// Get 返回缓存的公网IP，缓存过期或网络变化时在后台重新查询
+// 查询完成且IP发生变化时调用 onChange（在后台协程中执行）
+func (r *publicIPResolver) Get(providers []string, interval time.Duration, onChange func(oldIP, newIP string)) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	fingerprint := localNetFingerprint()
+	networkChanged := r.netFingerprint != "" && fingerprint != r.netFingerprint
+	expired := r.lastResolve.IsZero() || time.Since(r.lastResolve) >= interval
+	// 上次查询失败时按较短的间隔重试，避免空值被缓存整个查询周期
+	backoff := !r.lastAttempt.IsZero() && time.Since(r.lastAttempt) < publicIPRetryInterval
+
+	if (networkChanged || (expired && !backoff)) && !r.resolving {
+		r.netFingerprint = fingerprint
+		r.lastAttempt = time.Now()
+		r.resolving = true
+		go r.refresh(providers, onChange)
+	}
+	return r.ip
+}
+
+// refresh 重新查询公网IP并更新缓存，查询失败时保留缓存值，按 publicIPRetryInterval 重试
+func (r *publicIPResolver) refresh(providers []string, onChange func(oldIP, newIP string)) {
+	resolved, err := r.lookup(providers)
+
+	r.mu.Lock()
+	r.resolving = false
+	if err != nil {
+		r.mu.Unlock()
+		return
+	}
+	oldIP := r.ip
+	r.ip = resolved
+	r.lastResolve = time.Now()
+	r.mu.Unlock()
+
+	if oldIP != "" && oldIP != resolved && onChange != nil {
+		onChange(oldIP, resolved)
+	}
+}
+
+// lookup 依次尝试各个查询服务，返回第一个有效结果
+func (r *publicIPResolver) lookup(providers []string) (string, error) {
+	var lastErr error
+	for _, provider := range providers {
+		ip, err := r.query(provider)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return ip, nil
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("未配置公网IP查询服务")
+	}
+	return "", lastErr
+}
+
+func (r *publicIPResolver) query(provider string) (string, error) {
+	resp, err := r.client.Get(provider)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s 返回状态码 %d", provider, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64))
+	if err != nil {
+		return "", err
+	}
+
+	ip := strings.TrimSpace(string(body))
+	if stdnet.ParseIP(ip) == nil {
+		return "", fmt.Errorf("%s 返回了无效的IP: %q", provider, ip)
+	}
+	return ip, nil
+}
+
+// localNetFingerprint 计算本机网络接口地址的指纹，用于检测网络变化
+func localNetFingerprint() string {
+	addrs, err := stdnet.InterfaceAddrs()
+	if err != nil {
+		return ""
+	}
+
+	list := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		list = append(list, addr.String())
+	}
+	sort.Strings(list)
+
+	sum := sha256.Sum256([]byte(strings.Join(list, ",")))
+	return fmt.Sprintf("%x", sum[:8])
+}
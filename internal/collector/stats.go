@@ -0,0 +1,183 @@
+package collector
+
+import (
+	"agent/internal/fault"
+	"agent/internal/websocket"
+	"context"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// CollectorStat 单个采集项的运行统计
+type CollectorStat struct {
+	Name          string        `json:"name"`
+	Runs          uint64        `json:"runs"`
+	Errors        uint64        `json:"errors"`
+	LastDuration  time.Duration `json:"-"`
+	MaxDuration   time.Duration `json:"-"`
+	TotalDuration time.Duration `json:"-"`
+	LastError     string        `json:"last_error,omitempty"`
+	LastRun       time.Time     `json:"-"`
+
+	// 发送结果单独统计，耗时不计入采集耗时
+	SendErrors       uint64        `json:"send_errors"`
+	LastSendDuration time.Duration `json:"-"`
+	LastSendError    string        `json:"last_send_error,omitempty"`
+}
+
+// ErrorRate 错误率（0-1）
+func (s CollectorStat) ErrorRate() float64 {
+	if s.Runs == 0 {
+		return 0
+	}
+	return float64(s.Errors) / float64(s.Runs)
+}
+
+// AvgDuration 平均耗时
+func (s CollectorStat) AvgDuration() time.Duration {
+	if s.Runs == 0 {
+		return 0
+	}
+	return s.TotalDuration / time.Duration(s.Runs)
+}
+
+// collectorStats 各采集项的耗时与错误统计
+type collectorStats struct {
+	mu    sync.Mutex
+	stats map[string]*CollectorStat
+}
+
+func newCollectorStats() *collectorStats {
+	return &collectorStats{stats: make(map[string]*CollectorStat)}
+}
+
+// record 记录一次采集的耗时和结果
+func (s *collectorStats) record(name string, d time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stat, ok := s.stats[name]
+	if !ok {
+		stat = &CollectorStat{Name: name}
+		s.stats[name] = stat
+	}
+	stat.Runs++
+	stat.LastDuration = d
+	stat.TotalDuration += d
+	if d > stat.MaxDuration {
+		stat.MaxDuration = d
+	}
+	stat.LastRun = time.Now()
+	if err != nil {
+		stat.Errors++
+		stat.LastError = err.Error()
+	}
+}
+
+// recordSend 记录一次上报发送的耗时和结果
+func (s *collectorStats) recordSend(name string, d time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stat, ok := s.stats[name]
+	if !ok {
+		stat = &CollectorStat{Name: name}
+		s.stats[name] = stat
+	}
+	stat.LastSendDuration = d
+	if err != nil {
+		stat.SendErrors++
+		stat.LastSendError = err.Error()
+	}
+}
+
+// snapshot 返回按名称排序的统计快照
+func (s *collectorStats) snapshot() []CollectorStat {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]CollectorStat, 0, len(s.stats))
+	for _, stat := range s.stats {
+		result = append(result, *stat)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// collectFunc 采集函数，返回待上报的消息，返回 nil 消息表示本次无需上报
+type collectFunc func(ctx context.Context) (*websocket.Message, error)
+
+// timed 执行采集函数并上报结果，已关闭的采集项直接跳过
+// 采集与发送分别统计，网络发送的耗时和失败不计入采集项本身
+func (c *Collector) timed(ctx context.Context, name string, collect collectFunc) error {
+	if c.isCollectorDisabled(name) {
+		return nil
+	}
+	start := time.Now()
+	message, err := runCollect(ctx, name, collect)
+	c.stats.record(name, time.Since(start), err)
+	if message == nil {
+		return err
+	}
+
+	sendStart := time.Now()
+	sendErr := c.sendMessage(*message)
+	c.stats.recordSend(name, time.Since(sendStart), sendErr)
+	if err != nil {
+		return err
+	}
+	return sendErr
+}
+
+// runCollect 执行采集函数；故障注入配置的采集失败在采集阶段注入，计入采集错误而不是发送错误
+func runCollect(ctx context.Context, name string, collect collectFunc) (*websocket.Message, error) {
+	if err := fault.CollectorError(name); err != nil {
+		return nil, err
+	}
+	return collect(ctx)
+}
+
+// CollectorStats 返回各采集项的运行统计
+func (c *Collector) CollectorStats() []CollectorStat {
+	return c.stats.snapshot()
+}
+
+// SendAgentStats 发送 Agent 自身运行统计（含各采集项耗时与错误率）
+func (c *Collector) SendAgentStats() error {
+	collectors := make(map[string]interface{})
+	for _, stat := range c.stats.snapshot() {
+		collectors[stat.Name] = map[string]interface{}{
+			"runs":       stat.Runs,
+			"errors":     stat.Errors,
+			"error_rate": stat.ErrorRate(),
+			"last_ms":    stat.LastDuration.Milliseconds(),
+			"avg_ms":     stat.AvgDuration().Milliseconds(),
+			"max_ms":     stat.MaxDuration.Milliseconds(),
+			"last_error": stat.LastError,
+			"last_run":   stat.LastRun.Format(time.RFC3339),
+
+			"send_errors":     stat.SendErrors,
+			"last_send_ms":    stat.LastSendDuration.Milliseconds(),
+			"last_send_error": stat.LastSendError,
+		}
+	}
+
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	message := websocket.Message{
+		Type: "agent_stats",
+		Data: map[string]interface{}{
+			"collectors":  collectors,
+			"goroutines":  runtime.NumGoroutine(),
+			"heap_alloc":  memStats.HeapAlloc,
+			"sys_memory":  memStats.Sys,
+			"gc_count":    memStats.NumGC,
+			"reported_at": time.Now().Format(time.RFC3339),
+		},
+	}
+
+	return c.sendMessage(message)
+}
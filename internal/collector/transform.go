@@ -0,0 +1,63 @@
+package collector
+
+import (
+	"agent/internal/transform"
+	"agent/internal/websocket"
+)
+
+// setTransformScript 加载或卸载上报数据转换脚本，路径未变化时保持现有脚本
+// 加载失败时保留旧脚本，避免配置错误导致数据格式突然变化
+func (c *Collector) setTransformScript(path string) {
+	c.transformMu.Lock()
+	defer c.transformMu.Unlock()
+
+	if c.transformer != nil && c.transformer.Path() == path {
+		return
+	}
+	if path == "" {
+		if c.transformer != nil {
+			c.transformer.Close()
+			c.transformer = nil
+			c.Logger.Info("已停用上报数据转换脚本")
+		}
+		return
+	}
+
+	transformer, err := transform.Load(path)
+	if err != nil {
+		c.Logger.Error("加载上报数据转换脚本失败: %v", err)
+		return
+	}
+	if c.transformer != nil {
+		c.transformer.Close()
+	}
+	c.transformer = transformer
+	c.Logger.Info("已加载上报数据转换脚本: %s", path)
+}
+
+// applyTransform 使用转换脚本处理消息，keep 为 false 表示丢弃
+// 脚本执行出错时记录日志并发送原始数据
+// 执行期间持有 transformMu，避免脚本在执行中被 setTransformScript 关闭（脚本执行有超时限制）
+func (c *Collector) applyTransform(message websocket.Message) (websocket.Message, bool) {
+	if message.Type == "agent_log" {
+		return message, true
+	}
+
+	c.transformMu.Lock()
+	defer c.transformMu.Unlock()
+	if c.transformer == nil {
+		return message, true
+	}
+
+	data, keep, err := c.transformer.Apply(message.Type, message.Data)
+	if err != nil {
+		c.Logger.Warn("转换 %s 数据失败，发送原始数据: %v", message.Type, err)
+		return message, true
+	}
+	if !keep {
+		c.Logger.Debug("转换脚本丢弃了 %s 消息", message.Type)
+		return message, false
+	}
+	message.Data = data
+	return message, true
+}
@@ -0,0 +1,42 @@
+package collector
+
+import (
+	"agent/internal/logger"
+	"agent/internal/websocket"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// 切换脚本时正在执行的转换不应使用已关闭的脚本
+func TestApplyTransformDuringReload(t *testing.T) {
+	dir := t.TempDir()
+	paths := []string{filepath.Join(dir, "a.lua"), filepath.Join(dir, "b.lua")}
+	for _, path := range paths {
+		if err := os.WriteFile(path, []byte(`function transform(t, d) d.ok = true; return d end`), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c := &Collector{Logger: logger.NewConsoleLogger(io.Discard)}
+	c.setTransformScript(paths[0])
+	defer c.setTransformScript("")
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			c.setTransformScript(paths[i%2])
+		}
+	}()
+	for i := 0; i < 200; i++ {
+		message, keep := c.applyTransform(websocket.Message{Type: "metrics", Data: map[string]interface{}{"cpu": 1}})
+		data, _ := message.Data.(map[string]interface{})
+		if !keep || data["ok"] != true {
+			t.Fatalf("转换结果异常: keep=%v data=%v", keep, message.Data)
+		}
+	}
+	wg.Wait()
+}
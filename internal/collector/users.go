@@ -0,0 +1,58 @@
+package collector
+
+import (
+	"agent/internal/websocket"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+)
+
+// failedLoginWindow SSH 登录失败的统计窗口
+const failedLoginWindow = time.Hour
+
+// collectUsersInfo 采集当前登录的用户会话；开启 failed_logins 时附带最近的 SSH 登录失败统计
+func (c *Collector) collectUsersInfo(ctx context.Context) (*websocket.Message, error) {
+	ctx, cancel := c.System.CallContext(ctx)
+	defer cancel()
+
+	// 容器等环境中没有 utmp，视为没有登录会话
+	users, err := c.System.GetUsersWithContext(ctx)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("获取登录用户失败: %w", err)
+	}
+
+	sessions := make([]map[string]interface{}, 0, len(users))
+	for _, user := range users {
+		session := map[string]interface{}{
+			"user":     user.User,
+			"terminal": user.Terminal,
+			"host":     user.Host,
+		}
+		if user.Started > 0 {
+			session["started"] = time.Unix(int64(user.Started), 0).Format(time.RFC3339)
+		}
+		sessions = append(sessions, session)
+	}
+
+	data := map[string]interface{}{
+		"users": sessions,
+		"count": len(sessions),
+	}
+
+	if c.Config().FailedLogins {
+		stats, err := c.System.GetFailedSSHLoginsWithContext(ctx, failedLoginWindow)
+		if err != nil {
+			c.Logger.Debug("统计 SSH 登录失败失败: %v", err)
+		} else {
+			data["failed_ssh_logins"] = stats
+		}
+	}
+
+	message := websocket.Message{
+		Type: "users_info",
+		Data: data,
+	}
+	return &message, nil
+}
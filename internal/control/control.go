@@ -0,0 +1,161 @@
+package control
+
+import (
+	"agent/internal/logger"
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// 单次请求的读写超时，防止异常客户端占用连接
+const requestTimeout = 30 * time.Second
+
+// ErrUnavailable 控制通道不可用（Agent 未运行或未启用控制通道）
+var ErrUnavailable = errors.New("控制通道不可用")
+
+// Request 控制请求，每个连接只处理一条请求
+type Request struct {
+	Command string   `json:"command"`
+	Args    []string `json:"args,omitempty"`
+}
+
+// Response 控制响应
+type Response struct {
+	OK    bool            `json:"ok"`
+	Error string          `json:"error,omitempty"`
+	Data  json.RawMessage `json:"data,omitempty"`
+}
+
+// HandlerFunc 处理控制命令，返回值会以 JSON 写入响应的 data 字段
+type HandlerFunc func(args []string) (interface{}, error)
+
+// Server 本地控制通道服务端（Unix 域套接字 / Windows 命名管道）
+type Server struct {
+	address  string
+	logger   *logger.Logger
+	listener net.Listener
+
+	mu       sync.RWMutex
+	handlers map[string]HandlerFunc
+}
+
+// NewServer 创建控制通道服务端
+func NewServer(address string, logger *logger.Logger) *Server {
+	return &Server{
+		address:  address,
+		logger:   logger,
+		handlers: make(map[string]HandlerFunc),
+	}
+}
+
+// Handle 注册控制命令
+func (s *Server) Handle(command string, handler HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[command] = handler
+}
+
+// Start 开始监听并在后台处理请求
+func (s *Server) Start() error {
+	listener, err := listen(s.address)
+	if err != nil {
+		return fmt.Errorf("监听控制通道失败: %w", err)
+	}
+	s.listener = listener
+	go s.serve()
+	return nil
+}
+
+// Close 停止监听
+func (s *Server) Close() error {
+	if s.listener == nil {
+		return nil
+	}
+	err := s.listener.Close()
+	cleanup(s.address)
+	return err
+}
+
+func (s *Server) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			s.logger.Warn("控制通道接受连接失败: %v", err)
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+		go s.handleConn(conn)
+	}
+}
+
+func (s *Server) handleConn(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(requestTimeout))
+
+	var req Request
+	if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&req); err != nil {
+		writeResponse(conn, Response{Error: fmt.Sprintf("无效请求: %v", err)})
+		return
+	}
+
+	s.mu.RLock()
+	handler, ok := s.handlers[req.Command]
+	s.mu.RUnlock()
+	if !ok {
+		writeResponse(conn, Response{Error: fmt.Sprintf("未知命令: %s", req.Command)})
+		return
+	}
+
+	s.logger.Debug("控制通道命令: %s %v", req.Command, req.Args)
+	result, err := handler(req.Args)
+	if err != nil {
+		writeResponse(conn, Response{Error: err.Error()})
+		return
+	}
+
+	resp := Response{OK: true}
+	if result != nil {
+		data, err := json.Marshal(result)
+		if err != nil {
+			writeResponse(conn, Response{Error: fmt.Sprintf("序列化结果失败: %v", err)})
+			return
+		}
+		resp.Data = data
+	}
+	writeResponse(conn, resp)
+}
+
+func writeResponse(conn net.Conn, resp Response) {
+	_ = json.NewEncoder(conn).Encode(resp)
+}
+
+// Call 连接运行中的 Agent 并执行控制命令，返回响应的 data 字段
+// 无法连接时返回 ErrUnavailable，调用方可回退到旧的处理方式
+func Call(address string, timeout time.Duration, command string, args ...string) (json.RawMessage, error) {
+	conn, err := dial(address, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	if err := json.NewEncoder(conn).Encode(Request{Command: command, Args: args}); err != nil {
+		return nil, fmt.Errorf("发送控制请求失败: %w", err)
+	}
+
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("读取控制响应失败: %w", err)
+	}
+	if !resp.OK {
+		return nil, errors.New(resp.Error)
+	}
+	return resp.Data, nil
+}
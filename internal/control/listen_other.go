@@ -0,0 +1,47 @@
+//go:build !windows
+
+package control
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// AddressFor 返回配置文件对应的控制套接字路径（与配置文件同目录）
+func AddressFor(configPath string) string {
+	dir := filepath.Dir(configPath)
+	base := strings.TrimSuffix(filepath.Base(configPath), ".lock.json")
+	base = strings.TrimSuffix(base, ".json")
+	return filepath.Join(dir, base+".sock")
+}
+
+func listen(address string) (net.Listener, error) {
+	// 清理上次异常退出残留的套接字文件
+	if conn, err := net.DialTimeout("unix", address, time.Second); err == nil {
+		conn.Close()
+		return nil, &net.OpError{Op: "listen", Net: "unix", Err: os.ErrExist}
+	}
+	os.Remove(address)
+
+	listener, err := net.Listen("unix", address)
+	if err != nil {
+		return nil, err
+	}
+	// 仅允许 Agent 所属用户访问
+	if err := os.Chmod(address, 0600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+func dial(address string, timeout time.Duration) (net.Conn, error) {
+	return net.DialTimeout("unix", address, timeout)
+}
+
+func cleanup(address string) {
+	os.Remove(address)
+}
@@ -0,0 +1,39 @@
+//go:build windows
+
+package control
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Microsoft/go-winio"
+)
+
+// 仅允许 SYSTEM 和 Administrators 访问命名管道
+const pipeSecurityDescriptor = "D:P(A;;GA;;;SY)(A;;GA;;;BA)"
+
+// AddressFor 返回配置文件对应的命名管道名称（按配置文件路径区分多个实例）
+func AddressFor(configPath string) string {
+	absPath, err := filepath.Abs(configPath)
+	if err != nil {
+		absPath = configPath
+	}
+	sum := sha256.Sum256([]byte(strings.ToLower(absPath)))
+	return `\\.\pipe\cloudsentinel-agent-` + hex.EncodeToString(sum[:4])
+}
+
+func listen(address string) (net.Listener, error) {
+	return winio.ListenPipe(address, &winio.PipeConfig{
+		SecurityDescriptor: pipeSecurityDescriptor,
+	})
+}
+
+func dial(address string, timeout time.Duration) (net.Conn, error) {
+	return winio.DialPipe(address, &timeout)
+}
+
+func cleanup(address string) {}
@@ -0,0 +1,18 @@
+package crypto
+
+import (
+	"crypto/sha256"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// derivedKeySize 派生密钥长度（AES-256）
+const derivedKeySize = 32
+
+// DeriveKey 使用 PBKDF2-HMAC-SHA256 从口令派生 32 字节密钥（AES-256）
+func DeriveKey(password, salt []byte, iterations int) []byte {
+	if iterations < 1 {
+		iterations = 1
+	}
+	return pbkdf2.Key(password, salt, iterations, derivedKeySize, sha256.New)
+}
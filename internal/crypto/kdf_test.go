@@ -0,0 +1,51 @@
+package crypto
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestDeriveKey(t *testing.T) {
+	// PBKDF2-HMAC-SHA256 标准测试向量（RFC 7914 第 11 节）
+	tests := []struct {
+		name       string
+		password   string
+		salt       string
+		iterations int
+		want       string
+	}{
+		{
+			name:       "单次迭代",
+			password:   "passwd",
+			salt:       "salt",
+			iterations: 1,
+			want:       "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc",
+		},
+		{
+			name:       "多次迭代",
+			password:   "Password",
+			salt:       "NaCl",
+			iterations: 80000,
+			want:       "4ddcd8f60b98be21830cee5ef22701f9641a4418d04c0414aeff08876b34ab56",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DeriveKey([]byte(tt.password), []byte(tt.salt), tt.iterations)
+			if hex.EncodeToString(got) != tt.want {
+				t.Fatalf("DeriveKey() = %x, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeriveKeyClampsIterations(t *testing.T) {
+	password, salt := []byte("passwd"), []byte("salt")
+	want := DeriveKey(password, salt, 1)
+	for _, iterations := range []int{0, -5} {
+		if got := DeriveKey(password, salt, iterations); !bytes.Equal(got, want) {
+			t.Errorf("DeriveKey(iterations=%d) = %x, want %x", iterations, got, want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package crypto
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+)
+
+// 通信加密算法
+const (
+	CipherAESGCM           = "aes-256-gcm"
+	CipherChaCha20Poly1305 = "chacha20-poly1305"
+)
+
+// HandshakeX25519 基于 X25519 密钥协商 + ChaCha20-Poly1305 的握手方案标识
+const HandshakeX25519 = "x25519-chacha20poly1305"
+
+// x25519SessionInfo HKDF 派生会话密钥时使用的上下文信息
+var x25519SessionInfo = []byte("cloudsentinel x25519 session key v1")
+
+// GenerateX25519KeyPair 生成临时 X25519 密钥对，返回私钥和原始公钥（32 字节）
+func GenerateX25519KeyPair() (*ecdh.PrivateKey, []byte, error) {
+	privateKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("生成 X25519 密钥对失败: %w", err)
+	}
+	return privateKey, privateKey.PublicKey().Bytes(), nil
+}
+
+// DeriveX25519SessionKey 使用本端私钥和对端公钥协商共享密钥，并通过 HKDF-SHA256 派生 32 字节会话密钥
+// salt 为双方公钥的拼接（按握手约定顺序），保证会话密钥与本次握手绑定
+func DeriveX25519SessionKey(privateKey *ecdh.PrivateKey, peerPublicKey, salt []byte) ([]byte, error) {
+	peer, err := ecdh.X25519().NewPublicKey(peerPublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("解析对端 X25519 公钥失败: %w", err)
+	}
+
+	shared, err := privateKey.ECDH(peer)
+	if err != nil {
+		return nil, fmt.Errorf("X25519 密钥协商失败: %w", err)
+	}
+
+	sessionKey := make([]byte, chacha20poly1305.KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, shared, salt, x25519SessionInfo), sessionKey); err != nil {
+		return nil, fmt.Errorf("派生会话密钥失败: %w", err)
+	}
+	return sessionKey, nil
+}
+
+// EncryptChaCha20 使用 ChaCha20-Poly1305 加密消息
+// 返回格式：nonce(12字节) + ciphertext + tag(16字节)
+func EncryptChaCha20(message []byte, key []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, fmt.Errorf("创建 ChaCha20-Poly1305 失败: %w", err)
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("生成 nonce 失败: %w", err)
+	}
+
+	return aead.Seal(nonce, nonce, message, nil), nil
+}
+
+// DecryptChaCha20 使用 ChaCha20-Poly1305 解密消息
+// 输入格式：nonce(12字节) + ciphertext + tag(16字节)
+func DecryptChaCha20(encryptedMessage []byte, key []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, fmt.Errorf("创建 ChaCha20-Poly1305 失败: %w", err)
+	}
+
+	nonceSize := aead.NonceSize()
+	if len(encryptedMessage) < nonceSize {
+		return nil, errors.New("加密消息长度不足")
+	}
+
+	nonce, ciphertext := encryptedMessage[:nonceSize], encryptedMessage[nonceSize:]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("解密失败: %w", err)
+	}
+	return plaintext, nil
+}
+
+// EncryptWithCipher 按算法名称加密消息，未知算法回退到 AES-GCM
+func EncryptWithCipher(cipherName string, message []byte, key []byte) ([]byte, error) {
+	if cipherName == CipherChaCha20Poly1305 {
+		return EncryptChaCha20(message, key)
+	}
+	return EncryptMessage(message, key)
+}
+
+// DecryptWithCipher 按算法名称解密消息，未知算法回退到 AES-GCM
+func DecryptWithCipher(cipherName string, encryptedMessage []byte, key []byte) ([]byte, error) {
+	if cipherName == CipherChaCha20Poly1305 {
+		return DecryptChaCha20(encryptedMessage, key)
+	}
+	return DecryptMessage(encryptedMessage, key)
+}
@@ -0,0 +1,83 @@
+package crypto
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDeriveX25519SessionKey(t *testing.T) {
+	agentPrivate, agentPublic, err := GenerateX25519KeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	panelPrivate, panelPublic, err := GenerateX25519KeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	salt := append(append([]byte{}, agentPublic...), panelPublic...)
+
+	agentKey, err := DeriveX25519SessionKey(agentPrivate, panelPublic, salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	panelKey, err := DeriveX25519SessionKey(panelPrivate, agentPublic, salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(agentKey) != 32 {
+		t.Fatalf("会话密钥长度 = %d, want 32", len(agentKey))
+	}
+	if !bytes.Equal(agentKey, panelKey) {
+		t.Fatal("双方派生的会话密钥不一致")
+	}
+
+	// salt 拼接顺序不同时应派生出不同的密钥
+	reversed := append(append([]byte{}, panelPublic...), agentPublic...)
+	otherKey, err := DeriveX25519SessionKey(agentPrivate, panelPublic, reversed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(agentKey, otherKey) {
+		t.Fatal("不同 salt 派生出相同的会话密钥")
+	}
+}
+
+func TestDeriveX25519SessionKeyInvalidPeer(t *testing.T) {
+	privateKey, _, err := GenerateX25519KeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 空公钥、长度错误以及全零的低阶点都必须拒绝
+	for _, peer := range [][]byte{nil, make([]byte, 31), make([]byte, 32)} {
+		if _, err := DeriveX25519SessionKey(privateKey, peer, nil); err == nil {
+			t.Errorf("对端公钥 %x 应返回错误", peer)
+		}
+	}
+}
+
+func TestCipherRoundTrip(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	message := []byte(`{"type":"metrics"}`)
+	for _, cipher := range []string{CipherAESGCM, CipherChaCha20Poly1305, ""} {
+		t.Run(cipher, func(t *testing.T) {
+			sealed, err := EncryptWithCipher(cipher, message, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			opened, err := DecryptWithCipher(cipher, sealed, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(opened, message) {
+				t.Fatalf("解密结果 = %q, want %q", opened, message)
+			}
+
+			// 篡改任意字节后认证标签校验失败
+			tampered := append([]byte{}, sealed...)
+			tampered[len(tampered)-1] ^= 0xff
+			if _, err := DecryptWithCipher(cipher, tampered, key); err == nil {
+				t.Fatal("篡改后的密文应解密失败")
+			}
+		})
+	}
+}
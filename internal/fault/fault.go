@@ -0,0 +1,26 @@
+package fault
+
+import "errors"
+
+// 故障注入仅用于开发与预发布环境，用于确定性地验证重连、退避和进程监管逻辑。
+// 只有使用 -tags faultinject 构建的二进制才会生效，正式发布的版本中以下接口均为空操作。
+// 启用后可通过配置文件的 fault_injection 字段或以下环境变量配置（环境变量优先）：
+//
+//	CLOUDSENTINEL_FAULT_DROP_SEND=10          静默丢弃 10% 的发送（不返回错误）
+//	CLOUDSENTINEL_FAULT_READ_DELAY_MS=500     每次读取前延迟 500ms
+//	CLOUDSENTINEL_FAULT_CORRUPT=5             破坏 5% 的发送帧
+//	CLOUDSENTINEL_FAULT_FAIL_COLLECTORS=a,b   指定的采集项（消息类型）始终失败
+//	CLOUDSENTINEL_FAULT_SEED=42               随机种子，便于复现
+const envPrefix = "CLOUDSENTINEL_FAULT_"
+
+// ErrInjected 由故障注入产生的错误
+var ErrInjected = errors.New("故障注入")
+
+// Settings 故障注入配置
+type Settings struct {
+	DropSendPercent int      `json:"drop_send_percent,omitempty"` // 丢弃发送的百分比（0-100）
+	ReadDelayMs     int      `json:"read_delay_ms,omitempty"`     // 每次读取前的延迟（毫秒）
+	CorruptPercent  int      `json:"corrupt_percent,omitempty"`   // 破坏发送帧的百分比（0-100）
+	FailCollectors  []string `json:"fail_collectors,omitempty"`   // 始终失败的采集项（消息类型）
+	Seed            int64    `json:"seed,omitempty"`              // 随机种子，0 表示使用当前时间
+}
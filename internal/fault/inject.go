@@ -0,0 +1,151 @@
+//go:build faultinject
+
+package fault
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Available 当前构建是否包含故障注入
+const Available = true
+
+var (
+	mu       sync.Mutex
+	active   Settings
+	enabled  bool
+	rng      = rand.New(rand.NewSource(time.Now().UnixNano()))
+	failures = map[string]bool{}
+)
+
+// Configure 应用故障注入配置，环境变量会覆盖配置文件中的值
+func Configure(s *Settings) {
+	var merged Settings
+	if s != nil {
+		merged = *s
+		merged.FailCollectors = append([]string(nil), s.FailCollectors...)
+	}
+	applyEnv(&merged)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	active = merged
+	enabled = merged.DropSendPercent > 0 || merged.ReadDelayMs > 0 ||
+		merged.CorruptPercent > 0 || len(merged.FailCollectors) > 0
+
+	seed := merged.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	rng = rand.New(rand.NewSource(seed))
+
+	failures = make(map[string]bool, len(merged.FailCollectors))
+	for _, name := range merged.FailCollectors {
+		if name = strings.TrimSpace(name); name != "" {
+			failures[name] = true
+		}
+	}
+}
+
+func applyEnv(s *Settings) {
+	if v, ok := envInt("DROP_SEND"); ok {
+		s.DropSendPercent = v
+	}
+	if v, ok := envInt("READ_DELAY_MS"); ok {
+		s.ReadDelayMs = v
+	}
+	if v, ok := envInt("CORRUPT"); ok {
+		s.CorruptPercent = v
+	}
+	if v, ok := envInt("SEED"); ok {
+		s.Seed = int64(v)
+	}
+	if v := os.Getenv(envPrefix + "FAIL_COLLECTORS"); v != "" {
+		s.FailCollectors = strings.Split(v, ",")
+	}
+}
+
+func envInt(name string) (int, bool) {
+	raw := os.Getenv(envPrefix + name)
+	if raw == "" {
+		return 0, false
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
+
+// Enabled 是否启用了任意故障注入
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return enabled
+}
+
+// Describe 返回当前生效的故障注入配置描述
+func Describe() string {
+	mu.Lock()
+	defer mu.Unlock()
+	return fmt.Sprintf("drop_send=%d%%, read_delay=%dms, corrupt=%d%%, fail_collectors=%v",
+		active.DropSendPercent, active.ReadDelayMs, active.CorruptPercent, active.FailCollectors)
+}
+
+// hit 按百分比概率返回 true（调用方需持有锁）
+func hit(percent int) bool {
+	if percent <= 0 {
+		return false
+	}
+	if percent >= 100 {
+		return true
+	}
+	return rng.Intn(100) < percent
+}
+
+// DropSend 判断本次发送是否应被静默丢弃，丢弃时调用方不写入数据并视为发送成功
+func DropSend() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return enabled && hit(active.DropSendPercent)
+}
+
+// DelayRead 在读取前注入延迟
+func DelayRead() {
+	mu.Lock()
+	delay := time.Duration(active.ReadDelayMs) * time.Millisecond
+	mu.Unlock()
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
+// Corrupt 按概率破坏待发送的数据帧，返回可能被修改的副本
+func Corrupt(frame []byte) []byte {
+	mu.Lock()
+	defer mu.Unlock()
+	if !enabled || len(frame) == 0 || !hit(active.CorruptPercent) {
+		return frame
+	}
+	corrupted := make([]byte, len(frame))
+	copy(corrupted, frame)
+	idx := rng.Intn(len(corrupted))
+	corrupted[idx] ^= 0xFF
+	return corrupted
+}
+
+// CollectorError 若指定采集项被配置为失败，则返回注入的错误
+func CollectorError(name string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if enabled && failures[name] {
+		return fmt.Errorf("%w: 采集项 %s 失败", ErrInjected, name)
+	}
+	return nil
+}
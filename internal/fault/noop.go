@@ -0,0 +1,27 @@
+//go:build !faultinject
+
+package fault
+
+// Available 当前构建是否包含故障注入
+const Available = false
+
+// Configure 正式构建中忽略故障注入配置
+func Configure(s *Settings) {}
+
+// Enabled 正式构建中始终为 false
+func Enabled() bool { return false }
+
+// Describe 正式构建中返回空字符串
+func Describe() string { return "" }
+
+// DropSend 正式构建中从不丢弃发送
+func DropSend() bool { return false }
+
+// DelayRead 正式构建中不注入延迟
+func DelayRead() {}
+
+// Corrupt 正式构建中原样返回数据帧
+func Corrupt(frame []byte) []byte { return frame }
+
+// CollectorError 正式构建中始终返回 nil
+func CollectorError(name string) error { return nil }
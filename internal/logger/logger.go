@@ -2,9 +2,11 @@ package logger
 
 import (
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 )
@@ -15,8 +17,38 @@ const (
 	Green  = "\033[32m"
 	Yellow = "\033[33m"
 	White  = "\033[37m"
+	Cyan   = "\033[36m"
 )
 
+// recentCapacity 内存中保留的最近日志条数
+const recentCapacity = 200
+
+// 日志级别
+const (
+	LevelDebug = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+	// LevelAudit 审计日志专用级别，高于所有可配置的级别，因此不会被过滤，也不会混入错误日志统计
+	LevelAudit
+)
+
+// ParseLevel 解析日志级别字符串
+func ParseLevel(level string) (int, error) {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return LevelDebug, nil
+	case "", "info":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	default:
+		return LevelInfo, fmt.Errorf("unknown log level: %s", level)
+	}
+}
+
 type Logger struct {
 	fileLogger    *log.Logger
 	console       *log.Logger
@@ -26,6 +58,11 @@ type Logger struct {
 	currentDate   string
 	retentionDays int
 	handler       LogHandler
+	level         int
+
+	// 最近日志环形缓冲，供本地状态页展示
+	recent     []string
+	recentNext int
 }
 
 // LogHandler 日志处理函数类型
@@ -51,6 +88,7 @@ func NewLogger(logDir string, retentionDays int) (*Logger, error) {
 		file:          file,
 		currentDate:   date,
 		retentionDays: retentionDays,
+		level:         LevelInfo,
 	}
 
 	// 启动后台任务：清理旧日志
@@ -59,8 +97,21 @@ func NewLogger(logDir string, retentionDays int) (*Logger, error) {
 	return l, nil
 }
 
+// NewConsoleLogger 创建仅输出到指定 Writer 的日志器（不写日志文件），用于一次性 CLI 命令
+func NewConsoleLogger(w io.Writer) *Logger {
+	return &Logger{
+		fileLogger: log.New(io.Discard, "", log.LstdFlags),
+		console:    log.New(w, "", log.LstdFlags),
+		level:      LevelInfo,
+	}
+}
+
 // rotate 检查并轮转日志文件
 func (l *Logger) rotate() error {
+	if l.logDir == "" {
+		return nil
+	}
+
 	today := time.Now().Format("2006-01-02")
 	if today == l.currentDate {
 		return nil
@@ -162,20 +213,81 @@ func (l *Logger) deleteLogFile(name string) {
 	}
 }
 
+func (l *Logger) Debug(format string, v ...interface{}) {
+	l.log(LevelDebug, Cyan, "DEBUG", format, v...)
+}
+
 func (l *Logger) Info(format string, v ...interface{}) {
-	l.log(White, "INFO", format, v...)
+	l.log(LevelInfo, White, "INFO", format, v...)
 }
 
 func (l *Logger) Warn(format string, v ...interface{}) {
-	l.log(Yellow, "WARN", format, v...)
+	l.log(LevelWarn, Yellow, "WARN", format, v...)
 }
 
 func (l *Logger) Error(format string, v ...interface{}) {
-	l.log(Red, "ERROR", format, v...)
+	l.log(LevelError, Red, "ERROR", format, v...)
 }
 
 func (l *Logger) Success(format string, v ...interface{}) {
-	l.log(Green, "SUCCESS", format, v...)
+	l.log(LevelInfo, Green, "SUCCESS", format, v...)
+}
+
+// Audit 记录审计日志（敏感操作），不受日志级别过滤
+func (l *Logger) Audit(format string, v ...interface{}) {
+	l.log(LevelAudit, Cyan, "AUDIT", format, v...)
+}
+
+// SetLevel 设置日志级别（debug/info/warn/error）
+func (l *Logger) SetLevel(level string) error {
+	lv, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.level = lv
+	return nil
+}
+
+// Level 返回当前日志级别名称
+func (l *Logger) Level() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	switch l.level {
+	case LevelDebug:
+		return "debug"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	default:
+		return "info"
+	}
+}
+
+// remember 记录到最近日志环形缓冲（调用方需持有锁）
+func (l *Logger) remember(line string) {
+	if len(l.recent) < recentCapacity {
+		l.recent = append(l.recent, line)
+		return
+	}
+	l.recent[l.recentNext] = line
+	l.recentNext = (l.recentNext + 1) % recentCapacity
+}
+
+// Recent 返回最近的 n 条日志（按时间顺序）
+func (l *Logger) Recent(n int) []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ordered := make([]string, 0, len(l.recent))
+	ordered = append(ordered, l.recent[l.recentNext:]...)
+	ordered = append(ordered, l.recent[:l.recentNext]...)
+	if n > 0 && len(ordered) > n {
+		ordered = ordered[len(ordered)-n:]
+	}
+	return ordered
 }
 
 // SetHandler 设置日志处理函数
@@ -185,10 +297,14 @@ func (l *Logger) SetHandler(h LogHandler) {
 	l.handler = h
 }
 
-func (l *Logger) log(color, level, format string, v ...interface{}) {
+func (l *Logger) log(severity int, color, level, format string, v ...interface{}) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
+	if severity < l.level {
+		return
+	}
+
 	// 检查是否需要轮转
 	if err := l.rotate(); err != nil {
 		fmt.Fprintf(os.Stderr, "Log rotation failed: %v\n", err)
@@ -197,6 +313,7 @@ func (l *Logger) log(color, level, format string, v ...interface{}) {
 	msg := fmt.Sprintf(format, v...)
 	l.fileLogger.Printf("[%s] %s", level, msg)
 	l.console.Printf("%s[%s] %s%s", color, level, msg, Reset)
+	l.remember(fmt.Sprintf("%s [%s] %s", time.Now().Format("2006-01-02 15:04:05"), level, msg))
 
 	if l.handler != nil {
 		// 异步调用 handler，避免阻塞日志记录
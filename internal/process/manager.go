@@ -121,7 +121,7 @@ func (pm *ProcessManager) runHeartbeatProcess() {
 		}
 
 		// 检查连接状态
-		if pm.client == nil || !pm.client.IsConnected {
+		if pm.client == nil || !pm.client.IsConnected() {
 			pm.logger.Warn("心跳进程：WebSocket 未连接，等待重连...")
 			time.Sleep(pm.heartbeatRestartDelay)
 			pm.exponentialBackoff(&pm.heartbeatRestartDelay)
@@ -196,7 +196,7 @@ func (pm *ProcessManager) runReporterProcess() {
 		}
 
 		// 检查连接状态
-		if pm.client == nil || !pm.client.IsConnected {
+		if pm.client == nil || !pm.client.IsConnected() {
 			pm.logger.Warn("数据上报进程：WebSocket 未连接，等待重连...")
 			time.Sleep(pm.reporterRestartDelay)
 			pm.exponentialBackoff(&pm.reporterRestartDelay)
@@ -0,0 +1,44 @@
+package reporter
+
+import (
+	"time"
+)
+
+// HelloPayload 面板心跳响应的数据，server_time/agent_time 为毫秒时间戳
+type HelloPayload struct {
+	ServerTime int64 `json:"server_time"`
+	AgentTime  int64 `json:"agent_time"`
+}
+
+func init() {
+	RegisterMessageHandler("hello", handleHelloMessage)
+}
+
+// handleHelloMessage 根据面板心跳响应中的时间检测时钟偏差
+// 时钟偏差过大会导致 TLS 证书校验和令牌认证失败，超过阈值时告警，恢复后记录一次
+func handleHelloMessage(s *Session, env *Envelope) error {
+	var payload HelloPayload
+	if err := env.DecodeData(&payload); err != nil || payload.ServerTime <= 0 {
+		// 旧版面板不返回时间，忽略
+		return nil
+	}
+
+	var agentTime time.Time
+	if payload.AgentTime > 0 {
+		agentTime = time.UnixMilli(payload.AgentTime)
+	}
+	skew := s.Client.RecordServerTime(time.UnixMilli(payload.ServerTime), agentTime)
+
+	threshold := time.Duration(s.Config.Get().MaxClockSkew) * time.Second
+	exceeded := threshold > 0 && skew.Abs() > threshold
+	switch {
+	case exceeded && !s.clockSkewWarned:
+		s.clockSkewWarned = true
+		s.Logger.Warn("本地时钟与面板相差 %s（阈值 %s），可能导致 TLS 或认证失败，请检查 NTP 同步",
+			skew.Offset.Round(time.Millisecond), threshold)
+	case !exceeded && s.clockSkewWarned:
+		s.clockSkewWarned = false
+		s.Logger.Info("本地时钟与面板的偏差已恢复正常: %s", skew.Offset.Round(time.Millisecond))
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package reporter
+
+import (
+	"agent/config"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// UpdateConfigPayload update_config 命令的数据，未提供或类型不符的字段保持不变
+type UpdateConfigPayload struct {
+	Timezone          looseString     `json:"timezone"`
+	MetricsInterval   looseNumber     `json:"metrics_interval"`
+	DetailInterval    looseNumber     `json:"detail_interval"`
+	SystemInterval    looseNumber     `json:"system_interval"`
+	HeartbeatInterval looseNumber     `json:"heartbeat_interval"`
+	LogPath           looseString     `json:"log_path"`
+	DisplayName       looseString     `json:"display_name"`
+	MonitoredServices looseStringList `json:"monitored_services"`
+}
+
+// UpdatePayload update 命令的数据
+type UpdatePayload struct {
+	Version     string `json:"version"`
+	VersionType string `json:"version_type"`
+}
+
+func init() {
+	RegisterCommandHandler("service_check", handleServiceCheckCommand)
+	RegisterCommandHandler("pcap_capture", handlePcapCaptureCommand)
+	RegisterCommandHandler("restart", handleRestartCommand)
+	RegisterCommandHandler("update_config", handleUpdateConfigCommand)
+	RegisterCommandHandler("update", handleUpdateCommand)
+}
+
+func handleServiceCheckCommand(s *Session, env *Envelope) error {
+	sendCommandAck(s.Client, env.Command, env.CommandID, s.Logger)
+	var checkData map[string]interface{}
+	if err := env.DecodeData(&checkData); err != nil {
+		return nil
+	}
+	go handleServiceCheck(s.Client, checkData, s.Logger)
+	return nil
+}
+
+func handlePcapCaptureCommand(s *Session, env *Envelope) error {
+	sendCommandAck(s.Client, env.Command, env.CommandID, s.Logger)
+	var captureData map[string]interface{}
+	_ = env.DecodeData(&captureData)
+	go handlePcapCapture(s.Client, s.Config, env.CommandID, captureData, s.Logger)
+	return nil
+}
+
+func handleRestartCommand(s *Session, env *Envelope) error {
+	s.Logger.Info("收到重启命令，准备重启...")
+	// 发送确认消息
+	if err := s.Reply("restart", "success", "正在重启..."); err != nil {
+		s.Logger.Error("发送重启确认消息失败: %v", err)
+	}
+	restartAfterReply(s)
+	return nil
+}
+
+// restartAfterReply 延迟一小段时间确保响应发出后重启，不会返回
+func restartAfterReply(s *Session) {
+	time.Sleep(500 * time.Millisecond)
+	if err := restartAgent(s.Logger); err != nil {
+		s.Logger.Error("重启失败: %v", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func handleUpdateConfigCommand(s *Session, env *Envelope) error {
+	var update UpdateConfigPayload
+	if err := env.DecodeData(&update); err != nil {
+		s.Logger.Error("配置更新命令数据格式错误")
+		s.Reply("update_config", "error", "配置更新命令数据格式错误")
+		return nil
+	}
+
+	// 以配置文件的原始内容为基础只修改推送的配置项，不写入运行中填充的默认值
+	path := s.Config.Path()
+	cfg, err := config.LoadConfigForUpdate(path)
+	if err != nil {
+		s.Logger.Error("读取配置文件失败: %v", err)
+		s.Reply("update_config", "error", fmt.Sprintf("读取配置文件失败: %v", err))
+		return nil
+	}
+	configUpdated := false
+	if update.Timezone.Value != "" {
+		cfg.Timezone = update.Timezone.Value
+		configUpdated = true
+	}
+	if update.MetricsInterval > 0 {
+		cfg.MetricsInterval = int(update.MetricsInterval)
+		configUpdated = true
+	}
+	if update.DetailInterval > 0 {
+		cfg.DetailInterval = int(update.DetailInterval)
+		configUpdated = true
+	}
+	if update.SystemInterval > 0 {
+		cfg.SystemInterval = int(update.SystemInterval)
+		configUpdated = true
+	}
+	if update.HeartbeatInterval > 0 {
+		cfg.HeartbeatInterval = int(update.HeartbeatInterval)
+		configUpdated = true
+	}
+	if update.LogPath.Value != "" {
+		cfg.LogPath = update.LogPath.Value
+		configUpdated = true
+	}
+	if update.DisplayName.Valid {
+		cfg.DisplayName = strings.TrimSpace(update.DisplayName.Value)
+		configUpdated = true
+	}
+	if update.MonitoredServices.Valid {
+		cfg.MonitoredServices = update.MonitoredServices.Values
+		configUpdated = true
+	}
+
+	if !configUpdated {
+		s.Logger.Warn("配置更新命令未包含有效配置项")
+		s.Reply("update_config", "success", "未检测到配置变更")
+		return nil
+	}
+
+	// 保存配置到文件
+	if err := config.SaveConfig(cfg, path); err != nil {
+		s.Logger.Error("保存配置失败: %v", err)
+		s.Reply("update_config", "error", fmt.Sprintf("保存配置失败: %v", err))
+		return nil
+	}
+
+	s.Logger.Info("配置已更新并保存")
+
+	// 重载成功后再确认
+	if err := s.reloadConfig(); err != nil {
+		s.Logger.Error("配置重载失败: %v", err)
+		s.Reply("update_config", "error", fmt.Sprintf("配置已保存，但重载失败: %v", err))
+		return nil
+	}
+	if err := s.Reply("update_config", "success", "配置已更新并重载"); err != nil {
+		s.Logger.Error("发送配置更新确认消息失败: %v", err)
+	}
+	return nil
+}
+
+func handleUpdateCommand(s *Session, env *Envelope) error {
+	var update UpdatePayload
+	if err := env.DecodeData(&update); err != nil {
+		s.Logger.Error("更新命令数据格式错误")
+		s.Reply("update", "error", "更新命令数据格式错误")
+		return nil
+	}
+	if update.Version == "" {
+		s.Logger.Error("更新命令缺少版本号")
+		s.Reply("update", "error", "更新命令缺少版本号")
+		return nil
+	}
+
+	s.Logger.Info("收到更新命令，版本: %s, 类型: %s", update.Version, update.VersionType)
+
+	// 发送确认消息
+	if err := s.Reply("update", "success", "开始更新..."); err != nil {
+		s.Logger.Error("发送更新确认消息失败: %v", err)
+	}
+
+	go func() {
+		updateService := NewUpdateService(s.Logger)
+		if err := updateService.UpdateAgent(update.Version, update.VersionType); err != nil {
+			s.Logger.Error("更新失败: %v", err)
+			s.Reply("update", "error", fmt.Sprintf("更新失败: %v", err))
+			return
+		}
+		s.Reply("update", "success", "更新完成，正在重启...")
+	}()
+	return nil
+}
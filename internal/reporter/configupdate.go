@@ -0,0 +1,145 @@
+package reporter
+
+import (
+	"agent/config"
+	"agent/internal/collector"
+	"agent/internal/logger"
+	"agent/internal/websocket"
+	"fmt"
+	"sort"
+)
+
+// 面板推送的上报间隔允许范围（秒）
+const (
+	minPushedInterval = 1
+	maxPushedInterval = 86400
+)
+
+// ConfigUpdatePayload config_update 消息的数据，未提供的字段保持不变
+type ConfigUpdatePayload struct {
+	RequestID         string          `json:"request_id"`
+	MetricsInterval   *int            `json:"metrics_interval"`
+	DetailInterval    *int            `json:"detail_interval"`
+	SystemInterval    *int            `json:"system_interval"`
+	HeartbeatInterval *int            `json:"heartbeat_interval"`
+	LogLevel          *string         `json:"log_level"`
+	Collectors        map[string]bool `json:"collectors"` // 采集项开关，false 表示关闭
+}
+
+func init() {
+	RegisterMessageHandler("config_update", handleConfigUpdate)
+}
+
+// handleConfigUpdate 校验并应用面板推送的配置，持久化后回复生效的配置
+// 任一字段校验失败时整体拒绝，不做部分应用
+func handleConfigUpdate(s *Session, env *Envelope) error {
+	if env.IsResponse() {
+		return nil
+	}
+
+	var update ConfigUpdatePayload
+	if err := env.DecodeData(&update); err != nil {
+		return sendConfigUpdateAck(s, update.RequestID, "error", err.Error())
+	}
+
+	// 以配置文件的当前内容为基础修改，而不是运行中的配置副本，避免写回启动时的默认值或过期内容
+	path := s.Config.Path()
+	updated, err := config.LoadConfigForUpdate(path)
+	if err != nil {
+		return sendConfigUpdateAck(s, update.RequestID, "error", fmt.Sprintf("读取配置文件失败: %v", err))
+	}
+	if err := applyConfigUpdate(&updated, &update); err != nil {
+		s.Logger.Warn("拒绝面板推送的配置: %v", err)
+		return sendConfigUpdateAck(s, update.RequestID, "error", err.Error())
+	}
+
+	if err := config.SaveConfig(updated, path); err != nil {
+		return sendConfigUpdateAck(s, update.RequestID, "error", fmt.Sprintf("保存配置失败: %v", err))
+	}
+
+	// 通过重载路径在运行时生效（上报间隔、日志级别、采集项开关），重载成功后才确认
+	if err := s.reloadConfig(); err != nil {
+		s.Logger.Error("应用面板推送的配置失败: %v", err)
+		return sendConfigUpdateAck(s, update.RequestID, "error", fmt.Sprintf("配置已保存，但重载失败: %v", err))
+	}
+	s.Logger.Info("已应用面板推送的配置")
+	return sendConfigUpdateAck(s, update.RequestID, "success", "配置已更新")
+}
+
+// reloadConfig 使已保存的配置在运行时生效；未设置重载回调时重新读取配置文件（填充默认值）替换共享配置
+func (s *Session) reloadConfig() error {
+	if s.Callbacks.OnReload == nil {
+		cfg, err := config.LoadConfigFromFile(s.Config.Path())
+		if err != nil {
+			return err
+		}
+		s.Config.Replace(cfg)
+		return nil
+	}
+	return s.Callbacks.OnReload()
+}
+
+// applyConfigUpdate 校验推送的配置并写入 cfg
+func applyConfigUpdate(cfg *config.Config, update *ConfigUpdatePayload) error {
+	intervals := []struct {
+		name   string
+		value  *int
+		target *int
+	}{
+		{"metrics_interval", update.MetricsInterval, &cfg.MetricsInterval},
+		{"detail_interval", update.DetailInterval, &cfg.DetailInterval},
+		{"system_interval", update.SystemInterval, &cfg.SystemInterval},
+		{"heartbeat_interval", update.HeartbeatInterval, &cfg.HeartbeatInterval},
+	}
+	for _, interval := range intervals {
+		if interval.value == nil {
+			continue
+		}
+		if *interval.value < minPushedInterval || *interval.value > maxPushedInterval {
+			return fmt.Errorf("%s 超出范围（%d-%d）", interval.name, minPushedInterval, maxPushedInterval)
+		}
+		*interval.target = *interval.value
+	}
+
+	if update.LogLevel != nil {
+		if _, err := logger.ParseLevel(*update.LogLevel); err != nil {
+			return fmt.Errorf("log_level 无效: %s", *update.LogLevel)
+		}
+		cfg.LogLevel = *update.LogLevel
+	}
+
+	if len(update.Collectors) > 0 {
+		disabled := make(map[string]bool)
+		for _, name := range cfg.DisabledCollectors {
+			disabled[name] = true
+		}
+		for name, enabled := range update.Collectors {
+			if !collector.IsKnownCollector(name) {
+				return fmt.Errorf("未知的采集项: %s", name)
+			}
+			disabled[name] = !enabled
+		}
+		cfg.DisabledCollectors = nil
+		for name, off := range disabled {
+			if off {
+				cfg.DisabledCollectors = append(cfg.DisabledCollectors, name)
+			}
+		}
+		sort.Strings(cfg.DisabledCollectors)
+	}
+	return nil
+}
+
+// sendConfigUpdateAck 回复配置推送结果及当前生效的配置
+func sendConfigUpdateAck(s *Session, requestID, status, message string) error {
+	cfg := s.Config.Get()
+	return s.Client.SendMessage(websocket.Message{
+		Type: "config_update_ack",
+		Data: map[string]interface{}{
+			"request_id": requestID,
+			"status":     status,
+			"message":    message,
+			"config":     panelConfigView(&cfg),
+		},
+	})
+}
@@ -0,0 +1,78 @@
+package reporter
+
+import (
+	"agent/config"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func intPtr(v int) *int       { return &v }
+func strPtr(v string) *string { return &v }
+
+func TestApplyConfigUpdate(t *testing.T) {
+	cfg := config.Config{
+		MetricsInterval:    30,
+		DetailInterval:     60,
+		LogLevel:           "info",
+		DisabledCollectors: []string{"gpu_info"},
+	}
+	update := ConfigUpdatePayload{
+		MetricsInterval: intPtr(5),
+		LogLevel:        strPtr("debug"),
+		Collectors:      map[string]bool{"gpu_info": true, "users_info": false, "disk_io": false},
+	}
+	if err := applyConfigUpdate(&cfg, &update); err != nil {
+		t.Fatalf("applyConfigUpdate: %v", err)
+	}
+	if cfg.MetricsInterval != 5 || cfg.DetailInterval != 60 || cfg.LogLevel != "debug" {
+		t.Errorf("cfg = %+v", cfg)
+	}
+	if want := []string{"disk_io", "users_info"}; !reflect.DeepEqual(cfg.DisabledCollectors, want) {
+		t.Errorf("DisabledCollectors = %v, want %v", cfg.DisabledCollectors, want)
+	}
+}
+
+func TestApplyConfigUpdateRejects(t *testing.T) {
+	for name, update := range map[string]ConfigUpdatePayload{
+		"间隔过小":   {MetricsInterval: intPtr(0)},
+		"间隔过大":   {HeartbeatInterval: intPtr(maxPushedInterval + 1)},
+		"无效日志级别": {LogLevel: strPtr("verbose")},
+		"未知采集项":  {Collectors: map[string]bool{"no_such": true}},
+	} {
+		cfg := config.Config{MetricsInterval: 30, LogLevel: "info"}
+		if err := applyConfigUpdate(&cfg, &update); err == nil {
+			t.Errorf("%s: 应拒绝", name)
+		}
+	}
+}
+
+// 写回的配置文件只应包含原有内容和推送的字段，不应固化默认值
+func TestConfigUpdateKeepsFileMinimal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.json")
+	if err := os.WriteFile(path, []byte(`{"server":"ws://127.0.0.1:1","key":"k"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.LoadConfigForUpdate(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := applyConfigUpdate(&cfg, &ConfigUpdatePayload{MetricsInterval: intPtr(15)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.SaveConfig(cfg, path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"metrics_interval": 15`) {
+		t.Errorf("推送的字段未写回: %s", data)
+	}
+	if !strings.Contains(string(data), `"heartbeat_interval": 0`) {
+		t.Errorf("默认值被写入配置文件: %s", data)
+	}
+}
@@ -0,0 +1,195 @@
+package reporter
+
+import (
+	"agent/config"
+	"agent/internal/logger"
+	"agent/internal/websocket"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// Envelope 面板下发消息的公共字段，data 保留原始 JSON 由各处理函数按需解析
+type Envelope struct {
+	Type      string          `json:"type"`
+	Status    string          `json:"status,omitempty"`
+	Message   string          `json:"message,omitempty"`
+	Command   string          `json:"command,omitempty"`
+	CommandID string          `json:"command_id,omitempty"`
+	Data      json.RawMessage `json:"data,omitempty"`
+}
+
+// IsResponse 是否为面板对 Agent 请求的响应（带 status 字段）
+func (e *Envelope) IsResponse() bool {
+	return e.Status != ""
+}
+
+// DecodeData 将 data 字段解析到 v
+func (e *Envelope) DecodeData(v interface{}) error {
+	if len(e.Data) == 0 || string(e.Data) == "null" {
+		return fmt.Errorf("%s 消息缺少 data", e.Type)
+	}
+	if err := json.Unmarshal(e.Data, v); err != nil {
+		return fmt.Errorf("%s 消息数据格式错误: %w", e.Type, err)
+	}
+	return nil
+}
+
+// Session 一次 Reporter 运行期间处理函数共享的上下文
+type Session struct {
+	Client    *websocket.Client
+	Config    *config.Store
+	Logger    *logger.Logger
+	Callbacks ReporterCallbacks
+
+	jobs               *JobManager
+	keys               *keyState
+	taskPollStarted    bool
+	keyRotationStarted bool
+	clockSkewWarned    bool
+}
+
+// Reply 发送 command_response 消息
+func (s *Session) Reply(command, status, message string) error {
+	return s.ReplyWithData(command, status, message, nil)
+}
+
+// ReplyWithData 发送携带附加字段的 command_response 消息
+func (s *Session) ReplyWithData(command, status, message string, extra map[string]interface{}) error {
+	data := map[string]interface{}{
+		"command": command,
+		"status":  status,
+		"message": message,
+	}
+	for key, value := range extra {
+		data[key] = value
+	}
+	return s.Client.SendMessage(websocket.Message{Type: "command_response", Data: data})
+}
+
+// MessageHandler 按消息类型注册的处理函数
+type MessageHandler func(s *Session, env *Envelope) error
+
+// CommandHandler 按命令名注册的处理函数（type 为 command 的消息）
+type CommandHandler func(s *Session, env *Envelope) error
+
+var (
+	handlersMu      sync.RWMutex
+	messageHandlers = make(map[string]MessageHandler)
+	commandHandlers = make(map[string]CommandHandler)
+)
+
+// RegisterMessageHandler 注册消息类型处理函数，重复注册时覆盖
+func RegisterMessageHandler(msgType string, handler MessageHandler) {
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+	messageHandlers[msgType] = handler
+}
+
+// RegisterCommandHandler 注册面板命令处理函数，重复注册时覆盖
+func RegisterCommandHandler(command string, handler CommandHandler) {
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+	commandHandlers[command] = handler
+}
+
+// dispatch 将一条消息分发给已注册的处理函数
+func (s *Session) dispatch(raw []byte) {
+	var env Envelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		s.Logger.Error("解析JSON数据时出错: %v", err)
+		recordProtocolError("", fmt.Sprintf("解析JSON数据时出错: %v", err))
+		return
+	}
+
+	// 面板返回的失败响应统一记录
+	if env.IsResponse() && env.Message != "" && env.Status != "success" {
+		s.Logger.Warn("%s: %s", env.Type, env.Message)
+		recordProtocolError(env.Type, env.Message)
+	}
+
+	handlersMu.RLock()
+	handler, ok := messageHandlers[env.Type]
+	handlersMu.RUnlock()
+	if !ok {
+		if !env.IsResponse() {
+			s.Logger.Warn("未知的消息类型: %v", env.Type)
+			recordProtocolError(env.Type, "未知的消息类型")
+		}
+		return
+	}
+
+	if err := handler(s, &env); err != nil {
+		s.Logger.Error("处理 %s 消息失败: %v", env.Type, err)
+		recordProtocolError(env.Type, err.Error())
+	}
+}
+
+// handleCommandMessage 将 command 消息分发给对应的命令处理函数
+func handleCommandMessage(s *Session, env *Envelope) error {
+	if env.IsResponse() || env.Command == "" {
+		return nil
+	}
+
+	handlersMu.RLock()
+	handler, ok := commandHandlers[env.Command]
+	handlersMu.RUnlock()
+	if !ok {
+		s.Logger.Warn("未知的命令: %s", env.Command)
+		return nil
+	}
+	return handler(s, env)
+}
+
+func init() {
+	RegisterMessageHandler("command", handleCommandMessage)
+	RegisterMessageHandler("auth", handleAuthMessage)
+	RegisterMessageHandler("key_exchange", func(s *Session, env *Envelope) error {
+		if env.Status != "success" {
+			return nil
+		}
+		if err := handleKeyExchange(env, s.Client, s.Config, s.Logger); err != nil {
+			return fmt.Errorf("密钥交换失败: %w", err)
+		}
+		return nil
+	})
+	RegisterMessageHandler("session_key", func(s *Session, env *Envelope) error {
+		if env.Status != "success" {
+			return nil
+		}
+		if err := handleSessionKey(env, s.Client, s.Config, s.keys, s.Logger); err != nil {
+			return fmt.Errorf("接收会话密钥失败: %w", err)
+		}
+		return nil
+	})
+}
+
+// handleAuthMessage 处理认证结果；无 status 时表示面板要求重新认证
+func handleAuthMessage(s *Session, env *Envelope) error {
+	if !env.IsResponse() {
+		sendAuthMessage(s.Client, s.Config, s.keys, s.Logger)
+		return nil
+	}
+	if env.Status != "success" {
+		return nil
+	}
+
+	s.Logger.Success("认证成功")
+
+	// 发送当前配置到面板
+	sendConfigToPanel(s.Client, s.Config, s.Logger)
+	if !s.taskPollStarted {
+		s.taskPollStarted = true
+		go pollAgentTasks(s.Client, s.Config, s.Logger)
+	}
+	if !s.keyRotationStarted {
+		s.keyRotationStarted = true
+		go runKeyRotation(s.Client, s.Config, s.keys, s.Logger)
+	}
+
+	// 通知主进程认证成功，启动数据上报和心跳
+	if s.Callbacks.OnAuthSuccess != nil {
+		s.Callbacks.OnAuthSuccess()
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package reporter
+
+import (
+	"agent/internal/logger"
+	"errors"
+	"io"
+	"testing"
+)
+
+func newTestSession() *Session {
+	return &Session{Logger: logger.NewConsoleLogger(io.Discard)}
+}
+
+func TestDispatchMessageHandler(t *testing.T) {
+	calls := 0
+	RegisterMessageHandler("test_message", func(s *Session, env *Envelope) error {
+		calls++
+		var data struct {
+			A int `json:"a"`
+		}
+		if err := env.DecodeData(&data); err != nil || data.A != 1 {
+			t.Errorf("data = %+v, err = %v", data, err)
+		}
+		return nil
+	})
+
+	s := newTestSession()
+	s.dispatch([]byte(`{"type":"test_message","data":{"a":1}}`))
+	if calls != 1 {
+		t.Fatalf("处理函数调用 %d 次, want 1", calls)
+	}
+
+	// 未注册的类型和无法解析的消息只记录，不会调用任何处理函数
+	s.dispatch([]byte(`{"type":"no_such_type"}`))
+	s.dispatch([]byte(`{`))
+	if calls != 1 {
+		t.Fatalf("处理函数调用 %d 次, want 1", calls)
+	}
+}
+
+func TestDispatchCommandHandler(t *testing.T) {
+	var got []string
+	RegisterCommandHandler("test_command", func(s *Session, env *Envelope) error {
+		got = append(got, env.CommandID)
+		return nil
+	})
+
+	s := newTestSession()
+	s.dispatch([]byte(`{"type":"command","command":"test_command","command_id":"c1"}`))
+	// 面板对命令的响应（带 status）不再分发给命令处理函数
+	s.dispatch([]byte(`{"type":"command","command":"test_command","command_id":"c2","status":"success"}`))
+	s.dispatch([]byte(`{"type":"command","command":"no_such_command","command_id":"c3"}`))
+
+	if len(got) != 1 || got[0] != "c1" {
+		t.Fatalf("已处理的命令 = %v, want [c1]", got)
+	}
+}
+
+func TestDispatchRecordsHandlerError(t *testing.T) {
+	RegisterMessageHandler("test_failing", func(s *Session, env *Envelope) error {
+		return errors.New("处理失败")
+	})
+	newTestSession().dispatch([]byte(`{"type":"test_failing"}`))
+	errs := RecentProtocolErrors()
+	if len(errs) == 0 || errs[len(errs)-1].Type != "test_failing" {
+		t.Fatalf("处理函数返回的错误应记录为协议错误, got %+v", errs)
+	}
+}
+
+func TestEnvelopeDecodeDataMissing(t *testing.T) {
+	for _, data := range []string{"", "null"} {
+		env := &Envelope{Type: "command", Data: []byte(data)}
+		var v map[string]interface{}
+		if err := env.DecodeData(&v); err == nil {
+			t.Errorf("data=%q 应返回错误", data)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package reporter
+
+import (
+	"agent/config"
+	"agent/internal/crypto"
+	"agent/internal/logger"
+	"agent/internal/websocket"
+	"crypto/ecdh"
+	"encoding/base64"
+	"fmt"
+)
+
+// prepareX25519Handshake 生成本次握手的临时 X25519 密钥对，返回 Base64 编码的公钥
+// 临时密钥保存在会话的密钥状态中，协商完成后立即丢弃
+func (k *keyState) prepareX25519Handshake(logger *logger.Logger) string {
+	privateKey, publicKey, err := crypto.GenerateX25519KeyPair()
+	if err != nil {
+		logger.Warn("生成 X25519 临时密钥失败，仅使用 RSA 握手: %v", err)
+		return ""
+	}
+
+	k.mu.Lock()
+	k.handshakePrivate = privateKey
+	k.handshakePublicKey = publicKey
+	k.mu.Unlock()
+
+	return base64.StdEncoding.EncodeToString(publicKey)
+}
+
+// takeX25519Handshake 取出并清除进行中的握手临时密钥，每个临时密钥只能使用一次
+func (k *keyState) takeX25519Handshake() (*ecdh.PrivateKey, []byte) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	privateKey, publicKey := k.handshakePrivate, k.handshakePublicKey
+	k.handshakePrivate = nil
+	k.handshakePublicKey = nil
+	return privateKey, publicKey
+}
+
+// handshakeTranscript 面板需要签名的握手记录：
+// Agent 临时公钥 || 面板临时公钥 || 握手方案 || 0x00 || 防重放标志（'1'/'0'）
+// 方案和防重放标志一并签名，中间人无法在首次协商时关闭防重放或把方案降级为 RSA
+func handshakeTranscript(agentPublicKey, panelPublicKey []byte, scheme string, replayProtection bool) []byte {
+	transcript := make([]byte, 0, len(agentPublicKey)+len(panelPublicKey)+len(scheme)+2)
+	transcript = append(transcript, agentPublicKey...)
+	transcript = append(transcript, panelPublicKey...)
+	transcript = append(transcript, scheme...)
+	transcript = append(transcript, 0)
+	if replayProtection {
+		return append(transcript, '1')
+	}
+	return append(transcript, '0')
+}
+
+// verifyHandshakeSignature 使用已固定的面板 RSA 公钥校验面板对握手记录的签名
+// 签名按消息中实际携带的方案和防重放标志计算，任一字段被篡改都会导致校验失败
+func verifyHandshakeSignature(cfg config.Config, transcript []byte, signatureBase64 string) error {
+	if cfg.PanelPublicKey == "" || cfg.PanelFingerprint == "" {
+		return fmt.Errorf("尚未完成密钥交换，无法验证面板握手签名")
+	}
+	fingerprint, err := crypto.GetPublicKeyFingerprint([]byte(cfg.PanelPublicKey))
+	if err != nil {
+		return fmt.Errorf("计算面板公钥指纹失败: %w", err)
+	}
+	if fingerprint != cfg.PanelFingerprint {
+		return fmt.Errorf("面板公钥与已固定的指纹不一致")
+	}
+
+	if signatureBase64 == "" {
+		return fmt.Errorf("缺少面板握手签名")
+	}
+	signature, err := base64.StdEncoding.DecodeString(signatureBase64)
+	if err != nil {
+		return fmt.Errorf("面板握手签名 Base64 解码失败: %w", err)
+	}
+
+	ok, err := crypto.VerifySignature(transcript, signature, []byte(cfg.PanelPublicKey))
+	if err != nil {
+		return fmt.Errorf("验证面板握手签名失败: %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("面板握手签名无效，可能存在中间人攻击")
+	}
+	return nil
+}
+
+// checkSchemeDowngrade Agent 发起了 X25519 握手而面板选择了其他方案时，要求面板对该选择签名
+// 不支持 X25519 的旧面板需要配置 legacy_handshake=true，Agent 将不再发起 X25519 握手
+func checkSchemeDowngrade(data *SessionKeyPayload, store *config.Store, keys *keyState) error {
+	_, agentPublicKey := keys.takeX25519Handshake()
+	if agentPublicKey == nil {
+		return nil
+	}
+	transcript := handshakeTranscript(agentPublicKey, nil, data.Scheme, data.ReplayProtection)
+	if err := verifyHandshakeSignature(store.Get(), transcript, data.HandshakeSignature); err != nil {
+		return fmt.Errorf("已发起 X25519 握手，拒绝未经签名的握手方案 %q（面板不支持 X25519 时请设置 legacy_handshake=true）: %w", data.Scheme, err)
+	}
+	return nil
+}
+
+// applyReplayProtection 根据面板在会话密钥消息中的确认启用防重放
+// 已启用时面板不能在轮换密钥时关闭，否则拒绝本次会话密钥
+func applyReplayProtection(data *SessionKeyPayload, client *websocket.Client, logger *logger.Logger) error {
+	enabled := data.ReplayProtection
+	wasEnabled := client.IsReplayProtectionEnabled()
+	if err := client.SetReplayProtection(enabled); err != nil {
+		return err
+	}
+	if enabled && !wasEnabled {
+		logger.Info("面板已确认防重放，加密帧将携带序号")
+	}
+	return nil
+}
+
+// handleX25519SessionKey 使用面板的 X25519 公钥协商会话密钥并启用 ChaCha20-Poly1305 加密
+func handleX25519SessionKey(data *SessionKeyPayload, client *websocket.Client, store *config.Store, keys *keyState, logger *logger.Logger) error {
+	panelPublicKeyBase64 := data.PanelX25519PublicKey
+	if panelPublicKeyBase64 == "" {
+		return fmt.Errorf("缺少面板 X25519 公钥")
+	}
+	panelPublicKey, err := base64.StdEncoding.DecodeString(panelPublicKeyBase64)
+	if err != nil {
+		return fmt.Errorf("Base64解码失败: %w", err)
+	}
+
+	privateKey, agentPublicKey := keys.takeX25519Handshake()
+	if privateKey == nil {
+		return fmt.Errorf("未发起 X25519 握手或临时密钥已使用")
+	}
+
+	// 派生会话密钥之前先确认面板临时公钥、方案和防重放标志确实来自已固定的面板
+	transcript := handshakeTranscript(agentPublicKey, panelPublicKey, data.Scheme, data.ReplayProtection)
+	if err := verifyHandshakeSignature(store.Get(), transcript, data.HandshakeSignature); err != nil {
+		return err
+	}
+
+	// salt = Agent 公钥 || 面板公钥，双方按相同顺序拼接
+	salt := append(append([]byte{}, agentPublicKey...), panelPublicKey...)
+	sessionKey, err := crypto.DeriveX25519SessionKey(privateKey, panelPublicKey, salt)
+	if err != nil {
+		return err
+	}
+
+	// 先确认防重放协商结果，拒绝降级时不安装新密钥
+	if err := applyReplayProtection(data, client, logger); err != nil {
+		return err
+	}
+
+	rotated := client.IsEncryptionEnabled()
+	if rotated {
+		client.RotateSessionKey(sessionKey, crypto.CipherChaCha20Poly1305, keyRotationGracePeriod)
+	} else {
+		client.EnableEncryptionWithCipher(sessionKey, crypto.CipherChaCha20Poly1305)
+	}
+	saveSessionKey(store, sessionKey, logger)
+
+	if rotated {
+		logger.Success("会话密钥已轮换（X25519 + ChaCha20-Poly1305）")
+	} else {
+		logger.Success("X25519 握手完成，ChaCha20-Poly1305 加密通信已启用")
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package reporter
+
+import (
+	"agent/config"
+	"agent/internal/crypto"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+// pinnedPanel 生成面板 RSA 密钥并返回已固定该公钥的配置
+func pinnedPanel(t *testing.T) (config.Config, []byte) {
+	t.Helper()
+	privateKey, publicKey, err := crypto.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fingerprint, err := crypto.GetPublicKeyFingerprint(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return config.Config{PanelPublicKey: string(publicKey), PanelFingerprint: fingerprint}, privateKey
+}
+
+func TestVerifyHandshakeSignature(t *testing.T) {
+	cfg, panelPrivateKey := pinnedPanel(t)
+	agentPub := []byte(strings.Repeat("a", 32))
+	panelPub := []byte(strings.Repeat("p", 32))
+
+	signed := handshakeTranscript(agentPub, panelPub, crypto.HandshakeX25519, true)
+	signature, err := crypto.SignData(signed, panelPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := base64.StdEncoding.EncodeToString(signature)
+
+	if err := verifyHandshakeSignature(cfg, signed, sig); err != nil {
+		t.Fatalf("合法签名校验失败: %v", err)
+	}
+
+	// 中间人关闭防重放或改写方案后，签名不再匹配
+	if err := verifyHandshakeSignature(cfg, handshakeTranscript(agentPub, panelPub, crypto.HandshakeX25519, false), sig); err == nil {
+		t.Fatal("篡改防重放标志后应校验失败")
+	}
+	if err := verifyHandshakeSignature(cfg, handshakeTranscript(agentPub, panelPub, "rsa", true), sig); err == nil {
+		t.Fatal("篡改握手方案后应校验失败")
+	}
+	if err := verifyHandshakeSignature(cfg, signed, ""); err == nil {
+		t.Fatal("缺少签名时应校验失败")
+	}
+
+	// 固定的指纹与公钥不一致时不信任该公钥
+	tampered := cfg
+	tampered.PanelFingerprint = "00"
+	if err := verifyHandshakeSignature(tampered, signed, sig); err == nil {
+		t.Fatal("指纹不一致时应校验失败")
+	}
+	if err := verifyHandshakeSignature(config.Config{}, signed, sig); err == nil {
+		t.Fatal("未固定面板公钥时应校验失败")
+	}
+}
+
+func TestCheckSchemeDowngrade(t *testing.T) {
+	cfg, panelPrivateKey := pinnedPanel(t)
+	store := config.NewStore(cfg, "unused")
+
+	// 未发起 X25519 握手时（legacy_handshake）接受 RSA 方案
+	keys := &keyState{}
+	if err := checkSchemeDowngrade(&SessionKeyPayload{}, store, keys); err != nil {
+		t.Fatalf("未发起 X25519 握手时不应拒绝: %v", err)
+	}
+
+	agentPub := keys.prepareX25519Handshake(nil)
+	if agentPub == "" {
+		t.Fatal("生成临时密钥失败")
+	}
+	if err := checkSchemeDowngrade(&SessionKeyPayload{Scheme: "rsa"}, store, keys); err == nil {
+		t.Fatal("发起 X25519 握手后应拒绝未签名的 RSA 方案")
+	}
+
+	agentPub = keys.prepareX25519Handshake(nil)
+	raw, _ := base64.StdEncoding.DecodeString(agentPub)
+	signature, err := crypto.SignData(handshakeTranscript(raw, nil, "rsa", true), panelPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := &SessionKeyPayload{Scheme: "rsa", ReplayProtection: true, HandshakeSignature: base64.StdEncoding.EncodeToString(signature)}
+	if err := checkSchemeDowngrade(payload, store, keys); err != nil {
+		t.Fatalf("面板签名的 RSA 方案应被接受: %v", err)
+	}
+}
@@ -0,0 +1,272 @@
+package reporter
+
+import (
+	"agent/config"
+	"agent/internal/logger"
+	"agent/internal/websocket"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// maxConcurrentJobs 同时运行的任务数上限，超出的任务排队等待
+const maxConcurrentJobs = 2
+
+// 任务状态
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+)
+
+// JobRunner 执行一种任务，通过 job.Progress 上报进度，返回值作为最终结果
+type JobRunner func(ctx context.Context, job *Job) (interface{}, error)
+
+var (
+	jobRunnersMu sync.RWMutex
+	jobRunners   = make(map[string]JobRunner)
+)
+
+// RegisterJobRunner 注册任务类型
+func RegisterJobRunner(kind string, runner JobRunner) {
+	jobRunnersMu.Lock()
+	defer jobRunnersMu.Unlock()
+	jobRunners[kind] = runner
+}
+
+// Job 面板下发的一个异步任务
+type Job struct {
+	ID     string
+	Kind   string
+	Params json.RawMessage
+
+	client *websocket.Client
+	config *config.Store
+	logger *logger.Logger
+	cancel context.CancelFunc
+}
+
+// DecodeParams 将任务参数解析到 v，无参数时保持默认值
+func (j *Job) DecodeParams(v interface{}) error {
+	if len(j.Params) == 0 || string(j.Params) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(j.Params, v); err != nil {
+		return fmt.Errorf("任务参数格式错误: %w", err)
+	}
+	return nil
+}
+
+// Progress 上报任务进度，percent 为 -1 表示无法估计
+func (j *Job) Progress(percent int, message string, data interface{}) {
+	j.send(JobRunning, percent, message, data)
+}
+
+func (j *Job) send(status string, percent int, message string, data interface{}) {
+	payload := map[string]interface{}{
+		"job_id":   j.ID,
+		"kind":     j.Kind,
+		"status":   status,
+		"progress": percent,
+		"message":  message,
+	}
+	if data != nil {
+		payload["data"] = data
+	}
+	if err := j.client.SendMessage(websocket.Message{Type: "job_progress", Data: payload}); err != nil {
+		j.logger.Debug("发送任务进度失败: job=%s error=%v", j.ID, err)
+	}
+}
+
+// JobManager 管理任务的排队、并发、取消和结果上报
+type JobManager struct {
+	client *websocket.Client
+	config *config.Store
+	logger *logger.Logger
+
+	slots chan struct{}
+
+	mu   sync.Mutex
+	jobs map[string]*Job
+}
+
+// NewJobManager 创建任务管理器
+func NewJobManager(client *websocket.Client, cfg *config.Store, logger *logger.Logger) *JobManager {
+	return &JobManager{
+		client: client,
+		config: cfg,
+		logger: logger,
+		slots:  make(chan struct{}, maxConcurrentJobs),
+		jobs:   make(map[string]*Job),
+	}
+}
+
+// Submit 提交任务，立即返回；任务在后台排队执行
+func (m *JobManager) Submit(id, kind string, params json.RawMessage) error {
+	if id == "" {
+		return errors.New("缺少 job_id")
+	}
+	jobRunnersMu.RLock()
+	runner, ok := jobRunners[kind]
+	jobRunnersMu.RUnlock()
+	if !ok {
+		return fmt.Errorf("不支持的任务类型: %s", kind)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &Job{
+		ID:     id,
+		Kind:   kind,
+		Params: params,
+		client: m.client,
+		config: m.config,
+		logger: m.logger,
+		cancel: cancel,
+	}
+
+	m.mu.Lock()
+	if _, exists := m.jobs[id]; exists {
+		m.mu.Unlock()
+		cancel()
+		return fmt.Errorf("任务已存在: %s", id)
+	}
+	m.jobs[id] = job
+	m.mu.Unlock()
+
+	job.send(JobQueued, 0, "已排队", nil)
+	go m.run(ctx, job, runner)
+	return nil
+}
+
+// Cancel 取消排队中或运行中的任务
+func (m *JobManager) Cancel(id string) error {
+	m.mu.Lock()
+	job, ok := m.jobs[id]
+	m.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("任务不存在或已结束: %s", id)
+	}
+	job.cancel()
+	return nil
+}
+
+// CancelAll 取消所有任务（Reporter 退出时调用）
+func (m *JobManager) CancelAll() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, job := range m.jobs {
+		job.cancel()
+	}
+}
+
+func (m *JobManager) run(ctx context.Context, job *Job, runner JobRunner) {
+	defer func() {
+		m.mu.Lock()
+		delete(m.jobs, job.ID)
+		m.mu.Unlock()
+		job.cancel()
+	}()
+
+	// 等待并发槽位，排队期间可被取消
+	select {
+	case m.slots <- struct{}{}:
+		defer func() { <-m.slots }()
+	case <-ctx.Done():
+		m.finish(job, JobCancelled, nil, ctx.Err(), 0)
+		return
+	}
+
+	m.logger.Info("开始执行任务: id=%s kind=%s", job.ID, job.Kind)
+	job.send(JobRunning, 0, "开始执行", nil)
+	start := time.Now()
+
+	var result interface{}
+	var err error
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("任务异常: %v", r)
+			}
+		}()
+		result, err = runner(ctx, job)
+	}()
+
+	status := JobSucceeded
+	if ctx.Err() != nil {
+		status = JobCancelled
+	} else if err != nil {
+		status = JobFailed
+	}
+	m.finish(job, status, result, err, time.Since(start))
+}
+
+// finish 上报任务最终状态
+func (m *JobManager) finish(job *Job, status string, result interface{}, err error, elapsed time.Duration) {
+	payload := map[string]interface{}{
+		"job_id":      job.ID,
+		"kind":        job.Kind,
+		"status":      status,
+		"duration_ms": elapsed.Milliseconds(),
+	}
+	if result != nil {
+		payload["result"] = result
+	}
+	if err != nil {
+		payload["error"] = err.Error()
+	}
+	if sendErr := m.client.SendMessage(websocket.Message{Type: "job_result", Data: payload}); sendErr != nil {
+		m.logger.Warn("发送任务结果失败: job=%s error=%v", job.ID, sendErr)
+	}
+	m.logger.Info("任务结束: id=%s kind=%s status=%s", job.ID, job.Kind, status)
+}
+
+// JobDispatchPayload job_dispatch 消息的数据
+type JobDispatchPayload struct {
+	JobID  string          `json:"job_id"`
+	Kind   string          `json:"kind"`
+	Params json.RawMessage `json:"params"`
+}
+
+// JobCancelPayload job_cancel 消息的数据
+type JobCancelPayload struct {
+	JobID string `json:"job_id"`
+}
+
+func init() {
+	RegisterMessageHandler("job_dispatch", func(s *Session, env *Envelope) error {
+		if env.IsResponse() {
+			return nil
+		}
+		var payload JobDispatchPayload
+		if err := env.DecodeData(&payload); err != nil {
+			return err
+		}
+		if err := s.jobs.Submit(payload.JobID, payload.Kind, payload.Params); err != nil {
+			return s.Client.SendMessage(websocket.Message{
+				Type: "job_result",
+				Data: map[string]interface{}{
+					"job_id": payload.JobID,
+					"kind":   payload.Kind,
+					"status": JobFailed,
+					"error":  err.Error(),
+				},
+			})
+		}
+		return nil
+	})
+	RegisterMessageHandler("job_cancel", func(s *Session, env *Envelope) error {
+		if env.IsResponse() {
+			return nil
+		}
+		var payload JobCancelPayload
+		if err := env.DecodeData(&payload); err != nil {
+			return err
+		}
+		return s.jobs.Cancel(payload.JobID)
+	})
+}
@@ -0,0 +1,233 @@
+package reporter
+
+import (
+	"agent/config"
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 内置任务参数限制
+const (
+	speedtestDefaultDuration = 10 * time.Second
+	speedtestMaxDuration     = 30 * time.Second
+	speedtestMaxBytes        = 200 * 1024 * 1024
+	tailLogDefaultDuration   = 60 * time.Second
+	tailLogMaxDuration       = 5 * time.Minute
+	tailLogMaxLineBytes      = 4096
+)
+
+// speedtestClient 测速使用的 HTTP 客户端，不跟随跳转到其他主机，避免绕过测速地址限制
+var speedtestClient = &http.Client{
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return fmt.Errorf("跳转次数过多")
+		}
+		if req.URL.Host != via[0].URL.Host {
+			return fmt.Errorf("测速地址不允许跳转到其他主机: %s", req.URL.Host)
+		}
+		return nil
+	},
+}
+
+func init() {
+	RegisterJobRunner("speedtest", runSpeedtestJob)
+	RegisterJobRunner("tail_log", runTailLogJob)
+}
+
+// runSpeedtestJob 下载测速：从面板测速接口下载数据并计算吞吐量
+// 其他 URL 需要显式开启 speedtest_custom_url 能力，避免面板借 Agent 访问内网地址
+func runSpeedtestJob(ctx context.Context, job *Job) (interface{}, error) {
+	var params struct {
+		URL             string `json:"url"`
+		DurationSeconds int    `json:"duration_seconds"`
+	}
+	if err := job.DecodeParams(&params); err != nil {
+		return nil, err
+	}
+	cfg := job.config.Get()
+	endpoint, err := agentAPIEndpoint(cfg.Server, "/api/agent/speedtest")
+	if err != nil {
+		return nil, err
+	}
+	if params.URL == "" {
+		params.URL = endpoint
+	} else if params.URL != endpoint {
+		if err := checkCustomSpeedtestURL(&cfg, params.URL); err != nil {
+			job.logger.Audit("拒绝 speedtest: job_id=%s url=%s 原因=%v", job.ID, params.URL, err)
+			return nil, err
+		}
+		job.logger.Audit("speedtest 使用自定义 URL: job_id=%s url=%s", job.ID, params.URL)
+	}
+	duration := time.Duration(params.DurationSeconds) * time.Second
+	if duration <= 0 {
+		duration = speedtestDefaultDuration
+	}
+	if duration > speedtestMaxDuration {
+		duration = speedtestMaxDuration
+	}
+
+	downloadCtx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(downloadCtx, http.MethodGet, params.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	resp, err := speedtestClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("测速请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("测速请求失败: HTTP %d", resp.StatusCode)
+	}
+	firstByte := time.Since(start)
+
+	var total int64
+	buf := make([]byte, 64*1024)
+	lastReport := time.Now()
+	for total < speedtestMaxBytes {
+		n, readErr := resp.Body.Read(buf)
+		total += int64(n)
+		if time.Since(lastReport) >= time.Second {
+			lastReport = time.Now()
+			percent := int(time.Since(start) * 100 / duration)
+			job.Progress(percent, "测速中", map[string]interface{}{
+				"bytes": total,
+				"mbps":  mbps(total, time.Since(start)),
+			})
+		}
+		if readErr != nil {
+			// 读完或到达测速时长视为正常结束，任务被取消时返回错误
+			if errors.Is(readErr, io.EOF) || (downloadCtx.Err() != nil && ctx.Err() == nil) {
+				break
+			}
+			return nil, readErr
+		}
+	}
+
+	elapsed := time.Since(start)
+	return map[string]interface{}{
+		"url":           params.URL,
+		"bytes":         total,
+		"seconds":       round2(elapsed.Seconds()),
+		"mbps":          mbps(total, elapsed),
+		"first_byte_ms": firstByte.Milliseconds(),
+	}, nil
+}
+
+// checkCustomSpeedtestURL 校验面板测速接口以外的测速 URL
+func checkCustomSpeedtestURL(cfg *config.Config, rawURL string) error {
+	if !cfg.HasCapability(config.CapabilitySpeedtestCustomURL) {
+		return fmt.Errorf("Agent 未开启自定义测速地址能力（capabilities 需包含 %s）", config.CapabilitySpeedtestCustomURL)
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("测速 URL 无效: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("测速 URL 仅支持 http/https: %s", rawURL)
+	}
+	return nil
+}
+
+func mbps(bytes int64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return round2(float64(bytes) * 8 / elapsed.Seconds() / 1e6)
+}
+
+func round2(v float64) float64 {
+	return float64(int64(v*100+0.5)) / 100
+}
+
+// runTailLogJob 持续读取日志文件新增内容并以进度消息推送，默认 60 秒
+// 仅允许读取 Agent 日志目录内的文件
+func runTailLogJob(ctx context.Context, job *Job) (interface{}, error) {
+	var params struct {
+		File            string `json:"file"`
+		DurationSeconds int    `json:"duration_seconds"`
+	}
+	if err := job.DecodeParams(&params); err != nil {
+		return nil, err
+	}
+
+	logDir, err := filepath.Abs(job.config.Get().LogPath)
+	if err != nil {
+		return nil, err
+	}
+	name := params.File
+	if name == "" {
+		name = time.Now().Format("2006-01-02") + ".txt"
+	}
+	path := filepath.Join(logDir, filepath.Base(name))
+
+	duration := time.Duration(params.DurationSeconds) * time.Second
+	if duration <= 0 {
+		duration = tailLogDefaultDuration
+	}
+	if duration > tailLogMaxDuration {
+		duration = tailLogMaxDuration
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	if _, err := file.Seek(0, io.SeekEnd); err != nil {
+		return nil, err
+	}
+
+	deadline := time.NewTimer(duration)
+	defer deadline.Stop()
+
+	reader := bufio.NewReader(file)
+	pending := ""
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	start := time.Now()
+	totalLines := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-deadline.C:
+			return map[string]interface{}{"file": filepath.Base(path), "lines": totalLines}, nil
+		case <-ticker.C:
+		}
+
+		var lines []string
+		for {
+			chunk, err := reader.ReadString('\n')
+			pending += chunk
+			if err != nil {
+				// 未读到完整行，保留到下次读取
+				break
+			}
+			line := strings.TrimRight(pending, "\r\n")
+			pending = ""
+			if len(line) > tailLogMaxLineBytes {
+				line = line[:tailLogMaxLineBytes]
+			}
+			lines = append(lines, line)
+		}
+		if len(lines) > 0 {
+			totalLines += len(lines)
+			percent := int(time.Since(start) * 100 / duration)
+			job.Progress(percent, "日志输出", map[string]interface{}{"lines": lines})
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package reporter
+
+import (
+	"agent/config"
+	"agent/internal/crypto"
+	"agent/internal/logger"
+	"agent/internal/websocket"
+	"crypto/ecdh"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 密钥轮换参数
+const (
+	keyRotationGracePeriod   = 2 * time.Minute // 旧密钥在轮换后仍可用于解密的时间
+	keyRotationCheckInterval = time.Minute
+)
+
+// keyState 单个 Reporter 会话的密钥状态，随会话创建，不在多个客户端之间共享
+// 更换 Agent 密钥对与解密会话密钥都在 mu 内进行，解密时读到的当前私钥与旧私钥始终一致
+type keyState struct {
+	mu sync.Mutex
+
+	// 轮换后短暂保留的旧私钥，用于解密面板在轮换前发出的会话密钥
+	previousPrivateKey       string
+	previousPrivateKeyExpiry time.Time
+
+	// 进行中的 X25519 握手使用的临时密钥
+	handshakePrivate   *ecdh.PrivateKey
+	handshakePublicKey []byte
+}
+
+// ensureAgentKeypair 配置中没有 Agent 密钥对时生成并保存，返回当前公钥
+func (k *keyState) ensureAgentKeypair(store *config.Store, logger *logger.Logger) (string, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if publicKey := store.Get().AgentPublicKey; publicKey != "" {
+		return publicKey, nil
+	}
+	privateKeyBytes, publicKeyBytes, err := crypto.GenerateKeyPair()
+	if err != nil {
+		return "", fmt.Errorf("生成Agent密钥对失败: %w", err)
+	}
+	store.Update(func(c *config.Config) {
+		c.AgentPrivateKey = string(privateKeyBytes)
+		c.AgentPublicKey = string(publicKeyBytes)
+	})
+	if err := store.SaveSecrets(); err != nil {
+		logger.Warn("保存Agent密钥对失败: %v", err)
+	}
+	return string(publicKeyBytes), nil
+}
+
+// rotateAgentKeypair 重新生成 Agent RSA 密钥对并通知面板
+// 面板收到 key_rotate 后会使用新公钥下发新的会话密钥
+func rotateAgentKeypair(client *websocket.Client, store *config.Store, keys *keyState, logger *logger.Logger) error {
+	privateKeyBytes, publicKeyBytes, err := crypto.GenerateKeyPair()
+	if err != nil {
+		return fmt.Errorf("生成Agent密钥对失败: %w", err)
+	}
+	fingerprint, err := crypto.GetPublicKeyFingerprint(publicKeyBytes)
+	if err != nil {
+		return fmt.Errorf("计算Agent公钥指纹失败: %w", err)
+	}
+
+	keys.mu.Lock()
+	store.Update(func(c *config.Config) {
+		keys.previousPrivateKey = c.AgentPrivateKey
+		keys.previousPrivateKeyExpiry = time.Now().Add(keyRotationGracePeriod)
+		c.AgentPrivateKey = string(privateKeyBytes)
+		c.AgentPublicKey = string(publicKeyBytes)
+	})
+	// 写入 Agent 实际使用的配置文件对应的密钥存储
+	if err := store.SaveSecrets(); err != nil {
+		logger.Warn("保存轮换后的Agent密钥对失败: %v", err)
+	}
+	keys.mu.Unlock()
+
+	message := websocket.Message{
+		Type: "key_rotate",
+		Data: map[string]interface{}{
+			"agent_public_key":  string(publicKeyBytes),
+			"agent_fingerprint": fingerprint,
+		},
+	}
+	if err := client.SendMessage(message); err != nil {
+		return fmt.Errorf("发送密钥轮换消息失败: %w", err)
+	}
+
+	logger.Info("Agent密钥对已轮换，等待面板下发新的会话密钥")
+	return nil
+}
+
+// requestSessionKey 请求面板下发新的会话密钥
+// 当前使用 X25519 握手时附带新的临时公钥，面板据此重新协商
+func requestSessionKey(client *websocket.Client, keys *keyState, logger *logger.Logger) error {
+	data := map[string]interface{}{
+		"reason": "rotation",
+	}
+	if client.Cipher() == crypto.CipherChaCha20Poly1305 {
+		if x25519PublicKey := keys.prepareX25519Handshake(logger); x25519PublicKey != "" {
+			data["scheme"] = crypto.HandshakeX25519
+			data["agent_x25519_public_key"] = x25519PublicKey
+		}
+	}
+	return client.SendMessage(websocket.Message{
+		Type: "session_key_request",
+		Data: data,
+	})
+}
+
+// decryptSessionKey 使用当前私钥解密会话密钥，失败时在宽限期内尝试轮换前的私钥
+func decryptSessionKey(encryptedSessionKey []byte, store *config.Store, keys *keyState) ([]byte, error) {
+	keys.mu.Lock()
+	privateKey := store.Get().AgentPrivateKey
+	previous := keys.previousPrivateKey
+	valid := previous != "" && time.Now().Before(keys.previousPrivateKeyExpiry)
+	keys.mu.Unlock()
+
+	if privateKey == "" {
+		return nil, fmt.Errorf("缺少Agent私钥，无法解密会话密钥")
+	}
+	sessionKey, err := crypto.DecryptWithPrivateKey(encryptedSessionKey, []byte(privateKey))
+	if err == nil {
+		return sessionKey, nil
+	}
+
+	if valid {
+		if sessionKey, prevErr := crypto.DecryptWithPrivateKey(encryptedSessionKey, []byte(previous)); prevErr == nil {
+			return sessionKey, nil
+		}
+	}
+	return nil, err
+}
+
+// runKeyRotation 按配置的间隔定期轮换密钥对和会话密钥
+func runKeyRotation(client *websocket.Client, store *config.Store, keys *keyState, logger *logger.Logger) {
+	ticker := time.NewTicker(keyRotationCheckInterval)
+	defer ticker.Stop()
+
+	lastKeypair := time.Now()
+	lastSession := time.Now()
+
+	for {
+		select {
+		case <-client.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if !client.IsEncryptionEnabled() {
+			continue
+		}
+
+		cfg := store.Get()
+		if interval := time.Duration(cfg.KeypairRotation) * time.Second; interval > 0 && time.Since(lastKeypair) >= interval {
+			if err := rotateAgentKeypair(client, store, keys, logger); err != nil {
+				logger.Warn("轮换Agent密钥对失败: %v", err)
+				continue
+			}
+			// 密钥对轮换时面板会同时下发新的会话密钥
+			lastKeypair = time.Now()
+			lastSession = time.Now()
+			continue
+		}
+
+		if interval := time.Duration(cfg.SessionKeyRotation) * time.Second; interval > 0 && time.Since(lastSession) >= interval {
+			if err := requestSessionKey(client, keys, logger); err != nil {
+				logger.Warn("请求新的会话密钥失败: %v", err)
+				continue
+			}
+			logger.Info("已请求面板轮换会话密钥")
+			lastSession = time.Now()
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package reporter
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// 面板各版本下发的字段类型并不统一（数值可能以字符串发送），
+// 以下类型逐字段宽松解析：类型不符的字段视为未提供，不影响同一消息中的其他字段
+
+// looseNumber 接受 JSON 数字或数字字符串，无法解析时为 0
+type looseNumber float64
+
+func (n *looseNumber) UnmarshalJSON(data []byte) error {
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil
+	}
+	switch v := raw.(type) {
+	case float64:
+		*n = looseNumber(v)
+	case string:
+		if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			*n = looseNumber(f)
+		}
+	}
+	return nil
+}
+
+// looseString 仅接受 JSON 字符串，Valid 表示字段存在且类型正确
+type looseString struct {
+	Value string
+	Valid bool
+}
+
+func (s *looseString) UnmarshalJSON(data []byte) error {
+	var v string
+	if err := json.Unmarshal(data, &v); err == nil {
+		s.Value = v
+		s.Valid = true
+	}
+	return nil
+}
+
+// looseStringList 接受字符串数组，忽略其中的非字符串元素；不是数组时 Valid 为 false
+type looseStringList struct {
+	Values []string
+	Valid  bool
+}
+
+func (l *looseStringList) UnmarshalJSON(data []byte) error {
+	var items []interface{}
+	if err := json.Unmarshal(data, &items); err != nil || items == nil {
+		return nil
+	}
+	l.Values = make([]string, 0, len(items))
+	for _, item := range items {
+		if str, ok := item.(string); ok {
+			l.Values = append(l.Values, str)
+		}
+	}
+	l.Valid = true
+	return nil
+}
@@ -0,0 +1,44 @@
+package reporter
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestUpdateConfigPayloadLooseDecode(t *testing.T) {
+	decode := func(raw string) UpdateConfigPayload {
+		t.Helper()
+		var p UpdateConfigPayload
+		if err := json.Unmarshal([]byte(raw), &p); err != nil {
+			t.Fatalf("解析 %s 失败: %v", raw, err)
+		}
+		return p
+	}
+
+	p := decode(`{"metrics_interval":30,"detail_interval":"60"}`)
+	if p.MetricsInterval != 30 || p.DetailInterval != 60 {
+		t.Errorf("数值字段: got %+v", p)
+	}
+
+	// 类型不符的字段被忽略，不影响其他字段
+	p = decode(`{"metrics_interval":"abc","system_interval":[1],"display_name":5,"timezone":"Asia/Shanghai"}`)
+	want := UpdateConfigPayload{Timezone: looseString{Value: "Asia/Shanghai", Valid: true}}
+	if !reflect.DeepEqual(p, want) {
+		t.Errorf("类型不符: got %+v, want %+v", p, want)
+	}
+
+	// 空字符串表示显式清空，与未下发区分
+	p = decode(`{"display_name":""}`)
+	if !p.DisplayName.Valid || p.DisplayName.Value != "" {
+		t.Errorf("清空展示名称: got %+v", p.DisplayName)
+	}
+
+	p = decode(`{"monitored_services":["nginx",3,"mysql"]}`)
+	if !p.MonitoredServices.Valid || !reflect.DeepEqual(p.MonitoredServices.Values, []string{"nginx", "mysql"}) {
+		t.Errorf("服务列表: got %+v", p.MonitoredServices)
+	}
+	if p = decode(`{"monitored_services":"nginx"}`); p.MonitoredServices.Valid {
+		t.Errorf("非数组的服务列表应忽略: got %+v", p.MonitoredServices)
+	}
+}
@@ -0,0 +1,280 @@
+package reporter
+
+import (
+	"agent/config"
+	"agent/internal/logger"
+	"agent/internal/websocket"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// 抓包限制
+const (
+	pcapDefaultDuration = 30 * time.Second
+	pcapMaxDuration     = 5 * time.Minute
+	pcapDefaultMaxBytes = 10 * 1024 * 1024
+	pcapMaxBytes        = 50 * 1024 * 1024
+	pcapMaxFilterLength = 512
+)
+
+// pcapInterfacePattern 网卡名称白名单，防止参数注入
+var pcapInterfacePattern = regexp.MustCompile(`^[A-Za-z0-9._:@-]{1,64}$`)
+
+// pcapRequest 抓包参数
+type pcapRequest struct {
+	Interface  string
+	Filter     string
+	Duration   time.Duration
+	MaxBytes   int64
+	MaxPackets int
+}
+
+// pcapResult 抓包结果
+type pcapResult struct {
+	Data      []byte
+	Packets   int
+	Truncated bool
+}
+
+// parsePcapRequest 解析并校验抓包参数
+func parsePcapRequest(data map[string]interface{}) (pcapRequest, error) {
+	req := pcapRequest{
+		Interface: "any",
+		Duration:  pcapDefaultDuration,
+		MaxBytes:  pcapDefaultMaxBytes,
+	}
+
+	if iface, ok := data["interface"].(string); ok && strings.TrimSpace(iface) != "" {
+		req.Interface = strings.TrimSpace(iface)
+	}
+	if !pcapInterfacePattern.MatchString(req.Interface) || strings.HasPrefix(req.Interface, "-") {
+		return req, fmt.Errorf("网卡名称不合法: %s", req.Interface)
+	}
+
+	if filter, ok := data["filter"].(string); ok {
+		req.Filter = strings.TrimSpace(filter)
+	}
+	if len(req.Filter) > pcapMaxFilterLength {
+		return req, fmt.Errorf("过滤表达式过长（最大 %d 字符）", pcapMaxFilterLength)
+	}
+	if strings.HasPrefix(req.Filter, "-") {
+		return req, fmt.Errorf("过滤表达式不合法")
+	}
+
+	if duration, ok := data["duration"].(float64); ok && duration > 0 {
+		req.Duration = time.Duration(duration) * time.Second
+	}
+	if req.Duration > pcapMaxDuration {
+		req.Duration = pcapMaxDuration
+	}
+
+	if maxBytes, ok := data["max_bytes"].(float64); ok && maxBytes > 0 {
+		req.MaxBytes = int64(maxBytes)
+	}
+	if req.MaxBytes > pcapMaxBytes {
+		req.MaxBytes = pcapMaxBytes
+	}
+
+	if maxPackets, ok := data["max_packets"].(float64); ok && maxPackets > 0 {
+		req.MaxPackets = int(maxPackets)
+	}
+
+	return req, nil
+}
+
+// checkPcapPrivilege 检查抓包所需的工具和权限
+func checkPcapPrivilege() (string, error) {
+	if runtime.GOOS == "windows" {
+		return "", errors.New("当前平台不支持抓包")
+	}
+	tcpdump, err := exec.LookPath("tcpdump")
+	if err != nil {
+		return "", errors.New("未找到 tcpdump，请先安装")
+	}
+	if os.Geteuid() != 0 {
+		return "", errors.New("抓包需要 root 权限")
+	}
+	return tcpdump, nil
+}
+
+// capturePcap 调用 tcpdump 抓包，超过时长或大小上限时停止
+func capturePcap(tcpdump string, req pcapRequest) (*pcapResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), req.Duration)
+	defer cancel()
+
+	args := []string{"-i", req.Interface, "-n", "-U", "-w", "-"}
+	if req.MaxPackets > 0 {
+		args = append(args, "-c", fmt.Sprintf("%d", req.MaxPackets))
+	}
+	if req.Filter != "" {
+		args = append(args, req.Filter)
+	}
+
+	cmd := exec.CommandContext(ctx, tcpdump, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("启动 tcpdump 失败: %w", err)
+	}
+
+	var buf bytes.Buffer
+	packets, truncated, copyErr := copyPcapRecords(&buf, stdout, req.MaxBytes)
+	// 达到大小上限后提前结束 tcpdump
+	cancel()
+	waitErr := cmd.Wait()
+
+	if buf.Len() == 0 {
+		if copyErr != nil {
+			return nil, copyErr
+		}
+		if waitErr != nil {
+			msg := strings.TrimSpace(stderr.String())
+			if len(msg) > 512 {
+				msg = msg[:512]
+			}
+			return nil, fmt.Errorf("tcpdump 执行失败: %v %s", waitErr, msg)
+		}
+	}
+
+	return &pcapResult{Data: buf.Bytes(), Packets: packets, Truncated: truncated}, nil
+}
+
+// copyPcapRecords 按完整数据包复制 pcap 流，保证输出不超过 maxBytes 且不截断数据包
+func copyPcapRecords(dst *bytes.Buffer, src io.Reader, maxBytes int64) (int, bool, error) {
+	header := make([]byte, 24)
+	if _, err := io.ReadFull(src, header); err != nil {
+		if err == io.EOF {
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("读取 pcap 文件头失败: %w", err)
+	}
+
+	var order binary.ByteOrder
+	switch binary.LittleEndian.Uint32(header[:4]) {
+	case 0xa1b2c3d4, 0xa1b23c4d:
+		order = binary.LittleEndian
+	case 0xd4c3b2a1, 0x4d3cb2a1:
+		order = binary.BigEndian
+	default:
+		return 0, false, errors.New("无法识别的 pcap 格式")
+	}
+	dst.Write(header)
+
+	packets := 0
+	record := make([]byte, 16)
+	for {
+		if _, err := io.ReadFull(src, record); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return packets, false, nil
+			}
+			return packets, false, err
+		}
+		inclLen := int64(order.Uint32(record[8:12]))
+		if int64(dst.Len())+16+inclLen > maxBytes {
+			return packets, true, nil
+		}
+
+		payload := make([]byte, inclLen)
+		if _, err := io.ReadFull(src, payload); err != nil {
+			// 不完整的数据包直接丢弃
+			return packets, false, nil
+		}
+		dst.Write(record)
+		dst.Write(payload)
+		packets++
+	}
+}
+
+// pcapRunning 是否有抓包任务正在执行
+var pcapRunning atomic.Bool
+
+// handlePcapCapture 处理面板下发的 pcap_capture 命令
+func handlePcapCapture(client *websocket.Client, store *config.Store, commandID string, data map[string]interface{}, logger *logger.Logger) {
+	sendResponse := func(status, message string, extra map[string]interface{}) {
+		payload := map[string]interface{}{
+			"command":    "pcap_capture",
+			"command_id": commandID,
+			"status":     status,
+			"message":    message,
+		}
+		for k, v := range extra {
+			payload[k] = v
+		}
+		if err := client.SendMessage(websocket.Message{Type: "command_response", Data: payload}); err != nil {
+			logger.Error("发送抓包响应失败: %v", err)
+		}
+	}
+
+	cfg := store.Get()
+	if !cfg.HasCapability(config.CapabilityPcapCapture) {
+		logger.Audit("拒绝 pcap_capture: command_id=%s 原因=未开启 %s 能力", commandID, config.CapabilityPcapCapture)
+		sendResponse("error", "Agent 未开启抓包能力（capabilities 需包含 pcap_capture）", nil)
+		return
+	}
+
+	req, err := parsePcapRequest(data)
+	if err != nil {
+		logger.Audit("拒绝 pcap_capture: command_id=%s 原因=%v", commandID, err)
+		sendResponse("error", err.Error(), nil)
+		return
+	}
+
+	// 同一时间只允许一个抓包任务，避免并发抓包占满磁盘和带宽
+	if !pcapRunning.CompareAndSwap(false, true) {
+		logger.Audit("拒绝 pcap_capture: command_id=%s 原因=已有抓包任务在执行", commandID)
+		sendResponse("error", "已有抓包任务在执行，请稍后再试", nil)
+		return
+	}
+	defer pcapRunning.Store(false)
+
+	tcpdump, err := checkPcapPrivilege()
+	if err != nil {
+		logger.Audit("拒绝 pcap_capture: command_id=%s 原因=%v", commandID, err)
+		sendResponse("error", err.Error(), nil)
+		return
+	}
+
+	logger.Audit("开始 pcap_capture: command_id=%s interface=%s filter=%q duration=%s max_bytes=%d max_packets=%d",
+		commandID, req.Interface, req.Filter, req.Duration, req.MaxBytes, req.MaxPackets)
+	sendResponse("running", "开始抓包...", nil)
+
+	result, err := capturePcap(tcpdump, req)
+	if err != nil {
+		logger.Audit("pcap_capture 失败: command_id=%s error=%v", commandID, err)
+		sendResponse("error", fmt.Sprintf("抓包失败: %v", err), nil)
+		return
+	}
+
+	transferID := newTransferID()
+	name := fmt.Sprintf("capture-%s-%s.pcap", req.Interface, time.Now().Format("20060102-150405"))
+	if err := sendFileChunks(client, transferID, name, "pcap", result.Data); err != nil {
+		logger.Audit("pcap_capture 上传失败: command_id=%s error=%v", commandID, err)
+		sendResponse("error", fmt.Sprintf("上传抓包文件失败: %v", err), nil)
+		return
+	}
+
+	logger.Audit("完成 pcap_capture: command_id=%s transfer_id=%s packets=%d bytes=%d truncated=%t",
+		commandID, transferID, result.Packets, len(result.Data), result.Truncated)
+	sendResponse("success", "抓包完成", map[string]interface{}{
+		"transfer_id": transferID,
+		"file_name":   name,
+		"packets":     result.Packets,
+		"bytes":       len(result.Data),
+		"truncated":   result.Truncated,
+	})
+}
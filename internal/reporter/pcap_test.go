@@ -0,0 +1,128 @@
+package reporter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParsePcapRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    map[string]interface{}
+		want    pcapRequest
+		wantErr bool
+	}{
+		{
+			name: "默认参数",
+			data: map[string]interface{}{},
+			want: pcapRequest{Interface: "any", Duration: pcapDefaultDuration, MaxBytes: pcapDefaultMaxBytes},
+		},
+		{
+			name: "指定参数",
+			data: map[string]interface{}{
+				"interface":   " eth0 ",
+				"filter":      " tcp port 443 ",
+				"duration":    float64(10),
+				"max_bytes":   float64(2048),
+				"max_packets": float64(100),
+			},
+			want: pcapRequest{Interface: "eth0", Filter: "tcp port 443", Duration: 10 * time.Second, MaxBytes: 2048, MaxPackets: 100},
+		},
+		{
+			name: "超出上限时截断",
+			data: map[string]interface{}{"duration": float64(3600), "max_bytes": float64(1 << 40)},
+			want: pcapRequest{Interface: "any", Duration: pcapMaxDuration, MaxBytes: pcapMaxBytes},
+		},
+		{
+			name: "类型不符的字段使用默认值",
+			data: map[string]interface{}{"duration": "10", "max_packets": -1},
+			want: pcapRequest{Interface: "any", Duration: pcapDefaultDuration, MaxBytes: pcapDefaultMaxBytes},
+		},
+		{name: "网卡名称注入", data: map[string]interface{}{"interface": "eth0; rm -rf /"}, wantErr: true},
+		{name: "网卡名称以横线开头", data: map[string]interface{}{"interface": "-w/tmp/x"}, wantErr: true},
+		{name: "过滤表达式以横线开头", data: map[string]interface{}{"filter": "-z cmd"}, wantErr: true},
+		{name: "过滤表达式过长", data: map[string]interface{}{"filter": strings.Repeat("a", pcapMaxFilterLength+1)}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePcapRequest(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Fatalf("parsePcapRequest() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// buildPcap 按指定字节序生成 pcap 流，每个数据包长度由 sizes 指定
+func buildPcap(order binary.ByteOrder, sizes ...int) []byte {
+	var buf bytes.Buffer
+	header := make([]byte, 24)
+	order.PutUint32(header[0:4], 0xa1b2c3d4)
+	buf.Write(header)
+	for _, size := range sizes {
+		record := make([]byte, 16)
+		order.PutUint32(record[8:12], uint32(size))
+		order.PutUint32(record[12:16], uint32(size))
+		buf.Write(record)
+		buf.Write(bytes.Repeat([]byte{0xab}, size))
+	}
+	return buf.Bytes()
+}
+
+func TestCopyPcapRecordsByteOrder(t *testing.T) {
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		input := buildPcap(order, 10, 20)
+		var dst bytes.Buffer
+		packets, truncated, err := copyPcapRecords(&dst, bytes.NewReader(input), 1024)
+		if err != nil {
+			t.Fatalf("%v: %v", order, err)
+		}
+		if packets != 2 || truncated || !bytes.Equal(dst.Bytes(), input) {
+			t.Fatalf("%v: packets=%d truncated=%v len=%d", order, packets, truncated, dst.Len())
+		}
+	}
+}
+
+func TestCopyPcapRecordsStopsAtPacketBoundary(t *testing.T) {
+	input := buildPcap(binary.LittleEndian, 10, 20)
+	onePacket := 24 + 16 + 10
+
+	// 上限不足以容纳第二个完整数据包时停止，输出中不包含半个数据包
+	var dst bytes.Buffer
+	packets, truncated, err := copyPcapRecords(&dst, bytes.NewReader(input), int64(onePacket+16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if packets != 1 || !truncated || dst.Len() != onePacket {
+		t.Fatalf("packets=%d truncated=%v len=%d", packets, truncated, dst.Len())
+	}
+
+	// tcpdump 被中断时最后一个数据包可能不完整，直接丢弃
+	dst.Reset()
+	packets, truncated, err = copyPcapRecords(&dst, bytes.NewReader(input[:onePacket+16+5]), 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if packets != 1 || truncated || dst.Len() != onePacket {
+		t.Fatalf("packets=%d truncated=%v len=%d", packets, truncated, dst.Len())
+	}
+}
+
+func TestCopyPcapRecordsInvalidHeader(t *testing.T) {
+	var dst bytes.Buffer
+	if packets, _, err := copyPcapRecords(&dst, bytes.NewReader(nil), 1024); err != nil || packets != 0 {
+		t.Fatalf("空输入: packets=%d err=%v", packets, err)
+	}
+	if _, _, err := copyPcapRecords(&dst, bytes.NewReader(make([]byte, 24)), 1024); err == nil {
+		t.Fatal("无法识别的格式应返回错误")
+	}
+	if _, _, err := copyPcapRecords(&dst, bytes.NewReader([]byte{0xd4, 0xc3}), 1024); err == nil {
+		t.Fatal("文件头不完整应返回错误")
+	}
+}
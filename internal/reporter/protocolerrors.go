@@ -0,0 +1,44 @@
+package reporter
+
+import (
+	"sync"
+	"time"
+)
+
+// protocolErrorCapacity 保留的最近协议错误条数
+const protocolErrorCapacity = 50
+
+// ProtocolError 一条协议处理错误（消息解析失败、处理失败或面板返回的失败响应）
+type ProtocolError struct {
+	Time  time.Time `json:"time"`
+	Type  string    `json:"type"`
+	Error string    `json:"error"`
+}
+
+var (
+	protocolErrorsMu sync.Mutex
+	protocolErrors   []ProtocolError
+)
+
+// recordProtocolError 记录一条协议错误，超出容量时丢弃最旧的记录
+func recordProtocolError(msgType, message string) {
+	protocolErrorsMu.Lock()
+	defer protocolErrorsMu.Unlock()
+	protocolErrors = append(protocolErrors, ProtocolError{
+		Time:  time.Now(),
+		Type:  msgType,
+		Error: message,
+	})
+	if len(protocolErrors) > protocolErrorCapacity {
+		protocolErrors = protocolErrors[len(protocolErrors)-protocolErrorCapacity:]
+	}
+}
+
+// RecentProtocolErrors 返回最近的协议错误（按时间先后）
+func RecentProtocolErrors() []ProtocolError {
+	protocolErrorsMu.Lock()
+	defer protocolErrorsMu.Unlock()
+	result := make([]ProtocolError, len(protocolErrors))
+	copy(result, protocolErrors)
+	return result
+}
@@ -3,6 +3,7 @@ package reporter
 import (
 	"agent/config"
 	"agent/internal/crypto"
+	"agent/internal/fault"
 	"agent/internal/logger"
 	"agent/internal/websocket"
 	"context"
@@ -54,7 +55,9 @@ type pulledAgentTask struct {
 }
 
 // sendAuthMessage 发送认证消息
-func sendAuthMessage(client *websocket.Client, cfg *config.Config, logger *logger.Logger) {
+func sendAuthMessage(client *websocket.Client, store *config.Store, keys *keyState, logger *logger.Logger) {
+	cfg := store.Get()
+
 	// 验证key是否存在
 	if cfg.Key == "" {
 		logger.Error("agent key为空，无法发送认证消息")
@@ -62,27 +65,10 @@ func sendAuthMessage(client *websocket.Client, cfg *config.Config, logger *logge
 	}
 
 	// 生成或加载 Agent 密钥对
-	var agentPublicKey string
-	if cfg.AgentPublicKey != "" {
-		// 使用已有的公钥
-		agentPublicKey = cfg.AgentPublicKey
-	} else {
-		// 生成新的密钥对
-		privateKeyBytes, publicKeyBytes, err := crypto.GenerateKeyPair()
-		if err != nil {
-			logger.Error("生成Agent密钥对失败: %v", err)
-			// 密钥生成失败不影响认证，继续使用明文通信
-		} else {
-			cfg.AgentPrivateKey = string(privateKeyBytes)
-			cfg.AgentPublicKey = string(publicKeyBytes)
-			agentPublicKey = cfg.AgentPublicKey
-
-			// 保存配置
-			configPath := config.GetConfigPath()
-			if err := config.SaveConfig(*cfg, configPath); err != nil {
-				logger.Warn("保存Agent密钥对失败: %v", err)
-			}
-		}
+	agentPublicKey, err := keys.ensureAgentKeypair(store, logger)
+	if err != nil {
+		// 密钥生成失败不影响认证，继续使用明文通信
+		logger.Error("%v", err)
 	}
 
 	authData := map[string]interface{}{
@@ -90,11 +76,28 @@ func sendAuthMessage(client *websocket.Client, cfg *config.Config, logger *logge
 		"key":  cfg.Key,
 	}
 
+	if cfg.DisplayName != "" {
+		authData["display_name"] = cfg.DisplayName
+	}
+	if len(cfg.Tags) > 0 {
+		authData["tags"] = cfg.Tags
+	}
+
 	// 如果生成了公钥，添加到认证数据中
 	if agentPublicKey != "" {
 		authData["agent_public_key"] = agentPublicKey
 	}
 
+	// 声明支持的加密能力，旧面板会忽略该字段继续使用 RSA+AES
+	capabilities := []string{websocket.ReplayProtectionCapability}
+	if !cfg.LegacyHandshake {
+		if x25519PublicKey := keys.prepareX25519Handshake(logger); x25519PublicKey != "" {
+			capabilities = append(capabilities, crypto.HandshakeX25519)
+			authData["agent_x25519_public_key"] = x25519PublicKey
+		}
+	}
+	authData["capabilities"] = capabilities
+
 	authMessage := websocket.Message{
 		Type: "auth",
 		Data: authData,
@@ -112,19 +115,26 @@ func sendAuthMessage(client *websocket.Client, cfg *config.Config, logger *logge
 
 // ReporterCallbacks 定义回调函数接口
 type ReporterCallbacks struct {
-	OnAuthSuccess func() // 认证成功时调用
-	OnDisconnect  func() // 断开连接时调用
-	OnReload      func() // 重载配置时调用
+	OnAuthSuccess func()       // 认证成功时调用
+	OnDisconnect  func()       // 断开连接时调用
+	OnReload      func() error // 重载配置时调用，返回错误表示新配置未生效
 }
 
 // StartReporter 启动消息处理循环，只负责消息读取和认证
-func StartReporter(client *websocket.Client, logger *logger.Logger, cfg config.Config, callbacks ReporterCallbacks) {
-	// 使用指针以便修改配置
-	cfgPtr := &cfg
-	taskPollStarted := false
+// store 与 Agent 共享，配置重载后处理函数读取到的即为最新配置
+func StartReporter(client *websocket.Client, logger *logger.Logger, store *config.Store, callbacks ReporterCallbacks) {
+	session := &Session{
+		Client:    client,
+		Config:    store,
+		Logger:    logger,
+		Callbacks: callbacks,
+		keys:      &keyState{},
+	}
+	session.jobs = NewJobManager(client, store, logger)
+	defer session.jobs.CancelAll()
 
 	// 连接成功后立即发送认证消息
-	sendAuthMessage(client, cfgPtr, logger)
+	sendAuthMessage(client, store, session.keys, logger)
 
 	// 消息读取循环
 	for {
@@ -156,15 +166,17 @@ func StartReporter(client *websocket.Client, logger *logger.Logger, cfg config.C
 			}
 			conn = client.GetConnection()
 			// 重连成功后立即发送认证消息
-			sendAuthMessage(client, cfgPtr, logger)
+			sendAuthMessage(client, store, session.keys, logger)
 			// 通知断开连接，让主进程重启子进程
 			if callbacks.OnDisconnect != nil {
 				callbacks.OnDisconnect()
 			}
 		}
 
-		// 设置读取超时，防止阻塞
-		conn.SetReadDeadline(time.Now().Add(90 * time.Second))
+		// 设置读取超时，防止阻塞；收到 pong 时会自动延长，超时即说明连接已失效
+		conn.SetReadDeadline(time.Now().Add(client.ReadTimeout()))
+
+		fault.DelayRead()
 
 		// 读取消息（支持加密）
 		var message []byte
@@ -187,8 +199,7 @@ func StartReporter(client *websocket.Client, logger *logger.Logger, cfg config.C
 				logger.Error("读取消息时出错: %v", err)
 			}
 
-			client.IsConnected = false
-
+			// Reconnect 会在锁内将连接标记为断开
 			if err := client.Reconnect(); err != nil {
 				logger.Error("重连失败: %v", err)
 				logger.Error("已达最大重连次数，请检查网络连接或后端服务状态")
@@ -204,7 +215,7 @@ func StartReporter(client *websocket.Client, logger *logger.Logger, cfg config.C
 				continue
 			} else {
 				// 重连成功后立即发送认证消息
-				sendAuthMessage(client, cfgPtr, logger)
+				sendAuthMessage(client, store, session.keys, logger)
 				// 通知断开连接，让主进程重启子进程
 				if callbacks.OnDisconnect != nil {
 					callbacks.OnDisconnect()
@@ -213,314 +224,7 @@ func StartReporter(client *websocket.Client, logger *logger.Logger, cfg config.C
 			continue
 		}
 
-		var jsonData map[string]interface{}
-		err = json.Unmarshal(message, &jsonData)
-		if err != nil {
-			logger.Error("解析JSON数据时出错: %v", err)
-			continue
-		}
-
-		typeValue, _ := jsonData["type"].(string)
-		statusValue, statusExists := jsonData["status"].(string)
-		messageValue, messageExists := jsonData["message"].(string)
-
-		// 处理密钥交换消息
-		if typeValue == "key_exchange" && statusValue == "success" {
-			if err := handleKeyExchange(jsonData, client, cfgPtr, logger); err != nil {
-				logger.Error("密钥交换失败: %v", err)
-			}
-		}
-
-		// 处理会话密钥消息
-		if typeValue == "session_key" && statusValue == "success" {
-			if err := handleSessionKey(jsonData, client, cfgPtr, logger); err != nil {
-				logger.Error("接收会话密钥失败: %v", err)
-			}
-		}
-
-		// 处理认证成功
-		if statusExists && typeValue == "auth" && statusValue == "success" {
-			logger.Success("认证成功")
-
-			// 发送当前配置到面板
-			sendConfigToPanel(client, cfgPtr, logger)
-			if !taskPollStarted {
-				taskPollStarted = true
-				go pollAgentTasks(client, cfgPtr, logger)
-			}
-
-			// 通知主进程认证成功，启动数据上报和心跳
-			if callbacks.OnAuthSuccess != nil {
-				callbacks.OnAuthSuccess()
-			}
-		}
-
-		// 处理带状态和消息的响应
-		if statusExists && messageExists {
-			if statusValue != "success" {
-				logger.Warn("%s: %s", typeValue, messageValue)
-			}
-		} else {
-			// 处理服务器请求
-			if !statusExists {
-				switch typeValue {
-				case "command":
-					// 处理服务器命令
-					commandData, ok := jsonData["command"].(string)
-					if ok {
-						commandID, _ := jsonData["command_id"].(string)
-						if commandData == "service_check" {
-							sendCommandAck(client, commandData, commandID, logger)
-							checkData, ok := jsonData["data"].(map[string]interface{})
-							if ok {
-								go handleServiceCheck(client, checkData, logger)
-							}
-						} else if commandData == "restart" {
-							logger.Info("收到重启命令，准备重启...")
-							// 发送确认消息
-							response := websocket.Message{
-								Type: "command_response",
-								Data: map[string]interface{}{
-									"command": "restart",
-									"status":  "success",
-									"message": "正在重启...",
-								},
-							}
-							if err := client.SendMessage(response); err != nil {
-								logger.Error("发送重启确认消息失败: %v", err)
-							}
-							// 延迟一小段时间确保消息发送成功
-							time.Sleep(500 * time.Millisecond)
-							// 执行重启
-							if err := restartAgent(logger); err != nil {
-								logger.Error("重启失败: %v", err)
-								os.Exit(1)
-							}
-							os.Exit(0)
-						} else if commandData == "update_config" {
-							// 处理配置更新命令
-							updateData, ok := jsonData["data"].(map[string]interface{})
-							if !ok {
-								logger.Error("配置更新命令数据格式错误")
-								response := websocket.Message{
-									Type: "command_response",
-									Data: map[string]interface{}{
-										"command": "update_config",
-										"status":  "error",
-										"message": "配置更新命令数据格式错误",
-									},
-								}
-								client.SendMessage(response)
-								continue
-							}
-
-							// 更新配置
-							configUpdated := false
-							if timezone, ok := updateData["timezone"].(string); ok && timezone != "" {
-								cfgPtr.Timezone = timezone
-								configUpdated = true
-							}
-							if metricsInterval, ok := updateData["metrics_interval"].(float64); ok && metricsInterval > 0 {
-								cfgPtr.MetricsInterval = int(metricsInterval)
-								configUpdated = true
-							}
-							if detailInterval, ok := updateData["detail_interval"].(float64); ok && detailInterval > 0 {
-								cfgPtr.DetailInterval = int(detailInterval)
-								configUpdated = true
-							}
-							if systemInterval, ok := updateData["system_interval"].(float64); ok && systemInterval > 0 {
-								cfgPtr.SystemInterval = int(systemInterval)
-								configUpdated = true
-							}
-							if heartbeatInterval, ok := updateData["heartbeat_interval"].(float64); ok && heartbeatInterval > 0 {
-								cfgPtr.HeartbeatInterval = int(heartbeatInterval)
-								configUpdated = true
-							}
-							if logPath, ok := updateData["log_path"].(string); ok && logPath != "" {
-								cfgPtr.LogPath = logPath
-								configUpdated = true
-							}
-
-							if monitoredServices, ok := updateData["monitored_services"].([]interface{}); ok {
-								var services []string
-								for _, s := range monitoredServices {
-									if str, ok := s.(string); ok {
-										services = append(services, str)
-									}
-								}
-								cfgPtr.MonitoredServices = services
-								configUpdated = true
-							}
-
-							if configUpdated {
-								// 保存配置到文件
-								configPath := config.GetConfigPath()
-								if err := config.SaveConfig(*cfgPtr, configPath); err != nil {
-									logger.Error("保存配置失败: %v", err)
-									response := websocket.Message{
-										Type: "command_response",
-										Data: map[string]interface{}{
-											"command": "update_config",
-											"status":  "error",
-											"message": fmt.Sprintf("保存配置失败: %v", err),
-										},
-									}
-									client.SendMessage(response)
-									continue
-								}
-
-								logger.Info("配置已更新并保存")
-
-								// 检查是否需要重启（server或key变化需要重启）
-								needRestart := false
-								if server, ok := updateData["server"].(string); ok && server != "" && server != cfgPtr.Server {
-									needRestart = true
-									logger.Info("检测到服务器地址变化，需要重启")
-								}
-								if key, ok := updateData["key"].(string); ok && key != "" && key != cfgPtr.Key {
-									needRestart = true
-									logger.Info("检测到密钥变化，需要重启")
-								}
-
-								if needRestart {
-									// 需要重启，发送重启响应
-									response := websocket.Message{
-										Type: "command_response",
-										Data: map[string]interface{}{
-											"command": "update_config",
-											"status":  "success",
-											"message": "配置已更新，正在重启...",
-										},
-									}
-									if err := client.SendMessage(response); err != nil {
-										logger.Error("发送配置更新确认消息失败: %v", err)
-									}
-									// 延迟一小段时间确保消息发送成功
-									time.Sleep(500 * time.Millisecond)
-									// 执行重启
-									if err := restartAgent(logger); err != nil {
-										logger.Error("重启失败: %v", err)
-										os.Exit(1)
-									}
-									os.Exit(0)
-								} else {
-									// 不需要重启，调用重载回调
-									if callbacks.OnReload != nil {
-										callbacks.OnReload()
-									}
-
-									response := websocket.Message{
-										Type: "command_response",
-										Data: map[string]interface{}{
-											"command": "update_config",
-											"status":  "success",
-											"message": "配置已更新并重载",
-										},
-									}
-									if err := client.SendMessage(response); err != nil {
-										logger.Error("发送配置更新确认消息失败: %v", err)
-									}
-								}
-							} else {
-								logger.Warn("配置更新命令未包含有效配置项")
-								response := websocket.Message{
-									Type: "command_response",
-									Data: map[string]interface{}{
-										"command": "update_config",
-										"status":  "success",
-										"message": "未检测到配置变更",
-									},
-								}
-								client.SendMessage(response)
-							}
-						} else if commandData == "update" {
-							// 处理更新命令
-							updateData, ok := jsonData["data"].(map[string]interface{})
-							if !ok {
-								logger.Error("更新命令数据格式错误")
-								response := websocket.Message{
-									Type: "command_response",
-									Data: map[string]interface{}{
-										"command": "update",
-										"status":  "error",
-										"message": "更新命令数据格式错误",
-									},
-								}
-								client.SendMessage(response)
-								continue
-							}
-
-							version, _ := updateData["version"].(string)
-							versionType, _ := updateData["version_type"].(string)
-
-							if version == "" {
-								logger.Error("更新命令缺少版本号")
-								response := websocket.Message{
-									Type: "command_response",
-									Data: map[string]interface{}{
-										"command": "update",
-										"status":  "error",
-										"message": "更新命令缺少版本号",
-									},
-								}
-								client.SendMessage(response)
-								continue
-							}
-
-							logger.Info("收到更新命令，版本: %s, 类型: %s", version, versionType)
-
-							// 发送确认消息
-							response := websocket.Message{
-								Type: "command_response",
-								Data: map[string]interface{}{
-									"command": "update",
-									"status":  "success",
-									"message": "开始更新...",
-								},
-							}
-							if err := client.SendMessage(response); err != nil {
-								logger.Error("发送更新确认消息失败: %v", err)
-							}
-
-							go func() {
-								updateService := NewUpdateService(logger)
-								if err := updateService.UpdateAgent(version, versionType); err != nil {
-									logger.Error("更新失败: %v", err)
-									// 发送错误响应
-									errorResponse := websocket.Message{
-										Type: "command_response",
-										Data: map[string]interface{}{
-											"command": "update",
-											"status":  "error",
-											"message": fmt.Sprintf("更新失败: %v", err),
-										},
-									}
-									client.SendMessage(errorResponse)
-								} else {
-									// 发送成功响应
-									successResponse := websocket.Message{
-										Type: "command_response",
-										Data: map[string]interface{}{
-											"command": "update",
-											"status":  "success",
-											"message": "更新完成，正在重启...",
-										},
-									}
-									client.SendMessage(successResponse)
-								}
-							}()
-						} else {
-							logger.Warn("未知的命令: %s", commandData)
-						}
-					}
-				case "auth":
-					// 服务器要求认证
-					sendAuthMessage(client, cfgPtr, logger)
-				default:
-					logger.Warn("未知的消息类型: %v", typeValue)
-				}
-			}
-		}
+		session.dispatch(message)
 	}
 }
 
@@ -540,7 +244,7 @@ func sendCommandAck(client *websocket.Client, command, commandID string, logger
 	}
 }
 
-func pollAgentTasks(client *websocket.Client, cfg *config.Config, logger *logger.Logger) {
+func pollAgentTasks(client *websocket.Client, store *config.Store, logger *logger.Logger) {
 	ticker := time.NewTicker(5 * time.Second)
 	defer ticker.Stop()
 
@@ -548,14 +252,14 @@ func pollAgentTasks(client *websocket.Client, cfg *config.Config, logger *logger
 		if client.IsStopped() {
 			return
 		}
-		if err := pullAndHandleAgentTasks(cfg, logger); err != nil {
+		if err := pullAndHandleAgentTasks(store.Get(), logger); err != nil {
 			logger.Warn("拉取 Agent 任务失败: %v", err)
 		}
 		<-ticker.C
 	}
 }
 
-func pullAndHandleAgentTasks(cfg *config.Config, logger *logger.Logger) error {
+func pullAndHandleAgentTasks(cfg config.Config, logger *logger.Logger) error {
 	endpoint, err := agentAPIEndpoint(cfg.Server, "/api/agent/tasks/pull")
 	if err != nil {
 		return err
@@ -669,31 +373,41 @@ func agentAPIEndpoint(server, apiPath string) (string, error) {
 	return u.String(), nil
 }
 
+// KeyExchangePayload key_exchange 消息的数据
+type KeyExchangePayload struct {
+	PanelPublicKey   string `json:"panel_public_key"`
+	PanelFingerprint string `json:"panel_fingerprint"`
+}
+
+// SessionKeyPayload session_key 消息的数据
+type SessionKeyPayload struct {
+	Scheme               string `json:"scheme"`
+	EncryptedSessionKey  string `json:"encrypted_session_key"`
+	PanelX25519PublicKey string `json:"panel_x25519_public_key"`
+	HandshakeSignature   string `json:"handshake_signature"` // 面板 RSA 私钥对握手记录的签名（Base64），见 handshakeTranscript
+	ReplayProtection     bool   `json:"replay_protection"`
+}
+
 // handleKeyExchange 处理密钥交换消息
-func handleKeyExchange(jsonData map[string]interface{}, client *websocket.Client, cfg *config.Config, logger *logger.Logger) error {
-	data, ok := jsonData["data"].(map[string]interface{})
-	if !ok {
+func handleKeyExchange(env *Envelope, client *websocket.Client, store *config.Store, logger *logger.Logger) error {
+	var data KeyExchangePayload
+	if err := env.DecodeData(&data); err != nil {
 		return fmt.Errorf("密钥交换数据格式错误")
 	}
 
-	panelPublicKey, ok := data["panel_public_key"].(string)
-	if !ok || panelPublicKey == "" {
+	panelPublicKey := data.PanelPublicKey
+	if panelPublicKey == "" {
 		return fmt.Errorf("缺少面板公钥")
 	}
 
-	panelFingerprint, ok := data["panel_fingerprint"].(string)
-	if !ok || panelFingerprint == "" {
+	panelFingerprint := data.PanelFingerprint
+	if panelFingerprint == "" {
 		return fmt.Errorf("缺少面板公钥指纹")
 	}
 
-	// 验证面板指纹
-	if cfg.PanelFingerprint != "" {
-		if cfg.PanelFingerprint != panelFingerprint {
-			return fmt.Errorf("面板公钥指纹不匹配，可能存在中间人攻击")
-		}
-	} else {
-		// 首次连接，保存指纹
-		cfg.PanelFingerprint = panelFingerprint
+	// 验证面板指纹（首次连接时记录）
+	if pinned := store.Get().PanelFingerprint; pinned != "" && pinned != panelFingerprint {
+		return fmt.Errorf("面板公钥指纹不匹配，可能存在中间人攻击")
 	}
 
 	// 计算接收到的面板公钥指纹并验证
@@ -706,12 +420,12 @@ func handleKeyExchange(jsonData map[string]interface{}, client *websocket.Client
 		return fmt.Errorf("面板公钥指纹验证失败")
 	}
 
-	// 保存面板公钥
-	cfg.PanelPublicKey = panelPublicKey
-
-	// 保存配置
-	configPath := config.GetConfigPath()
-	if err := config.SaveConfig(*cfg, configPath); err != nil {
+	// 保存面板公钥和指纹
+	store.Update(func(c *config.Config) {
+		c.PanelFingerprint = panelFingerprint
+		c.PanelPublicKey = panelPublicKey
+	})
+	if err := store.SaveSecrets(); err != nil {
 		logger.Warn("保存面板公钥失败: %v", err)
 	}
 
@@ -720,19 +434,33 @@ func handleKeyExchange(jsonData map[string]interface{}, client *websocket.Client
 	return nil
 }
 
+// panelConfigView 返回面板可见的配置项
+func panelConfigView(cfg *config.Config) map[string]interface{} {
+	disabledCollectors := cfg.DisabledCollectors
+	if disabledCollectors == nil {
+		disabledCollectors = []string{}
+	}
+	return map[string]interface{}{
+		"timezone":            cfg.Timezone,
+		"metrics_interval":    cfg.MetricsInterval,
+		"detail_interval":     cfg.DetailInterval,
+		"system_interval":     cfg.SystemInterval,
+		"heartbeat_interval":  cfg.HeartbeatInterval,
+		"package_interval":    cfg.PackageInterval,
+		"log_path":            cfg.LogPath,
+		"log_level":           cfg.LogLevel,
+		"display_name":        cfg.DisplayName,
+		"monitored_services":  cfg.MonitoredServices,
+		"disabled_collectors": disabledCollectors,
+	}
+}
+
 // sendConfigToPanel 发送当前配置到面板
-func sendConfigToPanel(client *websocket.Client, cfg *config.Config, logger *logger.Logger) {
+func sendConfigToPanel(client *websocket.Client, store *config.Store, logger *logger.Logger) {
+	cfg := store.Get()
 	configMessage := websocket.Message{
 		Type: "agent_config",
-		Data: map[string]interface{}{
-			"timezone":           cfg.Timezone,
-			"metrics_interval":   cfg.MetricsInterval,
-			"detail_interval":    cfg.DetailInterval,
-			"system_interval":    cfg.SystemInterval,
-			"heartbeat_interval": cfg.HeartbeatInterval,
-			"log_path":           cfg.LogPath,
-			"monitored_services": cfg.MonitoredServices,
-		},
+		Data: panelConfigView(&cfg),
 	}
 
 	if err := client.SendMessage(configMessage); err != nil {
@@ -743,19 +471,22 @@ func sendConfigToPanel(client *websocket.Client, cfg *config.Config, logger *log
 }
 
 // handleSessionKey 处理会话密钥消息
-func handleSessionKey(jsonData map[string]interface{}, client *websocket.Client, cfg *config.Config, logger *logger.Logger) error {
-	// 检查是否有Agent私钥
-	if cfg.AgentPrivateKey == "" {
-		return fmt.Errorf("缺少Agent私钥，无法解密会话密钥")
+func handleSessionKey(env *Envelope, client *websocket.Client, store *config.Store, keys *keyState, logger *logger.Logger) error {
+	var data SessionKeyPayload
+	if err := env.DecodeData(&data); err != nil {
+		return fmt.Errorf("会话密钥数据格式错误")
 	}
 
-	data, ok := jsonData["data"].(map[string]interface{})
-	if !ok {
-		return fmt.Errorf("会话密钥数据格式错误")
+	// 面板选择了 X25519 握手时，直接协商会话密钥
+	if data.Scheme == crypto.HandshakeX25519 {
+		return handleX25519SessionKey(&data, client, store, keys, logger)
+	}
+	if err := checkSchemeDowngrade(&data, store, keys); err != nil {
+		return err
 	}
 
-	encryptedSessionKeyBase64, ok := data["encrypted_session_key"].(string)
-	if !ok || encryptedSessionKeyBase64 == "" {
+	encryptedSessionKeyBase64 := data.EncryptedSessionKey
+	if encryptedSessionKeyBase64 == "" {
 		return fmt.Errorf("缺少加密的会话密钥")
 	}
 
@@ -765,28 +496,46 @@ func handleSessionKey(jsonData map[string]interface{}, client *websocket.Client,
 		return fmt.Errorf("Base64解码失败: %w", err)
 	}
 
-	// 使用Agent私钥解密会话密钥
-	sessionKey, err := crypto.DecryptWithPrivateKey(encryptedSessionKey, []byte(cfg.AgentPrivateKey))
+	// 使用Agent私钥解密会话密钥（密钥对轮换期间兼容旧私钥）
+	sessionKey, err := decryptSessionKey(encryptedSessionKey, store, keys)
 	if err != nil {
 		return fmt.Errorf("解密会话密钥失败: %w", err)
 	}
 
-	// 启用加密
-	client.EnableEncryption(sessionKey)
-	cfg.SessionKey = base64.StdEncoding.EncodeToString(sessionKey)
-	cfg.EncryptionEnabled = true
+	// 先确认防重放协商结果，拒绝降级时不安装新密钥
+	if err := applyReplayProtection(&data, client, logger); err != nil {
+		return err
+	}
 
-	// 保存配置
-	configPath := config.GetConfigPath()
-	if err := config.SaveConfig(*cfg, configPath); err != nil {
-		logger.Warn("保存会话密钥失败: %v", err)
+	// 启用加密；已启用时视为轮换，旧会话密钥在宽限期内仍可解密在途消息
+	rotated := client.IsEncryptionEnabled()
+	if rotated {
+		client.RotateSessionKey(sessionKey, crypto.CipherAESGCM, keyRotationGracePeriod)
+	} else {
+		client.EnableEncryption(sessionKey)
 	}
+	saveSessionKey(store, sessionKey, logger)
 
-	logger.Success("会话密钥接收成功，加密通信已启用")
+	if rotated {
+		logger.Success("会话密钥已轮换")
+	} else {
+		logger.Success("会话密钥接收成功，加密通信已启用")
+	}
 
 	return nil
 }
 
+// saveSessionKey 记录并保存新的会话密钥
+func saveSessionKey(store *config.Store, sessionKey []byte, logger *logger.Logger) {
+	store.Update(func(c *config.Config) {
+		c.SessionKey = base64.StdEncoding.EncodeToString(sessionKey)
+		c.EncryptionEnabled = true
+	})
+	if err := store.SaveSecrets(); err != nil {
+		logger.Warn("保存会话密钥失败: %v", err)
+	}
+}
+
 // restartAgent 重启agent程序
 func restartAgent(logger *logger.Logger) error {
 	// 获取当前可执行文件路径
@@ -0,0 +1,113 @@
+package reporter
+
+import (
+	"agent/config"
+	"agent/internal/logger"
+	"agent/internal/supportbundle"
+	"agent/internal/websocket"
+	"context"
+	"fmt"
+	"time"
+)
+
+func init() {
+	RegisterCommandHandler("support_bundle", handleSupportBundleCommand)
+	RegisterJobRunner("support_bundle", runSupportBundleJob)
+}
+
+// supportBundleOptions 返回 Agent 进程内生成诊断包的参数
+func supportBundleOptions(store *config.Store, logger *logger.Logger, logFiles int) supportbundle.Options {
+	cfg := store.Get()
+	return supportbundle.Options{
+		Config:         &cfg,
+		ConfigPath:     store.Path(),
+		Logger:         logger,
+		LogFiles:       logFiles,
+		Goroutines:     true,
+		ProtocolErrors: RecentProtocolErrors(),
+	}
+}
+
+// checkSupportBundleAllowed 诊断包包含日志等敏感内容，需要显式开启 support_bundle 能力
+// source 为请求来源（command_id 或 job_id），用于审计日志
+func checkSupportBundleAllowed(store *config.Store, logger *logger.Logger, source string) error {
+	cfg := store.Get()
+	if !cfg.HasCapability(config.CapabilitySupportBundle) {
+		logger.Audit("拒绝 support_bundle: %s 原因=未开启 %s 能力", source, config.CapabilitySupportBundle)
+		return fmt.Errorf("Agent 未开启诊断包能力（capabilities 需包含 %s）", config.CapabilitySupportBundle)
+	}
+	logger.Audit("生成 support_bundle: %s", source)
+	return nil
+}
+
+// uploadSupportBundle 生成诊断包并通过 file_chunk 上传到面板
+func uploadSupportBundle(client *websocket.Client, opts supportbundle.Options) (map[string]interface{}, error) {
+	data, err := supportbundle.Build(opts)
+	if err != nil {
+		return nil, fmt.Errorf("生成诊断包失败: %w", err)
+	}
+	transferID := newTransferID()
+	name := supportbundle.FileName(time.Now())
+	if err := sendFileChunks(client, transferID, name, "support_bundle", data); err != nil {
+		return nil, fmt.Errorf("上传诊断包失败: %w", err)
+	}
+	opts.Logger.Info("诊断包已上传: transfer_id=%s size=%d", transferID, len(data))
+	return map[string]interface{}{
+		"transfer_id": transferID,
+		"file_name":   name,
+		"size":        len(data),
+	}, nil
+}
+
+// handleSupportBundleCommand 面板请求诊断包，生成后上传并回复 command_response
+func handleSupportBundleCommand(s *Session, env *Envelope) error {
+	sendCommandAck(s.Client, env.Command, env.CommandID, s.Logger)
+	var params struct {
+		LogFiles int `json:"log_files"`
+	}
+	_ = env.DecodeData(&params)
+
+	if err := checkSupportBundleAllowed(s.Config, s.Logger, "command_id="+env.CommandID); err != nil {
+		if err := s.ReplyWithData(env.Command, "error", err.Error(), map[string]interface{}{"command_id": env.CommandID}); err != nil {
+			s.Logger.Error("发送诊断包响应失败: %v", err)
+		}
+		return nil
+	}
+
+	go func() {
+		opts := supportBundleOptions(s.Config, s.Logger, params.LogFiles)
+		result, err := uploadSupportBundle(s.Client, opts)
+		if result == nil {
+			result = make(map[string]interface{})
+		}
+		result["command_id"] = env.CommandID
+
+		status, message := "success", "诊断包已上传"
+		if err != nil {
+			s.Logger.Error("%v", err)
+			status, message = "error", err.Error()
+		}
+		if err := s.ReplyWithData(env.Command, status, message, result); err != nil {
+			s.Logger.Error("发送诊断包响应失败: %v", err)
+		}
+	}()
+	return nil
+}
+
+// runSupportBundleJob 以任务方式生成并上传诊断包
+func runSupportBundleJob(ctx context.Context, job *Job) (interface{}, error) {
+	var params struct {
+		LogFiles int `json:"log_files"`
+	}
+	if err := job.DecodeParams(&params); err != nil {
+		return nil, err
+	}
+	if err := checkSupportBundleAllowed(job.config, job.logger, "job_id="+job.ID); err != nil {
+		return nil, err
+	}
+	job.Progress(10, "生成诊断包", nil)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return uploadSupportBundle(job.client, supportBundleOptions(job.config, job.logger, params.LogFiles))
+}
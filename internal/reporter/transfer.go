@@ -0,0 +1,61 @@
+package reporter
+
+import (
+	"agent/internal/websocket"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// fileChunkSize 单个 file_chunk 消息携带的原始数据大小
+const fileChunkSize = 256 * 1024
+
+// newTransferID 生成文件传输ID
+func newTransferID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
+
+// sendFileChunks 通过 file_chunk 消息将文件分片上传到面板
+// 每个分片都携带完整文件的大小和 SHA256，面板按 index 重组后校验
+func sendFileChunks(client *websocket.Client, transferID, name, kind string, data []byte) error {
+	sum := sha256.Sum256(data)
+	checksum := hex.EncodeToString(sum[:])
+
+	total := (len(data) + fileChunkSize - 1) / fileChunkSize
+	if total == 0 {
+		total = 1
+	}
+
+	for index := 0; index < total; index++ {
+		start := index * fileChunkSize
+		end := start + fileChunkSize
+		if end > len(data) {
+			end = len(data)
+		}
+
+		message := websocket.Message{
+			Type: "file_chunk",
+			Data: map[string]interface{}{
+				"transfer_id": transferID,
+				"name":        name,
+				"kind":        kind,
+				"index":       index,
+				"total":       total,
+				"size":        len(data),
+				"sha256":      checksum,
+				"data":        base64.StdEncoding.EncodeToString(data[start:end]),
+			},
+		}
+		if err := client.SendMessage(message); err != nil {
+			return fmt.Errorf("发送文件分片 %d/%d 失败: %w", index+1, total, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package statuspage
+
+import (
+	"agent/internal/logger"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net"
+	"net/http"
+	"time"
+)
+
+//go:embed status.html
+var pageTemplate string
+
+var page = template.Must(template.New("status").Funcs(template.FuncMap{
+	"json": func(v interface{}) string {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err.Error()
+		}
+		return string(data)
+	},
+	"since": func(t time.Time) string {
+		if t.IsZero() {
+			return "-"
+		}
+		return time.Since(t).Truncate(time.Second).String() + " 前"
+	},
+}).Parse(pageTemplate))
+
+// Payload 最近一次上报的数据
+type Payload struct {
+	Type string      `json:"type"`
+	Time time.Time   `json:"time"`
+	Data interface{} `json:"data"`
+}
+
+// Snapshot 状态页展示的数据
+type Snapshot struct {
+	Connected bool                   `json:"connected"`
+	Status    interface{}            `json:"status"`
+	Config    map[string]interface{} `json:"config"`
+	Payloads  []Payload              `json:"payloads"`
+	Logs      []string               `json:"logs"`
+}
+
+// Server 本地状态页 HTTP 服务（仅用于运维人员在本机查看）
+type Server struct {
+	address  string
+	logger   *logger.Logger
+	snapshot func() Snapshot
+	server   *http.Server
+}
+
+// NewServer 创建状态页服务，snapshot 在每次请求时调用
+func NewServer(address string, logger *logger.Logger, snapshot func() Snapshot) *Server {
+	s := &Server{
+		address:  address,
+		logger:   logger,
+		snapshot: snapshot,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.handlePage)
+	mux.HandleFunc("/api/status", s.handleAPI)
+	s.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	return s
+}
+
+// Start 开始监听，非回环地址会被拒绝，避免意外暴露到公网
+func (s *Server) Start() error {
+	host, _, err := net.SplitHostPort(s.address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return errors.New("状态页只能监听回环地址（如 127.0.0.1）")
+	}
+
+	listener, err := net.Listen("tcp", s.address)
+	if err != nil {
+		return err
+	}
+	go func() {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Warn("本地状态页异常退出: %v", err)
+		}
+	}()
+	return nil
+}
+
+// Close 停止状态页服务
+func (s *Server) Close() error {
+	return s.server.Close()
+}
+
+func (s *Server) handlePage(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := page.Execute(w, s.snapshot()); err != nil {
+		s.logger.Debug("渲染本地状态页失败: %v", err)
+	}
+}
+
+func (s *Server) handleAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(s.snapshot())
+}
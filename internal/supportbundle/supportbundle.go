@@ -0,0 +1,244 @@
+// Package supportbundle 生成用于问题排查的诊断包（tar.gz）
+// 包含最近日志、脱敏配置、系统快照、goroutine 堆栈和最近的协议错误，密钥会被自动脱敏
+package supportbundle
+
+import (
+	"agent/config"
+	"agent/internal/collector"
+	"agent/internal/logger"
+	"agent/internal/version"
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 诊断包内容限制
+const (
+	DefaultLogFiles = 3
+	maxLogFileBytes = 5 * 1024 * 1024
+	snapshotTimeout = 30 * time.Second
+)
+
+// snapshotTypes 系统快照包含的采集项
+var snapshotTypes = []string{"system_info", "inventory", "metrics"}
+
+// Options 诊断包生成参数
+type Options struct {
+	Config         *config.Config
+	Logger         *logger.Logger
+	LogFiles       int         // 包含的最近日志文件数，0 使用默认值
+	Goroutines     bool        // 是否包含当前进程的 goroutine 堆栈
+	State          interface{} // 运行状态快照，可为空
+	ProtocolErrors interface{} // 最近的协议错误，可为空
+	ConfigPath     string      // 配置文件路径，用于读取密钥文件中存储的原始值以便脱敏，可为空
+}
+
+// FileName 返回诊断包的默认文件名
+func FileName(t time.Time) string {
+	return fmt.Sprintf("support-bundle-%s.tar.gz", t.Format("20060102-150405"))
+}
+
+// Build 生成诊断包，返回 tar.gz 数据
+func Build(opts Options) ([]byte, error) {
+	if opts.Config == nil {
+		return nil, fmt.Errorf("缺少配置")
+	}
+	if opts.LogFiles <= 0 {
+		opts.LogFiles = DefaultLogFiles
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	w := &writer{tw: tw, redactor: newRedactor(opts.Config.SecretValues(opts.ConfigPath)), now: time.Now()}
+
+	redacted := opts.Config.Redacted()
+	w.addJSON("config.json", redacted)
+	w.addJSON("runtime.json", runtimeInfo())
+	w.addJSON("system.json", systemSnapshot(opts.Config, opts.Logger))
+	if opts.State != nil {
+		w.addJSON("state.json", opts.State)
+	}
+	if opts.ProtocolErrors != nil {
+		w.addJSON("protocol_errors.json", opts.ProtocolErrors)
+	}
+	if opts.Goroutines {
+		var dump bytes.Buffer
+		if err := pprof.Lookup("goroutine").WriteTo(&dump, 2); err == nil {
+			w.addText("goroutines.txt", dump.Bytes())
+		}
+	}
+
+	logFiles, err := RecentLogFiles(opts.Config.LogPath, opts.LogFiles)
+	if err != nil {
+		w.errors = append(w.errors, fmt.Sprintf("读取日志目录失败: %v", err))
+	}
+	for _, path := range logFiles {
+		data, err := readTail(path, maxLogFileBytes)
+		if err != nil {
+			w.errors = append(w.errors, fmt.Sprintf("读取日志失败: %s: %v", path, err))
+			continue
+		}
+		w.addText("logs/"+filepath.Base(path), data)
+	}
+
+	// 收集过程中的错误单独记录，不影响诊断包生成
+	if len(w.errors) > 0 {
+		w.addText("errors.txt", []byte(strings.Join(w.errors, "\n")+"\n"))
+	}
+
+	if w.err != nil {
+		return nil, w.err
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writer 向 tar 写入条目，记录第一个写入错误
+type writer struct {
+	tw       *tar.Writer
+	redactor *strings.Replacer
+	now      time.Time
+	err      error
+	errors   []string
+}
+
+func (w *writer) addJSON(name string, v interface{}) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		w.errors = append(w.errors, fmt.Sprintf("序列化 %s 失败: %v", name, err))
+		return
+	}
+	w.addText(name, append(data, '\n'))
+}
+
+// addText 写入文本条目，写入前统一脱敏
+func (w *writer) addText(name string, data []byte) {
+	if w.err != nil {
+		return
+	}
+	text := w.redactor.Replace(string(data))
+
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    int64(len(text)),
+		ModTime: w.now,
+	}
+	if err := w.tw.WriteHeader(header); err != nil {
+		w.err = err
+		return
+	}
+	if _, err := io.WriteString(w.tw, text); err != nil {
+		w.err = err
+	}
+}
+
+// newRedactor 将密钥原文替换为占位符
+func newRedactor(secrets []string) *strings.Replacer {
+	var pairs []string
+	for _, secret := range secrets {
+		// 过短的值替换后容易误伤正常内容
+		if len(secret) >= 8 {
+			pairs = append(pairs, secret, "****")
+		}
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+func runtimeInfo() map[string]interface{} {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+	hostname, _ := os.Hostname()
+	return map[string]interface{}{
+		"version":    version.AgentVersion,
+		"os":         runtime.GOOS,
+		"arch":       runtime.GOARCH,
+		"go_version": runtime.Version(),
+		"hostname":   hostname,
+		"pid":        os.Getpid(),
+		"goroutines": runtime.NumGoroutine(),
+		"heap_alloc": memStats.HeapAlloc,
+		"time":       time.Now().Format(time.RFC3339),
+	}
+}
+
+// systemSnapshot 使用独立的采集器采集一次系统信息，不影响正在运行的上报
+func systemSnapshot(cfg *config.Config, log *logger.Logger) map[string]interface{} {
+	if log == nil {
+		log = logger.NewConsoleLogger(io.Discard)
+	}
+	col := collector.NewCollector(config.InitSystem(), log, nil, *cfg)
+	defer col.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), snapshotTimeout)
+	defer cancel()
+	messages, err := col.CollectOnce(ctx, snapshotTypes)
+
+	snapshot := make(map[string]interface{}, len(messages)+1)
+	for _, message := range messages {
+		snapshot[message.Type] = message.Data
+	}
+	if err != nil {
+		snapshot["error"] = err.Error()
+	}
+	return snapshot
+}
+
+// RecentLogFiles 返回日志目录下最近的 n 个日志文件（文件名为日期，按名称倒序）
+func RecentLogFiles(logDir string, n int) ([]string, error) {
+	entries, err := os.ReadDir(logDir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".txt") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+	if len(names) > n {
+		names = names[:n]
+	}
+	paths := make([]string, 0, len(names))
+	for _, name := range names {
+		paths = append(paths, filepath.Join(logDir, name))
+	}
+	return paths, nil
+}
+
+// readTail 读取文件末尾最多 maxBytes 字节
+func readTail(path string, maxBytes int64) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > maxBytes {
+		if _, err := file.Seek(info.Size()-maxBytes, io.SeekStart); err != nil {
+			return nil, err
+		}
+	}
+	return io.ReadAll(file)
+}
@@ -126,6 +126,15 @@ func (p *program) run() {
 		p.logger.Info("Starting CloudSentinel Agent service...")
 	}
 
+	// 启动时将旧版配置中的密钥迁移到独立存储（加载配置本身不会改写磁盘）
+	if migrated, err := config.MigrateSecrets(p.cfgPath); err != nil {
+		if p.logger != nil {
+			p.logger.Warning(fmt.Sprintf("Failed to migrate secrets in %s: %v", p.cfgPath, err))
+		}
+	} else if migrated && p.logger != nil {
+		p.logger.Info("Migrated secrets to separate storage")
+	}
+
 	cfg, err := config.LoadConfigFromFile(p.cfgPath)
 	if err != nil {
 		if p.logger != nil {
@@ -141,6 +150,7 @@ func (p *program) run() {
 		}
 		return
 	}
+	a.SetConfigPath(p.cfgPath)
 	p.agent = a
 
 	if err := p.agent.Start(); err != nil {
@@ -160,7 +170,7 @@ func (p *program) Stop(s service.Service) error {
 		p.logger.Info("Stopping CloudSentinel Agent service...")
 	}
 	if p.agent != nil {
-		p.agent.Stop()
+		p.agent.StopWithReason("service stop")
 	}
 	if p.logger != nil {
 		p.logger.Info("CloudSentinel Agent stopped")
@@ -0,0 +1,261 @@
+package system
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// cloudProbeTimeout 单个元数据服务的查询超时，非云主机上元数据地址不可达，需要尽快放弃
+const cloudProbeTimeout = 2 * time.Second
+
+// CloudMetadata 云厂商实例元数据
+type CloudMetadata struct {
+	Provider     string `json:"provider"` // aws/gcp/azure/aliyun/tencent
+	Region       string `json:"region,omitempty"`
+	Zone         string `json:"zone,omitempty"`
+	InstanceType string `json:"instance_type,omitempty"`
+	InstanceID   string `json:"instance_id,omitempty"`
+}
+
+// ErrNotCloudInstance 未检测到任何云厂商的元数据服务
+var ErrNotCloudInstance = errors.New("未检测到云厂商元数据服务")
+
+// cloudEndpoints 各云厂商元数据服务地址
+type cloudEndpoints struct {
+	AWS     string
+	GCP     string
+	Azure   string
+	Aliyun  string
+	Tencent string
+}
+
+var defaultCloudEndpoints = cloudEndpoints{
+	AWS:     "http://169.254.169.254",
+	GCP:     "http://169.254.169.254",
+	Azure:   "http://169.254.169.254",
+	Aliyun:  "http://100.100.100.200",
+	Tencent: "http://metadata.tencentyun.com",
+}
+
+// cloudHTTPClient 查询元数据使用的客户端，不经过代理（元数据地址只能从实例本身访问）
+var cloudHTTPClient = &http.Client{
+	Timeout:   cloudProbeTimeout,
+	Transport: &http.Transport{Proxy: nil},
+}
+
+// GetCloudMetadataWithContext 依次探测各云厂商的元数据服务，返回第一个成功的结果
+// 不在云主机上时返回 ErrNotCloudInstance
+func (s *System) GetCloudMetadataWithContext(ctx context.Context) (*CloudMetadata, error) {
+	return detectCloudMetadata(ctx, cloudHTTPClient, defaultCloudEndpoints)
+}
+
+func detectCloudMetadata(ctx context.Context, client *http.Client, endpoints cloudEndpoints) (*CloudMetadata, error) {
+	probes := []struct {
+		name  string
+		probe func(ctx context.Context, client *http.Client, base string) (*CloudMetadata, error)
+		base  string
+	}{
+		{"aws", probeAWS, endpoints.AWS},
+		{"gcp", probeGCP, endpoints.GCP},
+		{"azure", probeAzure, endpoints.Azure},
+		{"aliyun", probeAliyun, endpoints.Aliyun},
+		{"tencent", probeTencent, endpoints.Tencent},
+	}
+
+	// 并发探测，按上面的顺序取第一个成功的结果，总耗时不超过单个探测的超时
+	type result struct {
+		metadata *CloudMetadata
+		err      error
+	}
+	results := make([]chan result, len(probes))
+	for i, p := range probes {
+		results[i] = make(chan result, 1)
+		go func(ch chan<- result, probe func(context.Context, *http.Client, string) (*CloudMetadata, error), base string) {
+			metadata, err := probe(ctx, client, base)
+			ch <- result{metadata, err}
+		}(results[i], p.probe, p.base)
+	}
+	for i := range probes {
+		r := <-results[i]
+		if r.err == nil && r.metadata != nil {
+			return r.metadata, nil
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return nil, ErrNotCloudInstance
+}
+
+// metadataGet 请求元数据接口，非 200 响应视为错误
+func metadataGet(ctx context.Context, client *http.Client, method, url string, headers map[string]string) (*http.Response, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("%s 返回状态码 %d", url, resp.StatusCode)
+	}
+	return resp, body, nil
+}
+
+// probeAWS 使用 IMDSv2 读取实例身份文档
+func probeAWS(ctx context.Context, client *http.Client, base string) (*CloudMetadata, error) {
+	_, token, err := metadataGet(ctx, client, http.MethodPut, base+"/latest/api/token",
+		map[string]string{"X-aws-ec2-metadata-token-ttl-seconds": "60"})
+	if err != nil {
+		return nil, err
+	}
+	_, body, err := metadataGet(ctx, client, http.MethodGet, base+"/latest/dynamic/instance-identity/document",
+		map[string]string{"X-aws-ec2-metadata-token": strings.TrimSpace(string(token))})
+	if err != nil {
+		return nil, err
+	}
+	var doc struct {
+		Region           string `json:"region"`
+		AvailabilityZone string `json:"availabilityZone"`
+		InstanceType     string `json:"instanceType"`
+		InstanceID       string `json:"instanceId"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+	if doc.InstanceID == "" {
+		return nil, errors.New("AWS 实例身份文档缺少 instanceId")
+	}
+	return &CloudMetadata{
+		Provider:     "aws",
+		Region:       doc.Region,
+		Zone:         doc.AvailabilityZone,
+		InstanceType: doc.InstanceType,
+		InstanceID:   doc.InstanceID,
+	}, nil
+}
+
+// probeGCP 读取 Compute Engine 实例元数据
+func probeGCP(ctx context.Context, client *http.Client, base string) (*CloudMetadata, error) {
+	resp, body, err := metadataGet(ctx, client, http.MethodGet, base+"/computeMetadata/v1/instance/?recursive=true",
+		map[string]string{"Metadata-Flavor": "Google"})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Header.Get("Metadata-Flavor") != "Google" {
+		return nil, errors.New("不是 GCP 元数据服务")
+	}
+	var doc struct {
+		ID          json.Number `json:"id"`
+		Zone        string      `json:"zone"`
+		MachineType string      `json:"machineType"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+	// zone 与 machineType 为资源路径，如 projects/123/zones/us-central1-a
+	zone := lastPathSegment(doc.Zone)
+	region := zone
+	if i := strings.LastIndex(zone, "-"); i > 0 {
+		region = zone[:i]
+	}
+	return &CloudMetadata{
+		Provider:     "gcp",
+		Region:       region,
+		Zone:         zone,
+		InstanceType: lastPathSegment(doc.MachineType),
+		InstanceID:   doc.ID.String(),
+	}, nil
+}
+
+// probeAzure 读取 Azure IMDS 的 compute 信息
+func probeAzure(ctx context.Context, client *http.Client, base string) (*CloudMetadata, error) {
+	_, body, err := metadataGet(ctx, client, http.MethodGet, base+"/metadata/instance/compute?api-version=2021-02-01",
+		map[string]string{"Metadata": "true"})
+	if err != nil {
+		return nil, err
+	}
+	var doc struct {
+		Location string `json:"location"`
+		Zone     string `json:"zone"`
+		VMSize   string `json:"vmSize"`
+		VMID     string `json:"vmId"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+	if doc.VMID == "" {
+		return nil, errors.New("Azure 元数据缺少 vmId")
+	}
+	return &CloudMetadata{
+		Provider:     "azure",
+		Region:       doc.Location,
+		Zone:         doc.Zone,
+		InstanceType: doc.VMSize,
+		InstanceID:   doc.VMID,
+	}, nil
+}
+
+// probeAliyun 读取阿里云 ECS 元数据
+func probeAliyun(ctx context.Context, client *http.Client, base string) (*CloudMetadata, error) {
+	return probePlainMetadata(ctx, client, base, "aliyun", map[string]string{
+		"instance_id":   "instance-id",
+		"region":        "region-id",
+		"zone":          "zone-id",
+		"instance_type": "instance/instance-type",
+	})
+}
+
+// probeTencent 读取腾讯云 CVM 元数据
+func probeTencent(ctx context.Context, client *http.Client, base string) (*CloudMetadata, error) {
+	return probePlainMetadata(ctx, client, base, "tencent", map[string]string{
+		"instance_id":   "instance-id",
+		"region":        "placement/region",
+		"zone":          "placement/zone",
+		"instance_type": "instance/instance-type",
+	})
+}
+
+// probePlainMetadata 逐项读取以纯文本返回的元数据（阿里云、腾讯云），instance_id 为必需项
+func probePlainMetadata(ctx context.Context, client *http.Client, base, provider string, paths map[string]string) (*CloudMetadata, error) {
+	get := func(field string) string {
+		_, body, err := metadataGet(ctx, client, http.MethodGet, base+"/latest/meta-data/"+paths[field], nil)
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(string(body))
+	}
+	instanceID := get("instance_id")
+	if instanceID == "" {
+		return nil, fmt.Errorf("未读取到 %s 实例 ID", provider)
+	}
+	return &CloudMetadata{
+		Provider:     provider,
+		Region:       get("region"),
+		Zone:         get("zone"),
+		InstanceType: get("instance_type"),
+		InstanceID:   instanceID,
+	}, nil
+}
+
+func lastPathSegment(path string) string {
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		return path[i+1:]
+	}
+	return path
+}
@@ -0,0 +1,120 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// cloudServer 模拟单个云厂商的元数据服务
+func cloudServer(t *testing.T, provider string) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	switch provider {
+	case "aws":
+		mux.HandleFunc("/latest/api/token", func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPut {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.Write([]byte("token-1"))
+		})
+		mux.HandleFunc("/latest/dynamic/instance-identity/document", func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-aws-ec2-metadata-token") != "token-1" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"region":"us-east-1","availabilityZone":"us-east-1a","instanceType":"t3.micro","instanceId":"i-0abc"}`))
+		})
+	case "gcp":
+		mux.HandleFunc("/computeMetadata/v1/instance/", func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Metadata-Flavor") != "Google" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.Header().Set("Metadata-Flavor", "Google")
+			w.Write([]byte(`{"id":1234567890123456789,"zone":"projects/1/zones/us-central1-a","machineType":"projects/1/machineTypes/e2-medium"}`))
+		})
+	case "azure":
+		mux.HandleFunc("/metadata/instance/compute", func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Metadata") != "true" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"location":"eastus","zone":"1","vmSize":"Standard_B1s","vmId":"vm-1"}`))
+		})
+	case "aliyun", "tencent":
+		values := map[string]string{
+			"/latest/meta-data/instance-id":            "ins-1",
+			"/latest/meta-data/region-id":              "cn-hangzhou",
+			"/latest/meta-data/zone-id":                "cn-hangzhou-i",
+			"/latest/meta-data/placement/region":       "ap-guangzhou",
+			"/latest/meta-data/placement/zone":         "ap-guangzhou-3",
+			"/latest/meta-data/instance/instance-type": "S5.SMALL1",
+		}
+		mux.HandleFunc("/latest/meta-data/", func(w http.ResponseWriter, r *http.Request) {
+			value, ok := values[r.URL.Path]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write([]byte(value + "\n"))
+		})
+	}
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDetectCloudMetadata(t *testing.T) {
+	tests := []struct {
+		provider string
+		want     CloudMetadata
+	}{
+		{"aws", CloudMetadata{Provider: "aws", Region: "us-east-1", Zone: "us-east-1a", InstanceType: "t3.micro", InstanceID: "i-0abc"}},
+		{"gcp", CloudMetadata{Provider: "gcp", Region: "us-central1", Zone: "us-central1-a", InstanceType: "e2-medium", InstanceID: "1234567890123456789"}},
+		{"azure", CloudMetadata{Provider: "azure", Region: "eastus", Zone: "1", InstanceType: "Standard_B1s", InstanceID: "vm-1"}},
+		{"aliyun", CloudMetadata{Provider: "aliyun", Region: "cn-hangzhou", Zone: "cn-hangzhou-i", InstanceType: "S5.SMALL1", InstanceID: "ins-1"}},
+		{"tencent", CloudMetadata{Provider: "tencent", Region: "ap-guangzhou", Zone: "ap-guangzhou-3", InstanceType: "S5.SMALL1", InstanceID: "ins-1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			// 其他厂商的地址指向不提供任何接口的服务
+			empty := httptest.NewServer(http.NotFoundHandler())
+			defer empty.Close()
+			endpoints := cloudEndpoints{AWS: empty.URL, GCP: empty.URL, Azure: empty.URL, Aliyun: empty.URL, Tencent: empty.URL}
+			url := cloudServer(t, tt.provider).URL
+			switch tt.provider {
+			case "aws":
+				endpoints.AWS = url
+			case "gcp":
+				endpoints.GCP = url
+			case "azure":
+				endpoints.Azure = url
+			case "aliyun":
+				endpoints.Aliyun = url
+			case "tencent":
+				endpoints.Tencent = url
+			}
+
+			got, err := detectCloudMetadata(context.Background(), http.DefaultClient, endpoints)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *got != tt.want {
+				t.Fatalf("got %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectCloudMetadataNotCloud(t *testing.T) {
+	empty := httptest.NewServer(http.NotFoundHandler())
+	defer empty.Close()
+	endpoints := cloudEndpoints{AWS: empty.URL, GCP: empty.URL, Azure: empty.URL, Aliyun: empty.URL, Tencent: empty.URL}
+	if _, err := detectCloudMetadata(context.Background(), http.DefaultClient, endpoints); !errors.Is(err, ErrNotCloudInstance) {
+		t.Fatalf("err = %v, want ErrNotCloudInstance", err)
+	}
+}
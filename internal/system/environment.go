@@ -0,0 +1,72 @@
+package system
+
+import (
+	"strings"
+
+	"github.com/shirou/gopsutil/v4/host"
+)
+
+// 运行环境类型
+const (
+	EnvironmentBareMetal = "bare_metal"
+	EnvironmentVM        = "vm"
+	EnvironmentContainer = "container"
+	EnvironmentUnknown   = "unknown"
+)
+
+// Environment Agent 的运行环境（物理机、虚拟机或容器）
+type Environment struct {
+	Type           string `json:"type"`                     // bare_metal/vm/container/unknown
+	Virtualization string `json:"virtualization,omitempty"` // 虚拟化平台：kvm/vmware/hyperv/xen/virtualbox/openvz
+	Container      string `json:"container,omitempty"`      // 容器运行时：docker/podman/lxc/containerd
+	Kubernetes     bool   `json:"kubernetes,omitempty"`     // 是否运行在 Kubernetes Pod 中
+}
+
+// IsContainer 是否运行在容器中
+func (e *Environment) IsContainer() bool {
+	return e != nil && e.Type == EnvironmentContainer
+}
+
+// containerSystems gopsutil 虚拟化检测中属于容器的类型
+var containerSystems = map[string]bool{"docker": true, "lxc": true, "podman": true, "containerd": true}
+
+// GetEnvironment 检测运行环境：优先使用 cgroup/命名空间等容器特征，再结合 host 信息中的虚拟化字段
+// hostInfo 可为空
+func (s *System) GetEnvironment(hostInfo *host.InfoStat) *Environment {
+	env := detectEnvironment()
+
+	if hostInfo != nil && hostInfo.VirtualizationRole == "guest" {
+		system := normalizeVirtualization(hostInfo.VirtualizationSystem)
+		if containerSystems[system] {
+			if env.Container == "" {
+				env.Container = system
+			}
+		} else if env.Virtualization == "" {
+			env.Virtualization = system
+		}
+	}
+
+	switch {
+	case env.Container != "" || env.Kubernetes:
+		env.Type = EnvironmentContainer
+	case env.Virtualization != "":
+		env.Type = EnvironmentVM
+	case env.Type == "" && hostInfo != nil && hostInfo.VirtualizationRole == "host":
+		env.Type = EnvironmentBareMetal
+	case env.Type == "":
+		env.Type = EnvironmentUnknown
+	}
+	return env
+}
+
+// normalizeVirtualization 统一不同来源的虚拟化平台名称
+func normalizeVirtualization(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	switch name {
+	case "vbox":
+		return "virtualbox"
+	case "hyper-v", "microsoft":
+		return "hyperv"
+	}
+	return name
+}
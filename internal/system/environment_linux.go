@@ -0,0 +1,126 @@
+package system
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// detectEnvironment 通过容器标记文件、1 号进程环境变量、cgroup、挂载信息和 DMI 检测运行环境
+// 未发现容器或虚拟化特征时认为是物理机
+func detectEnvironment() *Environment {
+	return detectEnvironmentAt("/")
+}
+
+// detectEnvironmentAt 在指定根目录下检测，便于测试
+func detectEnvironmentAt(root string) *Environment {
+	read := func(path string) string {
+		data, _ := os.ReadFile(filepath.Join(root, path))
+		return string(data)
+	}
+	exists := func(path string) bool {
+		_, err := os.Stat(filepath.Join(root, path))
+		return err == nil
+	}
+
+	env := &Environment{}
+	env.Container, env.Kubernetes = containerFromCgroup(read("proc/1/cgroup") + read("proc/self/cgroup"))
+	if env.Container == "" {
+		env.Container = containerFromMountInfo(read("proc/self/mountinfo"))
+	}
+	if env.Container == "" {
+		switch {
+		case exists(".dockerenv"):
+			env.Container = "docker"
+		case exists("run/.containerenv"):
+			env.Container = "podman"
+		default:
+			// lxc、systemd-nspawn 等会为 1 号进程设置 container 环境变量
+			env.Container = environValue(read("proc/1/environ"), "container")
+		}
+	}
+	if exists("var/run/secrets/kubernetes.io/serviceaccount") ||
+		environValue(read("proc/self/environ"), "KUBERNETES_SERVICE_HOST") != "" {
+		env.Kubernetes = true
+	}
+	if env.Kubernetes && env.Container == "" {
+		env.Container = "containerd"
+	}
+	if env.Container != "" {
+		return env
+	}
+
+	env.Virtualization = virtualizationFromDMI(read("sys/class/dmi/id/sys_vendor"), read("sys/class/dmi/id/product_name"))
+	if env.Virtualization == "" && strings.Contains(read("proc/cpuinfo"), " hypervisor") {
+		// CPU 标记了 hypervisor 但无法识别具体平台
+		env.Virtualization = "unknown"
+	}
+	if env.Virtualization == "" {
+		env.Type = EnvironmentBareMetal
+	}
+	return env
+}
+
+// containerFromCgroup 根据 cgroup 路径识别容器运行时（cgroup v1 或未启用 cgroup 命名空间的 v2）
+func containerFromCgroup(cgroup string) (runtime string, kubernetes bool) {
+	kubernetes = strings.Contains(cgroup, "kubepods")
+	switch {
+	case strings.Contains(cgroup, "/docker/") || strings.Contains(cgroup, "/docker-"):
+		runtime = "docker"
+	case strings.Contains(cgroup, "libpod"):
+		runtime = "podman"
+	case strings.Contains(cgroup, "/lxc/") || strings.Contains(cgroup, "lxc.payload"):
+		runtime = "lxc"
+	case strings.Contains(cgroup, "containerd") || strings.Contains(cgroup, "cri-containerd"):
+		runtime = "containerd"
+	case kubernetes:
+		runtime = "containerd"
+	}
+	return runtime, kubernetes
+}
+
+// containerFromMountInfo 启用 cgroup 命名空间后 cgroup 路径只剩 "/"，改为从挂载来源识别
+func containerFromMountInfo(mountInfo string) string {
+	switch {
+	case strings.Contains(mountInfo, "/docker/containers/"):
+		return "docker"
+	case strings.Contains(mountInfo, "/containers/storage/"):
+		return "podman"
+	case strings.Contains(mountInfo, "/containerd/"):
+		return "containerd"
+	}
+	return ""
+}
+
+// environValue 从 /proc/<pid>/environ 的内容（以 NUL 分隔）中读取环境变量
+func environValue(environ, key string) string {
+	for _, kv := range strings.Split(environ, "\x00") {
+		if value, ok := strings.CutPrefix(kv, key+"="); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+// virtualizationFromDMI 根据 DMI 厂商和产品名识别虚拟化平台
+func virtualizationFromDMI(vendor, product string) string {
+	vendor = strings.TrimSpace(vendor)
+	product = strings.TrimSpace(product)
+	switch {
+	case strings.Contains(vendor, "QEMU") || strings.Contains(product, "KVM"):
+		return "kvm"
+	case strings.Contains(vendor, "VMware"):
+		return "vmware"
+	case vendor == "Microsoft Corporation" && strings.Contains(product, "Virtual Machine"):
+		return "hyperv"
+	case strings.Contains(vendor, "Xen") || strings.Contains(product, "HVM domU"):
+		return "xen"
+	case strings.Contains(vendor, "innotek") || strings.Contains(product, "VirtualBox"):
+		return "virtualbox"
+	case strings.Contains(vendor, "Amazon EC2") || strings.Contains(vendor, "Google") ||
+		strings.Contains(vendor, "Alibaba Cloud") || strings.Contains(product, "Alibaba Cloud ECS"):
+		// 主流云厂商的实例基于 KVM（AWS Nitro、GCE、阿里云神龙）
+		return "kvm"
+	}
+	return ""
+}
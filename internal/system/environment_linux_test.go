@@ -0,0 +1,83 @@
+package system
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeRoot 在临时目录下按相对路径写入文件，模拟 /proc、/sys 等
+func fakeRoot(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestDetectEnvironmentDockerCgroupV1(t *testing.T) {
+	env := detectEnvironmentAt(fakeRoot(t, map[string]string{
+		"proc/1/cgroup": "12:memory:/docker/3f1c2a\n0::/docker/3f1c2a\n",
+	}))
+	if env.Container != "docker" || env.Kubernetes {
+		t.Fatalf("env = %+v", env)
+	}
+}
+
+func TestDetectEnvironmentKubernetes(t *testing.T) {
+	env := detectEnvironmentAt(fakeRoot(t, map[string]string{
+		"proc/1/cgroup":       "0::/\n",
+		"proc/self/environ":   "PATH=/bin\x00KUBERNETES_SERVICE_HOST=10.0.0.1\x00",
+		"proc/self/mountinfo": "1 0 0:1 /var/lib/containerd/io.containerd/abc /etc/hostname rw - ext4 /dev/sda1 rw\n",
+	}))
+	if env.Container != "containerd" || !env.Kubernetes {
+		t.Fatalf("env = %+v", env)
+	}
+}
+
+func TestDetectEnvironmentLXC(t *testing.T) {
+	env := detectEnvironmentAt(fakeRoot(t, map[string]string{
+		"proc/1/environ": "container=lxc\x00TERM=linux\x00",
+	}))
+	if env.Container != "lxc" {
+		t.Fatalf("env = %+v", env)
+	}
+}
+
+func TestDetectEnvironmentVM(t *testing.T) {
+	env := detectEnvironmentAt(fakeRoot(t, map[string]string{
+		"sys/class/dmi/id/sys_vendor":   "VMware, Inc.\n",
+		"sys/class/dmi/id/product_name": "VMware Virtual Platform\n",
+	}))
+	if env.Virtualization != "vmware" || env.Container != "" || env.Type != "" {
+		t.Fatalf("env = %+v", env)
+	}
+}
+
+func TestDetectEnvironmentBareMetal(t *testing.T) {
+	env := detectEnvironmentAt(fakeRoot(t, map[string]string{
+		"proc/1/cgroup":               "0::/init.scope\n",
+		"sys/class/dmi/id/sys_vendor": "Dell Inc.\n",
+		"proc/cpuinfo":                "flags\t: fpu vme de pse\n",
+	}))
+	if env.Type != EnvironmentBareMetal {
+		t.Fatalf("env = %+v", env)
+	}
+}
+
+func TestGetEnvironmentType(t *testing.T) {
+	s := &System{}
+	env := s.GetEnvironment(nil)
+	switch env.Type {
+	case EnvironmentBareMetal, EnvironmentVM, EnvironmentContainer, EnvironmentUnknown:
+	default:
+		t.Fatalf("未知的运行环境类型: %+v", env)
+	}
+}
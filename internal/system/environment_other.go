@@ -0,0 +1,8 @@
+//go:build !linux
+
+package system
+
+// detectEnvironment 其他平台没有 cgroup 等容器特征，仅依赖 host 信息中的虚拟化字段
+func detectEnvironment() *Environment {
+	return &Environment{}
+}
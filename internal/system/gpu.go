@@ -26,8 +26,11 @@ type GPUStats struct {
 	GPUs      []GPUInfo `json:"gpus"`
 }
 
-// GetGPUInfo 获取GPU信息
-func (s *System) GetGPUInfo() (*GPUStats, error) {
+// gpuQueryTimeout nvidia-smi 查询超时
+const gpuQueryTimeout = 5 * time.Second
+
+// GetGPUInfoWithContext 获取GPU信息
+func (s *System) GetGPUInfoWithContext(ctx context.Context) (*GPUStats, error) {
 	stats := &GPUStats{
 		Available: false,
 		GPUs:      []GPUInfo{},
@@ -40,8 +43,8 @@ func (s *System) GetGPUInfo() (*GPUStats, error) {
 		return stats, nil
 	}
 
-	// 创建带超时的上下文（5秒超时）
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	// 在调用方 ctx 的基础上限制 nvidia-smi 的执行时间
+	ctx, cancel := context.WithTimeout(ctx, gpuQueryTimeout)
 	defer cancel()
 
 	// 执行 nvidia-smi 命令获取GPU信息
@@ -0,0 +1,55 @@
+package system
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/host"
+)
+
+// maxLoginSources 失败登录统计中保留的来源数
+const maxLoginSources = 10
+
+// FailedLoginStats 一段时间内的 SSH 登录失败统计
+type FailedLoginStats struct {
+	Source        string        `json:"source"`         // 数据来源：日志文件路径或 journald
+	WindowSeconds int           `json:"window_seconds"` // 统计窗口
+	Failed        int           `json:"failed"`         // 认证失败次数
+	InvalidUsers  int           `json:"invalid_users"`  // 尝试不存在用户的次数
+	TopSources    []LoginSource `json:"top_sources"`    // 失败次数最多的来源地址
+}
+
+// LoginSource 登录失败的来源地址
+type LoginSource struct {
+	IP    string `json:"ip"`
+	Count int    `json:"count"`
+}
+
+// GetUsersWithContext 当前登录的用户会话
+func (s *System) GetUsersWithContext(ctx context.Context) ([]host.UserStat, error) {
+	return host.UsersWithContext(ctx)
+}
+
+// GetFailedSSHLoginsWithContext 统计最近 window 内的 SSH 登录失败次数（仅 Linux）
+func (s *System) GetFailedSSHLoginsWithContext(ctx context.Context, window time.Duration) (*FailedLoginStats, error) {
+	return getFailedSSHLogins(ctx, window)
+}
+
+// topLoginSources 按失败次数排序返回前 maxLoginSources 个来源
+func topLoginSources(counts map[string]int) []LoginSource {
+	sources := make([]LoginSource, 0, len(counts))
+	for ip, count := range counts {
+		sources = append(sources, LoginSource{IP: ip, Count: count})
+	}
+	sort.Slice(sources, func(i, j int) bool {
+		if sources[i].Count != sources[j].Count {
+			return sources[i].Count > sources[j].Count
+		}
+		return sources[i].IP < sources[j].IP
+	})
+	if len(sources) > maxLoginSources {
+		sources = sources[:maxLoginSources]
+	}
+	return sources
+}
@@ -0,0 +1,125 @@
+package system
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// authLogMaxBytes 每次最多读取认证日志末尾的字节数
+const authLogMaxBytes = 4 * 1024 * 1024
+
+// authLogPaths Debian/Ubuntu 与 RHEL 系的认证日志
+var authLogPaths = []string{"/var/log/auth.log", "/var/log/secure"}
+
+// syslog 行首时间格式
+const (
+	syslogTimeLayout  = "Jan _2 15:04:05"
+	rfc3339TimePrefix = len("2006-01-02T15:04:05")
+)
+
+var (
+	failedLoginPattern = regexp.MustCompile(`sshd\[\d+\]: Failed \S+ for (?:invalid user )?\S+ from (\S+)`)
+	invalidUserPattern = regexp.MustCompile(`sshd\[\d+\]: Invalid user \S* ?from \S+`)
+)
+
+// getFailedSSHLogins 优先解析认证日志文件，不存在时读取 journald
+func getFailedSSHLogins(ctx context.Context, window time.Duration) (*FailedLoginStats, error) {
+	since := time.Now().Add(-window)
+	for _, path := range authLogPaths {
+		data, err := readFileTail(path, authLogMaxBytes)
+		if err != nil {
+			continue
+		}
+		stats := countFailedLogins(data, since, true)
+		stats.Source = path
+		stats.WindowSeconds = int(window.Seconds())
+		return stats, nil
+	}
+
+	output, err := runCommand(ctx, "journalctl", "--no-pager", "-q", "-o", "short",
+		"--since", since.Format("2006-01-02 15:04:05"), "_COMM=sshd")
+	if err != nil {
+		return nil, fmt.Errorf("未找到认证日志且无法读取 journald: %w", err)
+	}
+	// journalctl 已按时间过滤
+	stats := countFailedLogins(output, since, false)
+	stats.Source = "journald"
+	stats.WindowSeconds = int(window.Seconds())
+	return stats, nil
+}
+
+// countFailedLogins 统计日志中 since 之后的 SSH 登录失败
+func countFailedLogins(data string, since time.Time, filterTime bool) *FailedLoginStats {
+	stats := &FailedLoginStats{}
+	sources := make(map[string]int)
+	now := time.Now()
+	for _, line := range strings.Split(data, "\n") {
+		if !strings.Contains(line, "sshd[") {
+			continue
+		}
+		if filterTime {
+			at, ok := parseSyslogTime(line, now)
+			if !ok || at.Before(since) {
+				continue
+			}
+		}
+		if match := failedLoginPattern.FindStringSubmatch(line); match != nil {
+			stats.Failed++
+			sources[match[1]]++
+		} else if invalidUserPattern.MatchString(line) {
+			stats.InvalidUsers++
+		}
+	}
+	stats.TopSources = topLoginSources(sources)
+	return stats
+}
+
+// parseSyslogTime 解析日志行首的时间：传统 syslog 格式（无年份）或 RFC3339
+func parseSyslogTime(line string, now time.Time) (time.Time, bool) {
+	if len(line) >= rfc3339TimePrefix && line[4] == '-' {
+		field, _, _ := strings.Cut(line, " ")
+		if t, err := time.Parse(time.RFC3339Nano, field); err == nil {
+			return t, true
+		}
+		return time.Time{}, false
+	}
+	if len(line) < len(syslogTimeLayout) {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation(syslogTimeLayout, line[:len(syslogTimeLayout)], time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	t = t.AddDate(now.Year(), 0, 0)
+	// 跨年时日志中的日期可能属于上一年
+	if t.After(now.Add(24 * time.Hour)) {
+		t = t.AddDate(-1, 0, 0)
+	}
+	return t, true
+}
+
+// readFileTail 读取文件末尾最多 maxBytes 字节
+func readFileTail(path string, maxBytes int64) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+	if info.Size() > maxBytes {
+		if _, err := file.Seek(info.Size()-maxBytes, io.SeekStart); err != nil {
+			return "", err
+		}
+	}
+	data, err := io.ReadAll(file)
+	return string(data), err
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package system
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// getFailedSSHLogins 其他平台暂不支持统计 SSH 登录失败
+func getFailedSSHLogins(ctx context.Context, window time.Duration) (*FailedLoginStats, error) {
+	return nil, errors.New("当前平台不支持统计 SSH 登录失败")
+}
@@ -0,0 +1,75 @@
+package system
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// packageQueryTimeout 包管理器查询的超时，部分包管理器需要读取较大的索引
+const packageQueryTimeout = 2 * time.Minute
+
+// maxPendingPackages 上报的待更新包名数量上限
+const maxPendingPackages = 100
+
+// ErrNoPackageManager 未检测到支持的包管理器
+var ErrNoPackageManager = errors.New("未检测到支持的包管理器")
+
+// PackageStats 已安装软件包与待更新情况
+type PackageStats struct {
+	Manager         string   `json:"manager"`                    // 包管理器：apt/dnf/yum/apk/winget/chocolatey
+	Installed       int      `json:"installed"`                  // 已安装包数量
+	Updates         int      `json:"updates"`                    // 待更新包数量
+	SecurityUpdates *int     `json:"security_updates,omitempty"` // 待安装的安全更新数量，包管理器不支持区分时为空
+	Pending         []string `json:"pending,omitempty"`          // 待更新的包名（最多 maxPendingPackages 个）
+}
+
+// GetPackageStatsWithContext 查询已安装软件包数量和待更新情况（仅读取本地缓存的索引，不主动刷新）
+func (s *System) GetPackageStatsWithContext(ctx context.Context) (*PackageStats, error) {
+	ctx, cancel := context.WithTimeout(ctx, packageQueryTimeout)
+	defer cancel()
+	return getPackageStats(ctx)
+}
+
+// runCommandStatus 执行命令并返回标准输出和退出码，命令无法启动时返回错误
+// 部分包管理器用非零退出码表示“有可用更新”
+func runCommandStatus(ctx context.Context, name string, args ...string) (string, int, error) {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", 0, err
+	}
+	cmd := exec.CommandContext(ctx, path, args...)
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	err = cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && ctx.Err() == nil {
+		return stdout.String(), exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	return stdout.String(), 0, nil
+}
+
+// countLines 统计非空行数
+func countLines(output string) int {
+	count := 0
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) != "" {
+			count++
+		}
+	}
+	return count
+}
+
+// addPending 记录待更新包名，超过上限时只计数
+func (p *PackageStats) addPending(name string) {
+	p.Updates++
+	if len(p.Pending) < maxPendingPackages {
+		p.Pending = append(p.Pending, name)
+	}
+}
@@ -0,0 +1,119 @@
+package system
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// getPackageStats 按 apt、dnf、yum、apk 的顺序检测包管理器
+func getPackageStats(ctx context.Context) (*PackageStats, error) {
+	switch {
+	case commandExists("dpkg-query") && commandExists("apt"):
+		return aptPackageStats(ctx)
+	case commandExists("dnf"):
+		return rpmPackageStats(ctx, "dnf")
+	case commandExists("yum"):
+		return rpmPackageStats(ctx, "yum")
+	case commandExists("apk"):
+		return apkPackageStats(ctx)
+	}
+	return nil, ErrNoPackageManager
+}
+
+func commandExists(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
+
+// aptPackageStats 使用 dpkg-query 统计已安装包，apt list --upgradable 统计待更新包
+// 来源包含 -security 的视为安全更新
+func aptPackageStats(ctx context.Context) (*PackageStats, error) {
+	installed, err := runCommand(ctx, "dpkg-query", "-W", "-f", "${Status}\n")
+	if err != nil {
+		return nil, fmt.Errorf("dpkg-query 执行失败: %w", err)
+	}
+	stats := &PackageStats{Manager: "apt"}
+	stats.Installed = strings.Count(installed, "install ok installed")
+
+	upgradable, err := runCommand(ctx, "apt", "list", "--upgradable")
+	if err != nil {
+		return nil, fmt.Errorf("apt list 执行失败: %w", err)
+	}
+	security := 0
+	for _, line := range strings.Split(upgradable, "\n") {
+		// 格式: name/suite version arch [upgradable from: old]
+		name, rest, ok := strings.Cut(strings.TrimSpace(line), "/")
+		if !ok || strings.Contains(name, " ") {
+			continue
+		}
+		stats.addPending(name)
+		suite, _, _ := strings.Cut(rest, " ")
+		if strings.Contains(suite, "-security") {
+			security++
+		}
+	}
+	stats.SecurityUpdates = &security
+	return stats, nil
+}
+
+// rpmPackageStats 使用 rpm -qa 统计已安装包，dnf/yum check-update 统计待更新包（仅使用本地缓存）
+func rpmPackageStats(ctx context.Context, manager string) (*PackageStats, error) {
+	installed, err := runCommand(ctx, "rpm", "-qa")
+	if err != nil {
+		return nil, fmt.Errorf("rpm -qa 执行失败: %w", err)
+	}
+	stats := &PackageStats{Manager: manager, Installed: countLines(installed)}
+
+	// check-update 有可用更新时退出码为 100
+	output, code, err := runCommandStatus(ctx, manager, "-q", "-C", "check-update")
+	if err != nil || (code != 0 && code != 100) {
+		return nil, fmt.Errorf("%s check-update 执行失败: code=%d err=%v", manager, code, err)
+	}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		// 格式: name.arch version repo；“Obsoleting Packages” 之后的内容不计入
+		if len(fields) == 2 && fields[0] == "Obsoleting" {
+			break
+		}
+		if len(fields) != 3 {
+			continue
+		}
+		stats.addPending(fields[0])
+	}
+
+	if output, err := runCommand(ctx, manager, "-q", "-C", "updateinfo", "list", "--security"); err == nil {
+		security := 0
+		for _, line := range strings.Split(output, "\n") {
+			if len(strings.Fields(line)) >= 3 {
+				security++
+			}
+		}
+		stats.SecurityUpdates = &security
+	}
+	return stats, nil
+}
+
+// apkPackageStats 使用 apk info 统计已安装包，apk version -l '<' 统计待更新包
+func apkPackageStats(ctx context.Context) (*PackageStats, error) {
+	installed, err := runCommand(ctx, "apk", "info")
+	if err != nil {
+		return nil, fmt.Errorf("apk info 执行失败: %w", err)
+	}
+	stats := &PackageStats{Manager: "apk", Installed: countLines(installed)}
+
+	output, err := runCommand(ctx, "apk", "version", "-l", "<")
+	if err != nil {
+		return nil, fmt.Errorf("apk version 执行失败: %w", err)
+	}
+	for _, line := range strings.Split(output, "\n") {
+		// 格式: name-version < newversion，首行为 "Installed: Available:"
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[1] != "<" {
+			continue
+		}
+		stats.addPending(fields[0])
+	}
+	return stats, nil
+}
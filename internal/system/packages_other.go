@@ -0,0 +1,10 @@
+//go:build !linux && !windows
+
+package system
+
+import "context"
+
+// getPackageStats 其他平台暂不支持查询软件包
+func getPackageStats(ctx context.Context) (*PackageStats, error) {
+	return nil, ErrNoPackageManager
+}
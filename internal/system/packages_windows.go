@@ -0,0 +1,96 @@
+package system
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// getPackageStats 优先使用 Chocolatey（输出格式稳定），其次 winget
+// Windows 包管理器不区分安全更新，SecurityUpdates 为空
+func getPackageStats(ctx context.Context) (*PackageStats, error) {
+	if stats, err := chocoPackageStats(ctx); err == nil {
+		return stats, nil
+	}
+	if stats, err := wingetPackageStats(ctx); err == nil {
+		return stats, nil
+	}
+	return nil, ErrNoPackageManager
+}
+
+// chocoPackageStats 解析 choco list/outdated 的 -r（name|version|...）输出
+func chocoPackageStats(ctx context.Context) (*PackageStats, error) {
+	installed, err := runCommand(ctx, "choco", "list", "--local-only", "-r")
+	if err != nil {
+		return nil, err
+	}
+	stats := &PackageStats{Manager: "chocolatey", Installed: countLines(installed)}
+
+	outdated, err := runCommand(ctx, "choco", "outdated", "-r")
+	if err != nil {
+		return nil, fmt.Errorf("choco outdated 执行失败: %w", err)
+	}
+	for _, line := range strings.Split(outdated, "\n") {
+		name, _, ok := strings.Cut(strings.TrimSpace(line), "|")
+		if ok && name != "" {
+			stats.addPending(name)
+		}
+	}
+	return stats, nil
+}
+
+// wingetPackageStats 解析 winget list/upgrade 的表格输出（表头后以 "---" 分隔）
+func wingetPackageStats(ctx context.Context) (*PackageStats, error) {
+	installed, err := runCommand(ctx, "winget", "list", "--accept-source-agreements", "--disable-interactivity")
+	if err != nil {
+		return nil, err
+	}
+	rows, _ := wingetRows(installed)
+	stats := &PackageStats{Manager: "winget", Installed: len(rows)}
+
+	// winget upgrade 在没有可用更新时也可能返回非零退出码
+	upgrades, _, err := runCommandStatus(ctx, "winget", "upgrade", "--accept-source-agreements", "--disable-interactivity")
+	if err != nil {
+		return nil, fmt.Errorf("winget upgrade 执行失败: %w", err)
+	}
+	rows, nameWidth := wingetRows(upgrades)
+	for _, row := range rows {
+		// 末尾的汇总行（如 "3 upgrades available."）不含多列
+		if len(strings.Fields(row)) < 4 {
+			continue
+		}
+		name := row
+		if nameWidth > 0 && nameWidth < len(row) {
+			name = row[:nameWidth]
+		}
+		stats.addPending(strings.TrimSpace(name))
+	}
+	return stats, nil
+}
+
+// wingetRows 返回表格分隔线之后的数据行，以及表头中第一列（名称）的宽度
+func wingetRows(output string) ([]string, int) {
+	var rows []string
+	header := ""
+	started := false
+	for _, line := range strings.Split(strings.ReplaceAll(output, "\r", ""), "\n") {
+		if !started {
+			if strings.HasPrefix(strings.TrimSpace(line), "---") {
+				started = true
+			} else if strings.TrimSpace(line) != "" {
+				header = line
+			}
+			continue
+		}
+		if strings.TrimSpace(line) != "" {
+			rows = append(rows, line)
+		}
+	}
+
+	// 表头列之间至少有一个空格，第二列的起始位置即名称列宽度
+	nameWidth := 0
+	if fields := strings.Fields(header); len(fields) > 1 {
+		nameWidth = strings.Index(header, " "+fields[1]) + 1
+	}
+	return rows, nameWidth
+}
@@ -0,0 +1,23 @@
+package system
+
+import "context"
+
+// SecurityPosture 系统安全状态概览
+type SecurityPosture struct {
+	SELinux        string         `json:"selinux,omitempty"`  // enforcing/permissive/disabled，未安装时为空
+	AppArmor       string         `json:"apparmor,omitempty"` // enabled/disabled，未安装时为空
+	Firewall       FirewallStatus `json:"firewall"`
+	RebootRequired bool           `json:"reboot_required"` // 系统更新后需要重启
+}
+
+// FirewallStatus 防火墙状态
+type FirewallStatus struct {
+	Backend string `json:"backend,omitempty"` // firewalld/ufw/nftables/iptables/windows，无法检测时为空
+	Enabled bool   `json:"enabled"`
+}
+
+// GetSecurityPostureWithContext 获取 SELinux/AppArmor、防火墙和待重启状态
+// 部分检测需要 root 权限，无法检测的项保持为空
+func (s *System) GetSecurityPostureWithContext(ctx context.Context) *SecurityPosture {
+	return getSecurityPosture(ctx)
+}
@@ -0,0 +1,110 @@
+package system
+
+import (
+	"context"
+	"os"
+	"strings"
+)
+
+func getSecurityPosture(ctx context.Context) *SecurityPosture {
+	return &SecurityPosture{
+		SELinux:        selinuxState(),
+		AppArmor:       apparmorState(),
+		Firewall:       firewallStatus(ctx),
+		RebootRequired: rebootRequired(ctx),
+	}
+}
+
+// selinuxState 读取 selinuxfs 的 enforce 标志
+func selinuxState() string {
+	data, err := os.ReadFile("/sys/fs/selinux/enforce")
+	if err == nil {
+		if strings.TrimSpace(string(data)) == "1" {
+			return "enforcing"
+		}
+		return "permissive"
+	}
+	// 未挂载 selinuxfs 但存在配置文件，说明已安装但未启用
+	if _, err := os.Stat("/etc/selinux/config"); err == nil {
+		return "disabled"
+	}
+	return ""
+}
+
+// apparmorState 读取 apparmor 内核模块参数
+func apparmorState() string {
+	data, err := os.ReadFile("/sys/module/apparmor/parameters/enabled")
+	if err != nil {
+		return ""
+	}
+	if strings.TrimSpace(string(data)) == "Y" {
+		return "enabled"
+	}
+	return "disabled"
+}
+
+// firewallStatus 依次检测 firewalld、ufw、nftables、iptables，使用第一个检测到的后端
+func firewallStatus(ctx context.Context) FirewallStatus {
+	if output, code, err := runCommandStatus(ctx, "firewall-cmd", "--state"); err == nil {
+		if code == 0 && strings.TrimSpace(output) == "running" {
+			return FirewallStatus{Backend: "firewalld", Enabled: true}
+		}
+	}
+	if output, err := runCommand(ctx, "ufw", "status"); err == nil {
+		if strings.Contains(output, "Status: active") {
+			return FirewallStatus{Backend: "ufw", Enabled: true}
+		}
+	}
+	if output, err := runCommand(ctx, "nft", "list", "ruleset"); err == nil {
+		if nftHasRules(output) {
+			return FirewallStatus{Backend: "nftables", Enabled: true}
+		}
+	}
+	if output, err := runCommand(ctx, "iptables", "-S"); err == nil {
+		return FirewallStatus{Backend: "iptables", Enabled: iptablesHasRules(output)}
+	}
+	return FirewallStatus{}
+}
+
+// nftHasRules 规则集中是否包含链之外的规则（仅有空表或空链视为未启用）
+func nftHasRules(output string) bool {
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line == "}" || strings.HasPrefix(line, "table ") ||
+			strings.HasPrefix(line, "chain ") || strings.HasPrefix(line, "type ") {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// iptablesHasRules 存在追加规则或默认策略不是 ACCEPT 时视为启用
+func iptablesHasRules(output string) bool {
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "-A" {
+			return true
+		}
+		if fields[0] == "-P" && len(fields) >= 3 && fields[2] != "ACCEPT" {
+			return true
+		}
+	}
+	return false
+}
+
+// rebootRequired Debian 系检查 reboot-required 标记文件，RHEL 系使用 needs-restarting -r（需要重启时退出码为 1）
+func rebootRequired(ctx context.Context) bool {
+	for _, path := range []string{"/var/run/reboot-required", "/run/reboot-required"} {
+		if _, err := os.Stat(path); err == nil {
+			return true
+		}
+	}
+	if _, code, err := runCommandStatus(ctx, "needs-restarting", "-r"); err == nil {
+		return code == 1
+	}
+	return false
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !windows
+
+package system
+
+import "context"
+
+// getSecurityPosture 其他平台暂不支持检测安全状态
+func getSecurityPosture(ctx context.Context) *SecurityPosture {
+	return &SecurityPosture{}
+}
@@ -0,0 +1,37 @@
+package system
+
+import (
+	"context"
+	"strings"
+)
+
+// rebootRequiredKeys 存在任一注册表项表示有待完成的重启
+var rebootRequiredKeys = []string{
+	`HKLM\SOFTWARE\Microsoft\Windows\CurrentVersion\WindowsUpdate\Auto Update\RebootRequired`,
+	`HKLM\SOFTWARE\Microsoft\Windows\CurrentVersion\Component Based Servicing\RebootPending`,
+}
+
+func getSecurityPosture(ctx context.Context) *SecurityPosture {
+	posture := &SecurityPosture{}
+
+	// netsh 输出随系统语言变化，同时匹配英文和中文
+	if output, err := runCommand(ctx, "netsh", "advfirewall", "show", "allprofiles", "state"); err == nil {
+		posture.Firewall.Backend = "windows"
+		for _, line := range strings.Split(output, "\n") {
+			fields := strings.Fields(line)
+			if len(fields) == 2 && (fields[0] == "State" || fields[0] == "状态") &&
+				(fields[1] == "ON" || fields[1] == "启用") {
+				posture.Firewall.Enabled = true
+				break
+			}
+		}
+	}
+
+	for _, key := range rebootRequiredKeys {
+		if _, code, err := runCommandStatus(ctx, "reg", "query", key); err == nil && code == 0 {
+			posture.RebootRequired = true
+			break
+		}
+	}
+	return posture
+}
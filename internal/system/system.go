@@ -1,18 +1,29 @@
 package system
 
 import (
+	"context"
+	"fmt"
 	"strings"
 	"time"
 
-	"github.com/shirou/gopsutil/cpu"
-	"github.com/shirou/gopsutil/disk"
-	"github.com/shirou/gopsutil/host"
-	"github.com/shirou/gopsutil/mem"
-	"github.com/shirou/gopsutil/net"
-	"github.com/shirou/gopsutil/process"
+	"github.com/shirou/gopsutil/v4/cpu"
+	"github.com/shirou/gopsutil/v4/disk"
+	"github.com/shirou/gopsutil/v4/host"
+	"github.com/shirou/gopsutil/v4/load"
+	"github.com/shirou/gopsutil/v4/mem"
+	"github.com/shirou/gopsutil/v4/net"
+	"github.com/shirou/gopsutil/v4/process"
 )
 
+// DefaultCallTimeout 单次系统调用的默认超时
+const DefaultCallTimeout = 10 * time.Second
+
+// cpuSampleInterval CPU 使用率采样窗口
+const cpuSampleInterval = 3 * time.Second
+
 type System struct {
+	// CallTimeout 单次系统调用的超时（见 CallContext），0 表示使用默认值
+	CallTimeout time.Duration
 }
 
 // ProcessStatus 进程状态
@@ -24,139 +35,106 @@ type ProcessStatus struct {
 	Memory  float64 `json:"memory"`
 }
 
-// GetHostInfo 本机信息
-func (s *System) GetHostInfo() *host.InfoStat {
-	h, _ := host.Info()
-	return h
-}
-
-// GetBootTime 获取系统启动时间（Unix时间戳）
-func (s *System) GetBootTime() (uint64, error) {
-	return host.BootTime()
-}
-
-// GetUptime 获取系统运行时间
-func (s *System) GetUptime() uint64 {
-	hostInfo := s.GetHostInfo()
-	if hostInfo != nil && hostInfo.Uptime > 0 {
-		return hostInfo.Uptime
-	}
-
-	// 如果 hostInfo.Uptime 不可用，从 boot_time 计算
-	bootTimeUnix, err := s.GetBootTime()
-	if err != nil || bootTimeUnix == 0 {
-		return 0
-	}
-
-	// 计算从 boot_time 到现在的秒数
-	now := time.Now().Unix()
-	if int64(bootTimeUnix) > now {
-		return 0 // boot_time 异常，返回0
+// CallContext 基于调用方的 ctx 创建单次系统调用的 context，附加 CallTimeout 超时
+// 调用方取消 ctx（如采集器停止）时正在进行的系统调用同样会被取消
+func (s *System) CallContext(parent context.Context) (context.Context, context.CancelFunc) {
+	timeout := s.CallTimeout
+	if timeout <= 0 {
+		timeout = DefaultCallTimeout
 	}
-
-	return uint64(now - int64(bootTimeUnix))
+	return context.WithTimeout(parent, timeout)
 }
 
-// GetMemoryTotal 总内存
-func (s *System) GetMemoryTotal() int {
-	v, _ := mem.VirtualMemory()
-	return int(v.Total)
+// GetHostInfoWithContext 本机信息
+func (s *System) GetHostInfoWithContext(ctx context.Context) (*host.InfoStat, error) {
+	return host.InfoWithContext(ctx)
 }
 
-// GetMemoryFree 剩余内存
-func (s *System) GetMemoryFree() int {
-	v, _ := mem.VirtualMemory()
-	return int(v.Free)
+// GetHostIDWithContext 获取机器唯一标识（machine-id / 平台 UUID）
+func (s *System) GetHostIDWithContext(ctx context.Context) (string, error) {
+	return host.HostIDWithContext(ctx)
 }
 
-// GetMemoryUsed 已使用的内存
-func (s *System) GetMemoryUsed() int {
-	v, _ := mem.VirtualMemory()
-	return int(v.Used)
+// GetBootTimeWithContext 获取系统启动时间（Unix时间戳）
+func (s *System) GetBootTimeWithContext(ctx context.Context) (uint64, error) {
+	return host.BootTimeWithContext(ctx)
 }
 
-// GetMemoryUsedPercent 内存占用百分比
-func (s *System) GetMemoryUsedPercent() int {
-	v, _ := mem.VirtualMemory()
-	return int(v.UsedPercent)
-}
+// GetUptimeWithContext 获取系统运行时间（秒），host 信息中没有时由启动时间计算
+func (s *System) GetUptimeWithContext(ctx context.Context) (uint64, error) {
+	hostInfo, err := s.GetHostInfoWithContext(ctx)
+	if err == nil && hostInfo != nil && hostInfo.Uptime > 0 {
+		return hostInfo.Uptime, nil
+	}
 
-// GetSwapMemory 获取Swap内存信息
-func (s *System) GetSwapMemory() (total, used, free int, usedPercent float64) {
-	swap, err := mem.SwapMemory()
+	bootTimeUnix, err := s.GetBootTimeWithContext(ctx)
 	if err != nil {
-		return 0, 0, 0, 0.0
+		return 0, err
 	}
-	return int(swap.Total), int(swap.Used), int(swap.Free), swap.UsedPercent
+	now := time.Now().Unix()
+	if bootTimeUnix == 0 || int64(bootTimeUnix) > now {
+		return 0, fmt.Errorf("系统启动时间异常: %d", bootTimeUnix)
+	}
+	return uint64(now - int64(bootTimeUnix)), nil
 }
 
-// GetCpuCount cpu 物理核心数
-func (s *System) GetCpuCount() int {
-	count, _ := cpu.Counts(false)
-	return int(count)
+// GetVirtualMemoryWithContext 获取物理内存统计
+func (s *System) GetVirtualMemoryWithContext(ctx context.Context) (*mem.VirtualMemoryStat, error) {
+	return mem.VirtualMemoryWithContext(ctx)
 }
 
-// GetCpuLogicCount cpu 逻辑核心数
-func (s *System) GetCpuLogicCount() int {
-	count, _ := cpu.Counts(true)
-	return int(count)
+// GetSwapMemoryWithContext 获取Swap内存统计
+func (s *System) GetSwapMemoryWithContext(ctx context.Context) (*mem.SwapMemoryStat, error) {
+	return mem.SwapMemoryWithContext(ctx)
 }
 
-// GetCpuUsedPercent 3s内的cpu总使用率
-func (s *System) GetCpuUsedPercent() int {
-	percent, _ := cpu.Percent(3*time.Second, false)
-	if len(percent) > 0 {
-		return int(percent[0])
-	}
-	return 0
+// GetCpuCountsWithContext cpu 核心数，logical 为 true 时返回逻辑核心数
+func (s *System) GetCpuCountsWithContext(ctx context.Context, logical bool) (int, error) {
+	return cpu.CountsWithContext(ctx, logical)
 }
 
-// GetCpuUsedPercentEach 获取每个CPU核心的使用率
-func (s *System) GetCpuUsedPercentEach() []float64 {
-	percents, _ := cpu.Percent(3*time.Second, true)
-	return percents
+// GetCpuPercentWithContext 在采样窗口内计算 cpu 使用率，percpu 为 true 时返回每个核心的使用率
+func (s *System) GetCpuPercentWithContext(ctx context.Context, percpu bool) ([]float64, error) {
+	return cpu.PercentWithContext(ctx, cpuSampleInterval, percpu)
 }
 
-// GetCpuInfo 获取CPU信息
-func (s *System) GetCpuInfo() []cpu.InfoStat {
-	info, _ := cpu.Info()
-	return info
+// GetCpuPercentSinceLastCallWithContext 计算自上次调用以来的 cpu 总使用率（不阻塞等待采样窗口）
+func (s *System) GetCpuPercentSinceLastCallWithContext(ctx context.Context) (float64, error) {
+	percent, err := cpu.PercentWithContext(ctx, 0, false)
+	if err != nil || len(percent) == 0 {
+		return 0, err
+	}
+	return percent[0], nil
 }
 
-// GetDiskInfo 磁盘信息
-func (s *System) GetDiskInfo() []disk.UsageStat {
-	parts, _ := disk.Partitions(true)
-	var disks []disk.UsageStat
-	for _, part := range parts {
-		u, _ := disk.Usage(part.Mountpoint)
-		disks = append(disks, *u)
-	}
-	return disks
+// GetLoadAvgWithContext 获取系统平均负载（Windows 下不可用）
+func (s *System) GetLoadAvgWithContext(ctx context.Context) (*load.AvgStat, error) {
+	return load.AvgWithContext(ctx)
 }
 
-// GetDiskIOCounters 磁盘IO信息
-func (s *System) GetDiskIOCounters() (map[string]disk.IOCountersStat, error) {
-	return disk.IOCounters()
+// GetCpuInfoWithContext 获取CPU信息
+func (s *System) GetCpuInfoWithContext(ctx context.Context) ([]cpu.InfoStat, error) {
+	return cpu.InfoWithContext(ctx)
 }
 
-// GetDiskPart 获取磁盘分区信息
-func (s *System) GetDiskPart() []disk.PartitionStat {
-	parts, _ := disk.Partitions(true)
-	return parts
+// GetDiskIOCountersWithContext 磁盘IO信息
+func (s *System) GetDiskIOCountersWithContext(ctx context.Context) (map[string]disk.IOCountersStat, error) {
+	return disk.IOCountersWithContext(ctx)
 }
 
-// GetDiskUsage 获取指定挂载点的磁盘使用情况
-func (s *System) GetDiskUsage(mountpoint string) *disk.UsageStat {
-	usage, err := disk.Usage(mountpoint)
-	if err != nil {
-		return nil
-	}
-	return usage
+// GetDiskPartWithContext 获取磁盘分区信息
+func (s *System) GetDiskPartWithContext(ctx context.Context) ([]disk.PartitionStat, error) {
+	return disk.PartitionsWithContext(ctx, true)
 }
 
-// GetNetIOCounters 网络IO信息
-func (s *System) GetNetIOCounters() (map[string]net.IOCountersStat, error) {
-	counters, err := net.IOCounters(true)
+// GetDiskUsageWithContext 获取指定挂载点的磁盘使用情况
+func (s *System) GetDiskUsageWithContext(ctx context.Context, mountpoint string) (*disk.UsageStat, error) {
+	return disk.UsageWithContext(ctx, mountpoint)
+}
+
+// GetNetIOCountersWithContext 网络IO信息（按接口名索引）
+func (s *System) GetNetIOCountersWithContext(ctx context.Context) (map[string]net.IOCountersStat, error) {
+	counters, err := net.IOCountersWithContext(ctx, true)
 	if err != nil {
 		return nil, err
 	}
@@ -170,19 +148,23 @@ func (s *System) GetNetIOCounters() (map[string]net.IOCountersStat, error) {
 	return result, nil
 }
 
-// GetNetIO 获取网络连接信息
-func (s *System) GetNetIO() []net.ConnectionStat {
-	conns, _ := net.Connections("all")
-	return conns
+// GetNetInterfacesWithContext 获取网络接口列表
+func (s *System) GetNetInterfacesWithContext(ctx context.Context) ([]net.InterfaceStat, error) {
+	return net.InterfacesWithContext(ctx)
+}
+
+// GetNetIOWithContext 获取网络连接信息
+func (s *System) GetNetIOWithContext(ctx context.Context) ([]net.ConnectionStat, error) {
+	return net.ConnectionsWithContext(ctx, "all")
 }
 
-// GetProcessStatus 获取指定服务的状态
-func (s *System) GetProcessStatus(services []string) ([]ProcessStatus, error) {
+// GetProcessStatusWithContext 获取指定服务的状态
+func (s *System) GetProcessStatusWithContext(ctx context.Context, services []string) ([]ProcessStatus, error) {
 	if len(services) == 0 {
 		return []ProcessStatus{}, nil
 	}
 
-	processes, err := process.Processes()
+	processes, err := process.ProcessesWithContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -193,7 +175,11 @@ func (s *System) GetProcessStatus(services []string) ([]ProcessStatus, error) {
 	}
 
 	for _, p := range processes {
-		name, err := p.Name()
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		name, err := p.NameWithContext(ctx)
 		if err != nil {
 			continue
 		}
@@ -205,10 +191,10 @@ func (s *System) GetProcessStatus(services []string) ([]ProcessStatus, error) {
 				s.Running = true
 				s.Pids = append(s.Pids, p.Pid)
 
-				cpuPercent, _ := p.CPUPercent()
+				cpuPercent, _ := p.CPUPercentWithContext(ctx)
 				s.CPU += cpuPercent
 
-				memPercent, _ := p.MemoryPercent()
+				memPercent, _ := p.MemoryPercentWithContext(ctx)
 				s.Memory += float64(memPercent)
 			}
 		}
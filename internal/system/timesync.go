@@ -0,0 +1,49 @@
+package system
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// TimeSyncStatus 系统时间同步状态
+type TimeSyncStatus struct {
+	Available bool     `json:"available"`           // 是否检测到时间同步服务
+	Source    string   `json:"source,omitempty"`    // 同步服务：chrony/timesyncd/ntpd/w32time
+	Synced    bool     `json:"synced"`              // 是否已同步
+	Server    string   `json:"server,omitempty"`    // 当前参考服务器
+	Stratum   int      `json:"stratum,omitempty"`   // 层级
+	OffsetMs  *float64 `json:"offset_ms,omitempty"` // 本地时钟相对参考时间的偏差（毫秒）
+}
+
+// GetTimeSyncStatusWithContext 获取时间同步状态，未检测到同步服务时返回 Available=false（不是错误）
+func (s *System) GetTimeSyncStatusWithContext(ctx context.Context) (*TimeSyncStatus, error) {
+	return getTimeSyncStatus(ctx)
+}
+
+// runCommand 执行命令并返回标准输出，命令不存在或执行失败时返回错误
+func runCommand(ctx context.Context, name string, args ...string) (string, error) {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", err
+	}
+	cmd := exec.CommandContext(ctx, path, args...)
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+	return stdout.String(), nil
+}
+
+// parseFloatPtr 解析浮点数，失败时返回 nil
+func parseFloatPtr(value string, scale float64) *float64 {
+	v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return nil
+	}
+	v *= scale
+	return &v
+}
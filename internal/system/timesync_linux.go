@@ -0,0 +1,108 @@
+package system
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// getTimeSyncStatus 依次尝试 chrony、systemd-timesyncd 和 ntpd
+func getTimeSyncStatus(ctx context.Context) (*TimeSyncStatus, error) {
+	if status := chronyStatus(ctx); status != nil {
+		return status, nil
+	}
+	if status := timesyncdStatus(ctx); status != nil {
+		return status, nil
+	}
+	if status := ntpdStatus(ctx); status != nil {
+		return status, nil
+	}
+	return &TimeSyncStatus{Available: false}, nil
+}
+
+// chronyStatus 解析 chronyc -c tracking 的 CSV 输出
+// 字段：参考ID,参考名称,层级,参考时间,系统时间偏差(秒),...,闰秒状态
+func chronyStatus(ctx context.Context) *TimeSyncStatus {
+	output, err := runCommand(ctx, "chronyc", "-c", "tracking")
+	if err != nil {
+		return nil
+	}
+	fields := strings.Split(strings.TrimSpace(output), ",")
+	if len(fields) < 14 {
+		return nil
+	}
+	stratum, _ := strconv.Atoi(fields[2])
+	leap := fields[len(fields)-1]
+	return &TimeSyncStatus{
+		Available: true,
+		Source:    "chrony",
+		Synced:    leap != "Not synchronised" && stratum > 0 && stratum < 16,
+		Server:    fields[1],
+		Stratum:   stratum,
+		OffsetMs:  parseFloatPtr(fields[4], 1000),
+	}
+}
+
+// timesyncdStatus 解析 timedatectl 输出（systemd-timesyncd）
+func timesyncdStatus(ctx context.Context) *TimeSyncStatus {
+	output, err := runCommand(ctx, "timedatectl", "show", "-p", "NTP", "-p", "NTPSynchronized")
+	if err != nil {
+		return nil
+	}
+	props := parseKeyValues(output, "=")
+	if props["NTP"] != "yes" && props["NTPSynchronized"] != "yes" {
+		return nil
+	}
+	status := &TimeSyncStatus{
+		Available: true,
+		Source:    "timesyncd",
+		Synced:    props["NTPSynchronized"] == "yes",
+	}
+
+	// timesync-status 需要较新的 systemd，失败时只上报同步状态
+	if detail, err := runCommand(ctx, "timedatectl", "timesync-status"); err == nil {
+		values := parseKeyValues(detail, ":")
+		status.Server = values["Server"]
+		status.Stratum, _ = strconv.Atoi(values["Stratum"])
+		if offset, err := time.ParseDuration(strings.ReplaceAll(values["Offset"], "us", "µs")); err == nil {
+			ms := float64(offset) / float64(time.Millisecond)
+			status.OffsetMs = &ms
+		}
+	}
+	return status
+}
+
+// ntpdStatus 解析 ntpq -c rv 输出（ntpd）
+func ntpdStatus(ctx context.Context) *TimeSyncStatus {
+	output, err := runCommand(ctx, "ntpq", "-c", "rv")
+	if err != nil {
+		return nil
+	}
+	values := make(map[string]string)
+	for _, item := range strings.FieldsFunc(output, func(r rune) bool { return r == ',' || r == '\n' }) {
+		if key, value, ok := strings.Cut(strings.TrimSpace(item), "="); ok {
+			values[key] = strings.Trim(value, `"`)
+		}
+	}
+	stratum, _ := strconv.Atoi(values["stratum"])
+	return &TimeSyncStatus{
+		Available: true,
+		Source:    "ntpd",
+		Synced:    values["leap"] != "11" && values["leap"] != "" && stratum > 0 && stratum < 16,
+		Server:    values["refid"],
+		Stratum:   stratum,
+		OffsetMs:  parseFloatPtr(values["offset"], 1),
+	}
+}
+
+// parseKeyValues 解析 "key<sep>value" 形式的多行输出
+func parseKeyValues(output, sep string) map[string]string {
+	values := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		if key, value, ok := strings.Cut(line, sep); ok {
+			values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !windows
+
+package system
+
+import "context"
+
+// getTimeSyncStatus 其他平台暂不支持检测时间同步状态
+func getTimeSyncStatus(ctx context.Context) (*TimeSyncStatus, error) {
+	return &TimeSyncStatus{Available: false}, nil
+}
@@ -0,0 +1,44 @@
+package system
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+// getTimeSyncStatus 解析 w32tm /query /status /verbose 输出
+// 输出随系统语言变化，同时匹配英文和中文字段名
+func getTimeSyncStatus(ctx context.Context) (*TimeSyncStatus, error) {
+	output, err := runCommand(ctx, "w32tm", "/query", "/status", "/verbose")
+	if err != nil {
+		// 服务未启动时 w32tm 返回错误
+		return &TimeSyncStatus{Available: false}, nil
+	}
+
+	status := &TimeSyncStatus{Available: true, Source: "w32time"}
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		switch key {
+		case "Stratum", "层次":
+			if fields := strings.Fields(value); len(fields) > 0 {
+				status.Stratum, _ = strconv.Atoi(fields[0])
+			}
+		case "Source", "源":
+			// 去掉 ",0x9" 之类的标志位
+			status.Server, _, _ = strings.Cut(value, ",")
+		case "Phase Offset", "相位偏移":
+			status.OffsetMs = parseFloatPtr(strings.TrimSuffix(value, "s"), 1000)
+		}
+	}
+
+	// 使用本地 CMOS 时钟或自由运行时钟表示未与外部时间源同步
+	local := status.Server == "" || strings.Contains(status.Server, "Local CMOS Clock") ||
+		strings.Contains(status.Server, "Free-running System Clock")
+	status.Synced = !local && status.Stratum > 0 && status.Stratum < 16
+	return status, nil
+}
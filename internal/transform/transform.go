@@ -0,0 +1,222 @@
+// Package transform 使用用户提供的 Lua 脚本在发送前转换上报数据
+//
+// 脚本需定义全局函数 transform(type, data)：
+//   - 返回修改后的 data 表，作为新的上报数据
+//   - 返回 nil 表示丢弃该消息
+//
+// 脚本运行在受限环境中，仅开放 base/table/string/math 标准库，不能访问文件或执行命令。
+package transform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sync"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// callTimeout 单次转换的超时，避免脚本死循环阻塞上报
+const callTimeout = 200 * time.Millisecond
+
+// maxDepth 数据转换的最大嵌套层数
+const maxDepth = 32
+
+// Transformer 已加载的转换脚本，可并发调用
+type Transformer struct {
+	path string
+	mu   sync.Mutex
+	L    *lua.LState
+	fn   *lua.LFunction
+}
+
+// Load 加载转换脚本
+func Load(path string) (*Transformer, error) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	L := lua.NewState(lua.Options{SkipOpenLibs: true})
+	for _, lib := range []struct {
+		name string
+		open lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		L.Push(L.NewFunction(lib.open))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+	// base 库中可加载外部代码的函数
+	for _, name := range []string{"dofile", "loadfile", "load", "loadstring", "require"} {
+		L.SetGlobal(name, lua.LNil)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+	L.SetContext(ctx)
+	if err := L.DoString(string(source)); err != nil {
+		L.Close()
+		return nil, fmt.Errorf("加载转换脚本失败: %w", err)
+	}
+	L.RemoveContext()
+
+	fn, ok := L.GetGlobal("transform").(*lua.LFunction)
+	if !ok {
+		L.Close()
+		return nil, errors.New("转换脚本未定义 transform(type, data) 函数")
+	}
+	return &Transformer{path: path, L: L, fn: fn}, nil
+}
+
+// Path 返回脚本路径
+func (t *Transformer) Path() string {
+	return t.path
+}
+
+// Close 释放脚本运行环境
+func (t *Transformer) Close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.L.Close()
+}
+
+// Apply 转换一条消息的数据，keep 为 false 表示脚本要求丢弃该消息
+func (t *Transformer) Apply(msgType string, data interface{}) (result interface{}, keep bool, err error) {
+	// 先经过 JSON 序列化，统一结构体和各类 map 的表示
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, false, err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(raw, &generic); err != nil {
+		return nil, false, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+	t.L.SetContext(ctx)
+	defer t.L.RemoveContext()
+
+	value, err := toLua(t.L, generic, 0)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := t.L.CallByParam(lua.P{Fn: t.fn, NRet: 1, Protect: true}, lua.LString(msgType), value); err != nil {
+		return nil, false, fmt.Errorf("执行转换脚本失败: %w", err)
+	}
+	ret := t.L.Get(-1)
+	t.L.Pop(1)
+
+	if ret == lua.LNil {
+		return nil, false, nil
+	}
+	result, err = fromLua(ret, 0)
+	if err != nil {
+		return nil, false, err
+	}
+	return result, true, nil
+}
+
+// toLua 将 JSON 解码得到的通用数据转换为 Lua 值
+func toLua(L *lua.LState, v interface{}, depth int) (lua.LValue, error) {
+	if depth > maxDepth {
+		return lua.LNil, errors.New("数据嵌套层数过多")
+	}
+	switch value := v.(type) {
+	case nil:
+		return lua.LNil, nil
+	case bool:
+		return lua.LBool(value), nil
+	case float64:
+		return lua.LNumber(value), nil
+	case string:
+		return lua.LString(value), nil
+	case []interface{}:
+		table := L.CreateTable(len(value), 0)
+		for _, item := range value {
+			converted, err := toLua(L, item, depth+1)
+			if err != nil {
+				return lua.LNil, err
+			}
+			table.Append(converted)
+		}
+		return table, nil
+	case map[string]interface{}:
+		table := L.CreateTable(0, len(value))
+		for key, item := range value {
+			converted, err := toLua(L, item, depth+1)
+			if err != nil {
+				return lua.LNil, err
+			}
+			table.RawSetString(key, converted)
+		}
+		return table, nil
+	}
+	return lua.LNil, fmt.Errorf("不支持的数据类型: %T", v)
+}
+
+// fromLua 将 Lua 值转换回通用数据；键为 1..n 连续整数的表视为数组
+func fromLua(v lua.LValue, depth int) (interface{}, error) {
+	if depth > maxDepth {
+		return nil, errors.New("数据嵌套层数过多")
+	}
+	switch value := v.(type) {
+	case *lua.LNilType:
+		return nil, nil
+	case lua.LBool:
+		return bool(value), nil
+	case lua.LNumber:
+		f := float64(value)
+		if f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+			return int64(f), nil
+		}
+		return f, nil
+	case lua.LString:
+		return string(value), nil
+	case *lua.LTable:
+		if n := value.Len(); n > 0 && countKeys(value) == n {
+			list := make([]interface{}, 0, n)
+			for i := 1; i <= n; i++ {
+				item, err := fromLua(value.RawGetInt(i), depth+1)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, item)
+			}
+			return list, nil
+		}
+		result := make(map[string]interface{})
+		var convErr error
+		value.ForEach(func(key, item lua.LValue) {
+			if convErr != nil {
+				return
+			}
+			converted, err := fromLua(item, depth+1)
+			if err != nil {
+				convErr = err
+				return
+			}
+			result[key.String()] = converted
+		})
+		return result, convErr
+	}
+	return nil, fmt.Errorf("脚本返回了不支持的类型: %s", v.Type())
+}
+
+func countKeys(table *lua.LTable) int {
+	count := 0
+	table.ForEach(func(lua.LValue, lua.LValue) { count++ })
+	return count
+}
@@ -0,0 +1,142 @@
+package transform
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// loadScript 将脚本写入临时文件并加载
+func loadScript(t *testing.T, source string) *Transformer {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "transform.lua")
+	if err := os.WriteFile(path, []byte(source), 0600); err != nil {
+		t.Fatal(err)
+	}
+	tr, err := Load(path)
+	if err != nil {
+		t.Fatalf("加载脚本失败: %v", err)
+	}
+	t.Cleanup(tr.Close)
+	return tr
+}
+
+func TestApplyConversion(t *testing.T) {
+	tr := loadScript(t, `function transform(t, d) return d end`)
+
+	tests := []struct {
+		name string
+		data interface{}
+		want interface{}
+	}{
+		{"整数", map[string]interface{}{"n": 42}, map[string]interface{}{"n": int64(42)}},
+		{"小数", map[string]interface{}{"f": 1.5}, map[string]interface{}{"f": 1.5}},
+		{"字符串与布尔", map[string]interface{}{"s": "x", "b": true}, map[string]interface{}{"s": "x", "b": true}},
+		{"数组", map[string]interface{}{"list": []interface{}{"a", "b"}}, map[string]interface{}{"list": []interface{}{"a", "b"}}},
+		{"嵌套对象", map[string]interface{}{"m": map[string]interface{}{"k": 1}}, map[string]interface{}{"m": map[string]interface{}{"k": int64(1)}}},
+		{"结构体按 JSON 标签转换", struct {
+			Name string `json:"name"`
+		}{"agent"}, map[string]interface{}{"name": "agent"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, keep, err := tr.Apply("metrics", tt.data)
+			if err != nil || !keep {
+				t.Fatalf("keep=%v err=%v", keep, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyModifiesData(t *testing.T) {
+	tr := loadScript(t, `function transform(t, d) d.tag = t; d.secret = nil; return d end`)
+	got, keep, err := tr.Apply("metrics", map[string]interface{}{"cpu": 1, "secret": "x"})
+	if err != nil || !keep {
+		t.Fatalf("keep=%v err=%v", keep, err)
+	}
+	want := map[string]interface{}{"cpu": int64(1), "tag": "metrics"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %#v, want %#v", got, want)
+	}
+}
+
+func TestApplyReturnNilDrops(t *testing.T) {
+	tr := loadScript(t, `function transform(t, d) return nil end`)
+	if _, keep, err := tr.Apply("metrics", map[string]interface{}{"cpu": 1}); err != nil || keep {
+		t.Fatalf("keep=%v err=%v, 返回 nil 应丢弃消息", keep, err)
+	}
+}
+
+func TestApplyScriptErrors(t *testing.T) {
+	scripts := map[string]string{
+		"运行时错误":    `function transform(t, d) error("boom") end`,
+		"返回不支持的类型": `function transform(t, d) return function() end end`,
+	}
+	for name, script := range scripts {
+		tr := loadScript(t, script)
+		if _, _, err := tr.Apply("metrics", map[string]interface{}{}); err == nil {
+			t.Errorf("%s: 应返回错误", name)
+		}
+	}
+}
+
+func TestApplyTimeout(t *testing.T) {
+	tr := loadScript(t, `function transform(t, d) while true do end end`)
+	start := time.Now()
+	if _, _, err := tr.Apply("metrics", map[string]interface{}{}); err == nil {
+		t.Fatal("死循环应超时返回错误")
+	}
+	if elapsed := time.Since(start); elapsed > 10*callTimeout {
+		t.Fatalf("超时返回耗时 %s", elapsed)
+	}
+	// 超时后脚本仍可继续使用
+	if _, _, err := tr.Apply("metrics", map[string]interface{}{}); err == nil {
+		t.Fatal("死循环应超时返回错误")
+	}
+}
+
+func TestApplyRejectsDeepNesting(t *testing.T) {
+	tr := loadScript(t, `function transform(t, d) return d end`)
+	var data interface{} = "leaf"
+	for i := 0; i <= maxDepth+1; i++ {
+		data = map[string]interface{}{"n": data}
+	}
+	if _, _, err := tr.Apply("metrics", data); err == nil {
+		t.Fatal("超过最大嵌套层数时应返回错误")
+	}
+}
+
+func TestLoadSandbox(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		wantErr string
+	}{
+		{"未定义 transform", `x = 1`, "未定义"},
+		{"语法错误", `function transform(`, "加载转换脚本失败"},
+		{"禁止访问 os 库", `os.execute("true")`, "加载转换脚本失败"},
+		{"禁止加载外部代码", `dofile("/etc/passwd")`, "加载转换脚本失败"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "transform.lua")
+			if err := os.WriteFile(path, []byte(tt.script), 0600); err != nil {
+				t.Fatal(err)
+			}
+			tr, err := Load(path)
+			if err == nil {
+				tr.Close()
+				t.Fatal("期望加载失败")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want 包含 %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package websocket
+
+import (
+	"sync"
+	"time"
+)
+
+// Availability 连接可用性统计
+// 时长均基于单调时钟计算，系统时间被 NTP 调整时不受影响；时间点字段仅用于展示
+type Availability struct {
+	StartedAt           time.Time  `json:"started_at"`
+	ConnectedAt         *time.Time `json:"connected_at,omitempty"`
+	UptimeSeconds       int64      `json:"uptime_seconds"`
+	ConnectedSeconds    int64      `json:"connected_seconds"`
+	DisconnectedSeconds int64      `json:"disconnected_seconds"`
+	Reconnects          int        `json:"reconnects"`
+}
+
+// availabilityTracker 记录连接状态变化
+// time.Now() 返回的时间携带单调时钟读数，Sub/Since 计算时优先使用单调时钟
+type availabilityTracker struct {
+	mu                sync.Mutex
+	start             time.Time
+	connected         bool
+	since             time.Time // 最近一次状态变化
+	connectedTotal    time.Duration
+	disconnectedTotal time.Duration
+	connects          int
+}
+
+func newAvailabilityTracker() *availabilityTracker {
+	now := time.Now()
+	return &availabilityTracker{start: now, since: now}
+}
+
+// set 更新连接状态，状态未变化时忽略
+func (t *availabilityTracker) set(connected bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.connected == connected {
+		return
+	}
+	now := time.Now()
+	if t.connected {
+		t.connectedTotal += now.Sub(t.since)
+	} else {
+		t.disconnectedTotal += now.Sub(t.since)
+		t.connects++
+	}
+	t.connected = connected
+	t.since = now
+}
+
+func (t *availabilityTracker) snapshot() Availability {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	connectedTotal := t.connectedTotal
+	disconnectedTotal := t.disconnectedTotal
+	var connectedAt *time.Time
+	if t.connected {
+		connectedTotal += now.Sub(t.since)
+		// 由单调时钟推算建立时间，避免系统时间跳变后显示错误
+		at := now.Add(-now.Sub(t.since)).Round(time.Second)
+		connectedAt = &at
+	} else {
+		disconnectedTotal += now.Sub(t.since)
+	}
+
+	reconnects := t.connects - 1
+	if reconnects < 0 {
+		reconnects = 0
+	}
+	return Availability{
+		StartedAt:           now.Add(-now.Sub(t.start)).Round(time.Second),
+		ConnectedAt:         connectedAt,
+		UptimeSeconds:       int64(now.Sub(t.start).Seconds()),
+		ConnectedSeconds:    int64(connectedTotal.Seconds()),
+		DisconnectedSeconds: int64(disconnectedTotal.Seconds()),
+		Reconnects:          reconnects,
+	}
+}
+
+// Availability 返回连接可用性统计
+func (c *Client) Availability() Availability {
+	return c.availability.snapshot()
+}
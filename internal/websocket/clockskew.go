@@ -0,0 +1,65 @@
+package websocket
+
+import (
+	"sync"
+	"time"
+)
+
+// ClockSkew 与面板的时钟偏差测量结果
+type ClockSkew struct {
+	Offset     time.Duration // 面板时间减本地时间，正数表示本地时钟落后
+	RTT        time.Duration // 心跳往返时间，面板未回传 agent_time 时为 0
+	MeasuredAt time.Time
+}
+
+// Abs 返回偏差的绝对值
+func (s ClockSkew) Abs() time.Duration {
+	if s.Offset < 0 {
+		return -s.Offset
+	}
+	return s.Offset
+}
+
+type clockSkewState struct {
+	mu       sync.Mutex
+	skew     ClockSkew
+	measured bool
+}
+
+// RecordServerTime 根据面板心跳响应中的时间计算时钟偏差
+// agentTime 为面板回传的心跳发送时间（可为零值），用于扣除一半往返时间
+func (c *Client) RecordServerTime(serverTime, agentTime time.Time) ClockSkew {
+	now := time.Now()
+	skew := ClockSkew{Offset: serverTime.Sub(now), MeasuredAt: now}
+	if !agentTime.IsZero() {
+		if rtt := now.Sub(agentTime); rtt >= 0 && rtt < time.Minute {
+			skew.RTT = rtt
+			skew.Offset = serverTime.Sub(agentTime.Add(rtt / 2))
+		}
+	}
+
+	c.clockSkew.mu.Lock()
+	c.clockSkew.skew = skew
+	c.clockSkew.measured = true
+	c.clockSkew.mu.Unlock()
+	return skew
+}
+
+// ClockSkew 返回最近一次测得的时钟偏差，尚未测量时 ok 为 false
+func (c *Client) ClockSkew() (skew ClockSkew, ok bool) {
+	c.clockSkew.mu.Lock()
+	defer c.clockSkew.mu.Unlock()
+	return c.clockSkew.skew, c.clockSkew.measured
+}
+
+// heartbeatData 生成心跳数据：携带本地时间供面板回传，用于检测时钟偏差
+func (c *Client) heartbeatData() map[string]interface{} {
+	data := map[string]interface{}{}
+	if c.HeartbeatPayload != nil {
+		for key, value := range c.HeartbeatPayload() {
+			data[key] = value
+		}
+	}
+	data["agent_time"] = time.Now().UnixMilli()
+	return data
+}
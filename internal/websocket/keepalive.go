@@ -0,0 +1,67 @@
+package websocket
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// WebSocket 协议层保活参数
+const (
+	pingInterval     = 10 * time.Second // 发送 ping 的间隔
+	pongWait         = 10 * time.Second // 发送 ping 后等待 pong 的最长时间
+	closeAckWait     = time.Second      // 发送关闭帧后等待对端确认的时间
+	controlWriteWait = 5 * time.Second  // 控制帧写超时
+)
+
+// ReadTimeout 返回读取超时：超过该时间既没有收到消息也没有收到 pong，则认为连接已失效
+func (c *Client) ReadTimeout() time.Duration {
+	return pingInterval + pongWait
+}
+
+// setupKeepAlive 为新连接注册 pong/close 处理函数并启动 ping 协程
+func (c *Client) setupKeepAlive(conn *websocket.Conn) chan struct{} {
+	conn.SetReadDeadline(time.Now().Add(c.ReadTimeout()))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(c.ReadTimeout()))
+	})
+
+	// 收到对端关闭帧（或对端对我方关闭帧的确认）时通知 CloseWithReason
+	closeAck := make(chan struct{})
+	var once sync.Once
+	defaultCloseHandler := conn.CloseHandler()
+	conn.SetCloseHandler(func(code int, text string) error {
+		once.Do(func() { close(closeAck) })
+		return defaultCloseHandler(code, text)
+	})
+
+	go c.pingLoop(conn)
+	return closeAck
+}
+
+// pingLoop 定期发送 ping，连接被替换、关闭或写入失败时退出
+// 写入失败时关闭底层连接，使阻塞中的读取立即返回错误并触发重连
+func (c *Client) pingLoop(conn *websocket.Conn) {
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stopChan:
+			return
+		case <-ticker.C:
+			c.mu.Lock()
+			current := c.Conn == conn && c.connected
+			c.mu.Unlock()
+			if !current {
+				return
+			}
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(controlWriteWait)); err != nil {
+				c.Logger.Warn("发送 ping 失败，关闭连接: %v", err)
+				conn.Close()
+				return
+			}
+		}
+	}
+}
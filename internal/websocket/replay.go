@@ -0,0 +1,82 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ReplayProtectionCapability 防重放能力标识，在认证时声明，由面板在下发会话密钥时确认
+const ReplayProtectionCapability = "replay-protection-v1"
+
+// ErrReplayedFrame 重放、重复或乱序的加密帧
+var ErrReplayedFrame = errors.New("重放或乱序的加密帧")
+
+// sequencedFrame 启用防重放后加密前的明文格式
+// AEAD 标签保证帧内容不可篡改，序号保证已捕获的帧无法被重放
+type sequencedFrame struct {
+	Seq       uint64          `json:"seq"`
+	Timestamp int64           `json:"ts"`
+	Msg       json.RawMessage `json:"msg"`
+}
+
+// ErrReplayProtectionDowngrade 已协商的防重放被要求关闭
+var ErrReplayProtectionDowngrade = errors.New("防重放已协商启用，不允许关闭")
+
+// SetReplayProtection 启用或关闭防重放（面板确认支持后启用）
+// 双方协商启用后在本连接内强制生效，只有重新连接（丢弃会话密钥）才会重置
+func (c *Client) SetReplayProtection(enabled bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.replayProtection {
+		if !enabled {
+			return ErrReplayProtectionDowngrade
+		}
+		return nil
+	}
+	if enabled {
+		c.sendSeq = 0
+		c.recvSeq = 0
+		c.replayProtection = true
+	}
+	return nil
+}
+
+// IsReplayProtectionEnabled 是否已启用防重放
+func (c *Client) IsReplayProtectionEnabled() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.replayProtection
+}
+
+// sealSequence 为待发送消息附加递增序号（调用方需持有锁）
+func (c *Client) sealSequence(payload []byte) ([]byte, error) {
+	c.sendSeq++
+	return json.Marshal(sequencedFrame{
+		Seq:       c.sendSeq,
+		Timestamp: time.Now().UnixMilli(),
+		Msg:       payload,
+	})
+}
+
+// openSequence 校验解密后消息的序号，序号必须严格递增
+// 时间戳仅用于面板排查，不参与校验，避免时钟偏差导致误判
+func (c *Client) openSequence(payload []byte) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.replayProtection {
+		return payload, nil
+	}
+
+	var frame sequencedFrame
+	if err := json.Unmarshal(payload, &frame); err != nil || frame.Seq == 0 || len(frame.Msg) == 0 {
+		return nil, fmt.Errorf("%w: 缺少序号", ErrReplayedFrame)
+	}
+	if frame.Seq <= c.recvSeq {
+		return nil, fmt.Errorf("%w: seq=%d, 已接收=%d", ErrReplayedFrame, frame.Seq, c.recvSeq)
+	}
+	c.recvSeq = frame.Seq
+	return frame.Msg, nil
+}
@@ -0,0 +1,150 @@
+package websocket
+
+import (
+	"agent/internal/crypto"
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+// sealFrame 模拟面板发送一帧：附加序号后加密
+func sealFrame(t *testing.T, sender *Client, cipher string, key, payload []byte) []byte {
+	t.Helper()
+	sender.mu.Lock()
+	framed, err := sender.sealSequence(payload)
+	sender.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := crypto.EncryptWithCipher(cipher, framed, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sealed
+}
+
+func TestSequencedFrameRoundTrip(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 32)
+	for _, cipher := range []string{crypto.CipherAESGCM, crypto.CipherChaCha20Poly1305} {
+		t.Run(cipher, func(t *testing.T) {
+			sender := &Client{}
+			receiver := &Client{}
+			if err := sender.SetReplayProtection(true); err != nil {
+				t.Fatal(err)
+			}
+			if err := receiver.SetReplayProtection(true); err != nil {
+				t.Fatal(err)
+			}
+			receiver.EnableEncryptionWithCipher(key, cipher)
+
+			first := sealFrame(t, sender, cipher, key, []byte(`{"n":1}`))
+			second := sealFrame(t, sender, cipher, key, []byte(`{"n":2}`))
+
+			tests := []struct {
+				name    string
+				frame   []byte
+				want    string
+				wantErr error
+			}{
+				{"第一帧", first, `{"n":1}`, nil},
+				{"第二帧", second, `{"n":2}`, nil},
+				{"重放第二帧", second, "", ErrReplayedFrame},
+				{"乱序的第一帧", first, "", ErrReplayedFrame},
+			}
+			for _, tt := range tests {
+				decrypted, err := receiver.decryptWithFallback(tt.frame, key)
+				if err != nil {
+					t.Fatalf("%s: 解密失败: %v", tt.name, err)
+				}
+				got, err := receiver.openSequence(decrypted)
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("%s: err = %v, want %v", tt.name, err, tt.wantErr)
+				}
+				if tt.wantErr == nil && string(got) != tt.want {
+					t.Fatalf("%s: 消息 = %s, want %s", tt.name, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestOpenSequenceRejectsUnsequencedFrame(t *testing.T) {
+	receiver := &Client{}
+	if err := receiver.SetReplayProtection(true); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		payload string
+	}{
+		{"缺少序号包装", `{"type":"command"}`},
+		{"序号为零", `{"seq":0,"msg":{"type":"command"}}`},
+		{"缺少消息", `{"seq":1}`},
+		{"非 JSON", `not json`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := receiver.openSequence([]byte(tt.payload)); !errors.Is(err, ErrReplayedFrame) {
+				t.Fatalf("err = %v, want ErrReplayedFrame", err)
+			}
+		})
+	}
+}
+
+func TestReplayProtectionCannotBeDowngraded(t *testing.T) {
+	client := &Client{}
+	if err := client.SetReplayProtection(false); err != nil {
+		t.Fatalf("未启用时关闭不应报错: %v", err)
+	}
+	if err := client.SetReplayProtection(true); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.SetReplayProtection(false); !errors.Is(err, ErrReplayProtectionDowngrade) {
+		t.Fatalf("err = %v, want ErrReplayProtectionDowngrade", err)
+	}
+	if !client.IsReplayProtectionEnabled() {
+		t.Fatal("降级被拒绝后防重放应保持启用")
+	}
+
+	// 重新连接会丢弃会话密钥并重置防重放
+	client.mu.Lock()
+	client.resetSessionLocked()
+	client.mu.Unlock()
+	if client.IsReplayProtectionEnabled() || client.IsEncryptionEnabled() {
+		t.Fatal("重置后应关闭加密与防重放")
+	}
+}
+
+func TestDecryptWithFallbackUsesPreviousKey(t *testing.T) {
+	oldKey := bytes.Repeat([]byte{1}, 32)
+	newKey := bytes.Repeat([]byte{2}, 32)
+	inFlight, err := crypto.EncryptWithCipher(crypto.CipherAESGCM, []byte("old"), oldKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		grace   time.Duration
+		wantErr bool
+	}{
+		{"宽限期内可用旧密钥解密", time.Minute, false},
+		{"宽限期结束后拒绝旧密钥", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &Client{}
+			client.EnableEncryption(oldKey)
+			client.RotateSessionKey(newKey, crypto.CipherChaCha20Poly1305, tt.grace)
+
+			got, err := client.decryptWithFallback(inFlight, client.getSessionKey())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && string(got) != "old" {
+				t.Fatalf("解密结果 = %q, want %q", got, "old")
+			}
+		})
+	}
+}
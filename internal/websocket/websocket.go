@@ -2,10 +2,12 @@ package websocket
 
 import (
 	"agent/internal/crypto"
+	"agent/internal/fault"
 	"agent/internal/logger"
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -22,7 +24,7 @@ type Client struct {
 	API           string
 	Conn          *websocket.Conn
 	Logger        *logger.Logger
-	IsConnected   bool
+	connected     bool // 连接状态，读写需持有 mu，外部通过 IsConnected 读取
 	ReconnectWait time.Duration
 	MaxReconnect  int
 	mu            sync.Mutex
@@ -30,16 +32,39 @@ type Client struct {
 	// 加密相关字段
 	SessionKey        []byte // AES 会话密钥
 	EncryptionEnabled bool   // 是否启用加密
+	cipher            string // 加密算法，为空时使用 AES-256-GCM
+
+	// 密钥轮换：旧会话密钥在宽限期内仍可用于解密在途消息
+	previousSessionKey []byte
+	previousCipher     string
+	previousKeyExpiry  time.Time
+
+	// 防重放：协商启用后，加密帧携带单调递增的序号
+	replayProtection bool
+	sendSeq          uint64
+	recvSeq          uint64
+
+	// 对端关闭帧确认，用于完成关闭握手
+	closeAck chan struct{}
+
+	// 连接可用性统计
+	availability *availabilityTracker
+
+	// 与面板的时钟偏差
+	clockSkew clockSkewState
+
+	// HeartbeatPayload 可选，返回心跳消息额外携带的数据
+	HeartbeatPayload func() map[string]interface{}
 }
 
 func NewClient(api string, logger *logger.Logger) *Client {
 	return &Client{
 		API:           api,
 		Logger:        logger,
-		IsConnected:   false,
 		ReconnectWait: 5 * time.Second,
 		MaxReconnect:  5, // 最多重连5次
 		stopChan:      make(chan struct{}),
+		availability:  newAvailabilityTracker(),
 	}
 }
 
@@ -49,9 +74,15 @@ func (c *Client) Connect() error {
 		return fmt.Errorf("连接失败: %v", err)
 	}
 
+	closeAck := c.setupKeepAlive(conn)
+
 	c.mu.Lock()
 	c.Conn = conn
-	c.IsConnected = true
+	c.connected = true
+	c.availability.set(true)
+	c.closeAck = closeAck
+	// 序号与会话密钥绑定：新连接丢弃旧密钥（含宽限期内的旧密钥），重新认证后协商新密钥再从零计数
+	c.resetSessionLocked()
 	c.mu.Unlock()
 
 	return nil
@@ -97,7 +128,8 @@ func (c *Client) Reconnect() error {
 	if c.Conn != nil {
 		c.Conn.Close()
 	}
-	c.IsConnected = false
+	c.connected = false
+	c.availability.set(false)
 	c.mu.Unlock()
 
 	c.Logger.Warn("开始重新连接...")
@@ -115,7 +147,7 @@ func (c *Client) StartHeartbeat(ctx context.Context, healthChan chan<- bool, int
 	c.Logger.Info("心跳进程：已启动")
 
 	// 在开始前检查连接状态
-	if !c.IsConnected || c.Conn == nil {
+	if !c.IsConnected() || c.GetConnection() == nil {
 		c.Logger.Warn("心跳进程：WebSocket 未连接，等待连接...")
 		// 等待连接或 context 取消
 		select {
@@ -137,7 +169,7 @@ func (c *Client) StartHeartbeat(ctx context.Context, healthChan chan<- bool, int
 		select {
 		case <-ticker.C:
 			// 检查连接状态
-			if !c.IsConnected || c.Conn == nil {
+			if !c.IsConnected() || c.GetConnection() == nil {
 				c.Logger.Warn("心跳进程：连接已断开，等待重连...")
 				// 上报不健康状态
 				select {
@@ -161,7 +193,7 @@ func (c *Client) StartHeartbeat(ctx context.Context, healthChan chan<- bool, int
 						return
 					case <-checkTicker.C:
 						// 每5秒检查一次连接状态
-						if c.IsConnected && c.Conn != nil {
+						if c.IsConnected() && c.GetConnection() != nil {
 							checkTicker.Stop()
 							c.Logger.Info("心跳进程：连接已恢复，继续心跳")
 							goto continueHeartbeat
@@ -174,6 +206,7 @@ func (c *Client) StartHeartbeat(ctx context.Context, healthChan chan<- bool, int
 
 			heartbeatMessage := Message{
 				Type: "hello",
+				Data: c.heartbeatData(),
 			}
 			if err := c.SendMessage(heartbeatMessage); err != nil {
 				c.Logger.Error("心跳发送失败: %v", err)
@@ -211,7 +244,7 @@ func (c *Client) writePlainJSON(content interface{}) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	if !c.IsConnected || c.Conn == nil {
+	if !c.connected || c.Conn == nil {
 		return fmt.Errorf("未连接")
 	}
 
@@ -221,10 +254,17 @@ func (c *Client) writePlainJSON(content interface{}) error {
 		return err
 	}
 
+	// 故障注入：静默丢弃，模拟网络中丢失的消息
+	if fault.DropSend() {
+		return nil
+	}
+	data = fault.Corrupt(data)
+
 	err = c.Conn.WriteMessage(websocket.TextMessage, data)
 	if err != nil {
 		c.Logger.Error("发送消息时出错: %v", err)
-		c.IsConnected = false
+		c.connected = false
+		c.availability.set(false)
 		return err
 	}
 
@@ -236,7 +276,7 @@ func (c *Client) WriteEncryptedJSON(v interface{}) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	if !c.IsConnected || c.Conn == nil {
+	if !c.connected || c.Conn == nil {
 		return fmt.Errorf("未连接")
 	}
 
@@ -252,28 +292,62 @@ func (c *Client) WriteEncryptedJSON(v interface{}) error {
 		return err
 	}
 
-	// 使用 AES 加密
-	encryptedData, err := crypto.EncryptMessage(jsonData, sessionKey)
+	// 启用防重放时附加序号
+	if c.replayProtection {
+		jsonData, err = c.sealSequence(jsonData)
+		if err != nil {
+			return err
+		}
+	}
+
+	// 使用会话密钥加密
+	encryptedData, err := crypto.EncryptWithCipher(c.cipher, jsonData, sessionKey)
 	if err != nil {
 		return err
 	}
 
+	if fault.DropSend() {
+		return nil
+	}
+	encryptedData = fault.Corrupt(encryptedData)
+
 	// 直接发送二进制消息
 	err = c.Conn.WriteMessage(websocket.BinaryMessage, encryptedData)
 	if err != nil {
 		c.Logger.Error("发送加密消息时出错: %v", err)
-		c.IsConnected = false
+		c.connected = false
+		c.availability.set(false)
 		return err
 	}
 
 	return nil
 }
 
-// ReadEncryptedMessage 读取加密消息
+// ErrPlaintextFrame 启用加密后收到的未加密消息
+var ErrPlaintextFrame = errors.New("加密通信已启用，拒绝未加密的消息")
+
+// ReadEncryptedMessage 读取加密消息，重放、乱序或未加密的帧会被记录并丢弃
 func (c *Client) ReadEncryptedMessage() ([]byte, error) {
+	for {
+		message, err := c.readDecryptedFrame()
+		if errors.Is(err, ErrReplayedFrame) || errors.Is(err, ErrPlaintextFrame) {
+			c.Logger.Warn("丢弃消息: %v", err)
+			continue
+		}
+		return message, err
+	}
+}
+
+// readDecryptedFrame 读取并解密一帧消息
+func (c *Client) readDecryptedFrame() ([]byte, error) {
+	conn := c.GetConnection()
+	if conn == nil {
+		return nil, fmt.Errorf("未连接")
+	}
+
 	if !c.IsEncryptionEnabled() {
 		// 未启用加密，使用普通方式读取
-		_, message, err := c.Conn.ReadMessage()
+		_, message, err := conn.ReadMessage()
 		return message, err
 	}
 
@@ -284,18 +358,18 @@ func (c *Client) ReadEncryptedMessage() ([]byte, error) {
 	}
 
 	// 读取消息
-	messageType, message, err := c.Conn.ReadMessage()
+	messageType, message, err := conn.ReadMessage()
 	if err != nil {
 		return nil, err
 	}
 
 	// 如果是二进制消息，直接解密
 	if messageType == websocket.BinaryMessage {
-		decryptedData, err := crypto.DecryptMessage(message, sessionKey)
+		decryptedData, err := c.decryptWithFallback(message, sessionKey)
 		if err != nil {
 			return nil, err
 		}
-		return decryptedData, nil
+		return c.openSequence(decryptedData)
 	}
 
 	// 尝试解析为 JSON
@@ -312,25 +386,101 @@ func (c *Client) ReadEncryptedMessage() ([]byte, error) {
 			if err != nil {
 				return nil, err
 			}
-			decryptedData, err := crypto.DecryptMessage(encryptedData, sessionKey)
+			decryptedData, err := c.decryptWithFallback(encryptedData, sessionKey)
 			if err != nil {
 				return nil, err
 			}
-			return decryptedData, nil
+			return c.openSequence(decryptedData)
 		}
 	}
 
-	// 不是加密消息，直接返回原始数据
-	return message, nil
+	// 加密通信已启用，未加密的消息可能是伪造或降级攻击
+	return nil, ErrPlaintextFrame
 }
 
 // EnableEncryption 启用加密
 func (c *Client) EnableEncryption(sessionKey []byte) {
+	c.EnableEncryptionWithCipher(sessionKey, crypto.CipherAESGCM)
+}
+
+// EnableEncryptionWithCipher 使用指定算法启用加密
+func (c *Client) EnableEncryptionWithCipher(sessionKey []byte, cipher string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.SessionKey = make([]byte, len(sessionKey))
 	copy(c.SessionKey, sessionKey)
 	c.EncryptionEnabled = true
+	c.cipher = cipher
+}
+
+// Cipher 返回当前使用的加密算法
+func (c *Client) Cipher() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cipher == "" {
+		return crypto.CipherAESGCM
+	}
+	return c.cipher
+}
+
+// RotateSessionKey 原子切换到新的会话密钥和算法，旧密钥在 grace 时间内仍可用于解密
+func (c *Client) RotateSessionKey(sessionKey []byte, cipher string, grace time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.SessionKey != nil {
+		c.previousSessionKey = c.SessionKey
+		c.previousCipher = c.cipher
+		c.previousKeyExpiry = time.Now().Add(grace)
+	}
+	c.SessionKey = make([]byte, len(sessionKey))
+	copy(c.SessionKey, sessionKey)
+	c.EncryptionEnabled = true
+	c.cipher = cipher
+}
+
+// decryptWithFallback 使用当前会话密钥解密，失败时在宽限期内尝试旧密钥
+func (c *Client) decryptWithFallback(data, sessionKey []byte) ([]byte, error) {
+	cipher := c.Cipher()
+	decryptedData, err := crypto.DecryptWithCipher(cipher, data, sessionKey)
+	if err == nil {
+		return decryptedData, nil
+	}
+
+	c.mu.Lock()
+	previous := c.previousSessionKey
+	previousCipher := c.previousCipher
+	valid := previous != nil && time.Now().Before(c.previousKeyExpiry)
+	if previous != nil && !valid {
+		c.previousSessionKey = nil
+	}
+	c.mu.Unlock()
+
+	if valid {
+		if decrypted, prevErr := crypto.DecryptWithCipher(previousCipher, data, previous); prevErr == nil {
+			return decrypted, nil
+		}
+	}
+	return nil, err
+}
+
+// resetSessionLocked 清除会话密钥、宽限期旧密钥与防重放状态（调用方需持有锁）
+func (c *Client) resetSessionLocked() {
+	c.SessionKey = nil
+	c.EncryptionEnabled = false
+	c.cipher = ""
+	c.previousSessionKey = nil
+	c.previousCipher = ""
+	c.previousKeyExpiry = time.Time{}
+	c.replayProtection = false
+	c.sendSeq = 0
+	c.recvSeq = 0
+}
+
+// IsConnected 当前是否已连接
+func (c *Client) IsConnected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.connected
 }
 
 // IsEncryptionEnabled 检查是否启用加密
@@ -351,6 +501,11 @@ func (c *Client) getSessionKey() []byte {
 }
 
 func (c *Client) Close() {
+	c.CloseWithReason("")
+}
+
+// CloseWithReason 发送 WebSocket 关闭帧（正常关闭）后关闭连接
+func (c *Client) CloseWithReason(reason string) {
 	c.mu.Lock()
 	select {
 	case <-c.stopChan:
@@ -362,15 +517,40 @@ func (c *Client) Close() {
 	}
 	c.mu.Unlock()
 
+	c.mu.Lock()
+	conn := c.Conn
+	closeAck := c.closeAck
+	c.mu.Unlock()
+
+	// 关闭握手：发送关闭帧并短暂等待对端回应，再关闭底层连接
+	if conn != nil {
+		closeMessage := websocket.FormatCloseMessage(websocket.CloseNormalClosure, reason)
+		if err := conn.WriteControl(websocket.CloseMessage, closeMessage, time.Now().Add(controlWriteWait)); err != nil {
+			c.Logger.Debug("发送关闭帧失败: %v", err)
+		} else if closeAck != nil {
+			select {
+			case <-closeAck:
+			case <-time.After(closeAckWait):
+				c.Logger.Debug("等待对端关闭确认超时")
+			}
+		}
+	}
+
 	c.mu.Lock()
 	if c.Conn != nil {
 		c.Conn.Close()
 	}
-	c.IsConnected = false
+	c.connected = false
+	c.availability.set(false)
 	c.mu.Unlock()
 	c.Logger.Info("WebSocket 连接已关闭")
 }
 
+// Done 返回客户端停止时关闭的通道，供后台协程在等待时同时监听停止信号
+func (c *Client) Done() <-chan struct{} {
+	return c.stopChan
+}
+
 // IsStopped 检查客户端是否已停止
 func (c *Client) IsStopped() bool {
 	select {
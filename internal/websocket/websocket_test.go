@@ -0,0 +1,64 @@
+package websocket
+
+import (
+	"agent/internal/logger"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// echoServer 接受连接并丢弃收到的所有消息
+func echoServer(t *testing.T) string {
+	t.Helper()
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+// 心跳与重连同时进行时连接状态的读写必须加锁（配合 go test -race）
+func TestHeartbeatDuringReconnect(t *testing.T) {
+	client := NewClient(echoServer(t), logger.NewConsoleLogger(io.Discard))
+	client.ReconnectWait = 10 * time.Millisecond
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		client.StartHeartbeat(ctx, make(chan bool, 16), 5*time.Millisecond)
+	}()
+
+	for i := 0; i < 5; i++ {
+		if err := client.Reconnect(); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+	client.Close()
+
+	if client.IsConnected() {
+		t.Fatal("关闭后仍显示已连接")
+	}
+}